@@ -0,0 +1,242 @@
+// Package aurweb performs the account actions of the AUR website.
+package aurweb
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// sessionCookie holds the session id, which is also the form token.
+const sessionCookie = "AURSID"
+
+var (
+	errorListRe = regexp.MustCompile(`(?s)<ul class="errorlist">(.*?)</ul>`)
+	tagRe       = regexp.MustCompile(`<[^>]*>`)
+	spaceRe     = regexp.MustCompile(`\s+`)
+)
+
+// Session is a login to the AUR. The login cookie is cached in CachePath
+// and the password is only asked for when the cookie expired.
+type Session struct {
+	URL       string
+	User      string
+	Password  func() (string, error)
+	CachePath string
+
+	client *http.Client
+	base   *url.URL
+}
+
+type cachedSession struct {
+	User string `json:"user"`
+	SID  string `json:"sid"`
+}
+
+// NewSession returns a session for the AUR at aurURL. Requests go through
+// the transport of client.
+func NewSession(client *http.Client, aurURL, user string, password func() (string, error), cachePath string) (*Session, error) {
+	if user == "" {
+		return nil, text.ErrT("no AUR user set, see --auruser")
+	}
+
+	base, err := url.Parse(strings.TrimRight(aurURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{
+		URL:       base.String(),
+		User:      user,
+		Password:  password,
+		CachePath: cachePath,
+		client:    &http.Client{Transport: client.Transport, Timeout: client.Timeout, Jar: jar},
+		base:      base,
+	}
+	s.loadCookie()
+
+	return s, nil
+}
+
+func (s *Session) loadCookie() {
+	data, err := ioutil.ReadFile(s.CachePath)
+	if err != nil {
+		return
+	}
+
+	var cached cachedSession
+	if json.Unmarshal(data, &cached) != nil || cached.User != s.User || cached.SID == "" {
+		return
+	}
+
+	s.client.Jar.SetCookies(s.base, []*http.Cookie{{Name: sessionCookie, Value: cached.SID, Path: "/"}})
+}
+
+func (s *Session) saveCookie() error {
+	data, err := json.Marshal(cachedSession{User: s.User, SID: s.sid()})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.CachePath), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.CachePath, data, 0o600)
+}
+
+func (s *Session) sid() string {
+	for _, cookie := range s.client.Jar.Cookies(s.base) {
+		if cookie.Name == sessionCookie {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// page fetches a page of the AUR and fails on error statuses and on pages
+// reporting errors.
+func (s *Session) page(req *http.Request) (string, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errors.New(text.Tf("%s was not found", req.URL.Path))
+	}
+	if resp.StatusCode >= 400 {
+		return "", errors.New(text.Tf("%s failed: %s", req.URL.Path, resp.Status))
+	}
+
+	if m := errorListRe.FindStringSubmatch(string(body)); m != nil {
+		msg := html.UnescapeString(tagRe.ReplaceAllString(m[1], " "))
+		return "", errors.New(strings.TrimSpace(spaceRe.ReplaceAllString(msg, " ")))
+	}
+
+	return string(body), nil
+}
+
+func (s *Session) get(path string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, s.URL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	return s.page(req)
+}
+
+func (s *Session) post(path string, form url.Values) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, s.URL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return s.page(req)
+}
+
+func loggedIn(page string) bool {
+	return strings.Contains(page, `href="/logout`)
+}
+
+// Login logs in with the password and caches the session cookie.
+func (s *Session) Login() error {
+	password, err := s.Password()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.post("/login", url.Values{
+		"user":        {s.User},
+		"passwd":      {password},
+		"remember_me": {"on"},
+		"next":        {"/"},
+	})
+	if err != nil {
+		return errors.New(text.Tf("AUR login failed: %s", err))
+	}
+	if s.sid() == "" {
+		return errors.New(text.Tf("AUR login failed: %s", text.T("no session cookie")))
+	}
+
+	return s.saveCookie()
+}
+
+// act posts an action to a page of a package base, logging in first if the
+// cached session expired.
+func (s *Session) act(pkgbase, page string, form url.Values) error {
+	path := "/pkgbase/" + url.PathEscape(pkgbase) + "/"
+
+	current, err := s.get(path)
+	if err != nil {
+		return err
+	}
+
+	if !loggedIn(current) {
+		if err := s.Login(); err != nil {
+			return err
+		}
+		if current, err = s.get(path); err != nil {
+			return err
+		}
+		if !loggedIn(current) {
+			return errors.New(text.Tf("AUR login failed: %s", text.T("the session was not accepted")))
+		}
+	}
+
+	form.Set("token", s.sid())
+	_, err = s.post(path+page, form)
+	return err
+}
+
+// Vote votes for a package base.
+func (s *Session) Vote(pkgbase string) error {
+	return s.act(pkgbase, "", url.Values{"do_Vote": {"1"}})
+}
+
+// Unvote removes the vote for a package base.
+func (s *Session) Unvote(pkgbase string) error {
+	return s.act(pkgbase, "", url.Values{"do_UnVote": {"1"}})
+}
+
+// Notify enables the comment notifications of a package base.
+func (s *Session) Notify(pkgbase string) error {
+	return s.act(pkgbase, "", url.Values{"do_Notify": {"1"}})
+}
+
+// Unnotify disables the comment notifications of a package base.
+func (s *Session) Unnotify(pkgbase string) error {
+	return s.act(pkgbase, "", url.Values{"do_UnNotify": {"1"}})
+}
+
+// Adopt adopts an orphaned package base.
+func (s *Session) Adopt(pkgbase string) error {
+	return s.act(pkgbase, "", url.Values{"do_Adopt": {"1"}})
+}
+
+// Flag flags a package base out of date. The AUR requires a comment.
+func (s *Session) Flag(pkgbase, comment string) error {
+	if strings.TrimSpace(comment) == "" {
+		return text.ErrT("flagging a package out of date needs a comment, see --reason")
+	}
+	return s.act(pkgbase, "flag/", url.Values{"do_Flag": {"1"}, "comments": {comment}})
+}
@@ -0,0 +1,115 @@
+package aurweb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAUR is a stand-in for the account pages of the AUR.
+type fakeAUR struct {
+	sessions map[string]bool
+	actions  []string
+}
+
+func (f *fakeAUR) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(sessionCookie)
+	session := err == nil && f.sessions[cookie.Value]
+
+	switch r.URL.Path {
+	case "/":
+		w.WriteHeader(http.StatusOK)
+	case "/login":
+		if r.PostFormValue("user") != "jguer" || r.PostFormValue("passwd") != "secret" {
+			_, _ = w.Write([]byte(`<ul class="errorlist"><li>Bad username or password.</li></ul>`))
+			return
+		}
+		f.sessions["sid1"] = true
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "sid1", Path: "/"})
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	case "/pkgbase/yay/", "/pkgbase/yay/flag/":
+		if r.Method == http.MethodGet {
+			if session {
+				_, _ = w.Write([]byte(`<a href="/logout/">Logout</a>`))
+			}
+			return
+		}
+		if !session || r.PostFormValue("token") != cookie.Value {
+			_, _ = w.Write([]byte(`<ul class="errorlist"><li>You must be logged in.</li></ul>`))
+			return
+		}
+		for _, action := range []string{"do_Vote", "do_UnVote", "do_Notify", "do_UnNotify", "do_Adopt", "do_Flag"} {
+			if r.PostFormValue(action) != "" {
+				f.actions = append(f.actions, action+r.PostFormValue("comments"))
+			}
+		}
+		http.Redirect(w, r, "/pkgbase/yay/", http.StatusSeeOther)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-aurweb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	aur := &fakeAUR{sessions: map[string]bool{}}
+	server := httptest.NewServer(aur)
+	defer server.Close()
+
+	asked := 0
+	password := func() (string, error) {
+		asked++
+		return "secret", nil
+	}
+	cachePath := filepath.Join(dir, "aursession.json")
+
+	s, err := NewSession(server.Client(), server.URL+"/", "jguer", password, cachePath)
+	require.NoError(t, err)
+	require.NoError(t, s.Vote("yay"))
+	require.NoError(t, s.Notify("yay"))
+	assert.Equal(t, 1, asked)
+	assert.FileExists(t, cachePath)
+
+	// the cached cookie is reused
+	s, err = NewSession(server.Client(), server.URL, "jguer", password, cachePath)
+	require.NoError(t, err)
+	require.NoError(t, s.Flag("yay", "1.2 is out"))
+	assert.Error(t, s.Flag("yay", " "))
+	assert.Equal(t, 1, asked)
+
+	// an expired session logs in again
+	aur.sessions = map[string]bool{}
+	require.NoError(t, s.Unvote("yay"))
+	assert.Equal(t, 2, asked)
+
+	assert.Equal(t, []string{"do_Vote", "do_Notify", "do_Flag1.2 is out", "do_UnVote"}, aur.actions)
+
+	assert.Error(t, s.Adopt("missing"))
+
+	_, err = NewSession(server.Client(), server.URL, "", password, cachePath)
+	assert.Error(t, err)
+}
+
+func TestSession_BadPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-aurweb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(&fakeAUR{sessions: map[string]bool{}})
+	defer server.Close()
+
+	password := func() (string, error) { return "wrong", nil }
+	s, err := NewSession(server.Client(), server.URL, "jguer", password, filepath.Join(dir, "aursession.json"))
+	require.NoError(t, err)
+
+	err = s.Vote("yay")
+	assert.EqualError(t, err, "AUR login failed: Bad username or password.")
+}
@@ -0,0 +1,122 @@
+// Package comments reads the comments of AUR packages from their web page.
+package comments
+
+import (
+	"errors"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/news"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Comment is a comment on an AUR package. Content is html.
+type Comment struct {
+	ID      string
+	Header  string
+	Content string
+	Pinned  bool
+}
+
+var (
+	// every comment list of the page starts with one of these
+	sectionRe = regexp.MustCompile(`<div class="comments package-comments">`)
+	commentRe = regexp.MustCompile(`(?s)<h4 id="comment-(\d+)"[^>]*>(.*?)</h4>\s*` +
+		`<div id="comment-\d+-content"[^>]*>\s*<div>(.*?)</div>\s*</div>`)
+	tagRe   = regexp.MustCompile(`<[^>]*>`)
+	spaceRe = regexp.MustCompile(`\s+`)
+)
+
+// Parse finds the comments on an AUR package page. Pinned comments come
+// first as they do on the page.
+func Parse(page string) []Comment {
+	comments := make([]Comment, 0)
+
+	bounds := sectionRe.FindAllStringIndex(page, -1)
+	for i, bound := range bounds {
+		end := len(page)
+		if i+1 < len(bounds) {
+			end = bounds[i+1][0]
+		}
+		section := page[bound[1]:end]
+
+		header := section
+		if h := strings.Index(section, "</h3>"); h != -1 {
+			header = section[:h]
+		}
+		pinned := strings.Contains(header, "Pinned Comments")
+
+		for _, m := range commentRe.FindAllStringSubmatch(section, -1) {
+			comments = append(comments, Comment{
+				ID:      m[1],
+				Header:  headerText(m[2]),
+				Content: strings.TrimSpace(m[3]),
+				Pinned:  pinned,
+			})
+		}
+	}
+
+	return comments
+}
+
+// headerText turns the html of a comment header into one line of text.
+func headerText(header string) string {
+	header = html.UnescapeString(tagRe.ReplaceAllString(header, ""))
+	return strings.TrimSpace(spaceRe.ReplaceAllString(header, " "))
+}
+
+// Fetch fetches the comments of a package base from the AUR at aurURL.
+func Fetch(httpGet news.HttpGetter, aurURL, pkgbase string) (comments []Comment, err error) {
+	resp, err := httpGet.Get(strings.TrimRight(aurURL, "/") + "/pkgbase/" + url.PathEscape(pkgbase))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err2 := resp.Body.Close()
+		if err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.New(text.Tf("package base '%s' was not found in the AUR", pkgbase))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(text.Tf("failed to fetch comments of %s: %s", pkgbase, resp.Status))
+	}
+
+	page, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(string(page)), nil
+}
+
+// Print prints the pinned comments and up to latest other comments. A
+// negative latest prints all of them.
+func Print(comments []Comment, latest int) {
+	for i := range comments {
+		c := &comments[i]
+		if !c.Pinned {
+			if latest == 0 {
+				break
+			}
+			latest--
+		}
+
+		header := c.Header
+		if c.Pinned {
+			header = text.T("(Pinned)") + " " + header
+		}
+		text.Println(text.Bold(text.Magenta(header)))
+		// the reset ending the text would be put on a line of its own
+		content := strings.TrimSuffix(news.ParseHTML(c.Content), text.ResetCode)
+		text.Println(strings.TrimSpace(content))
+		text.Println()
+	}
+}
@@ -0,0 +1,95 @@
+package comments
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+const samplePage = `<div id="pkgdetails" class="box">yay</div>
+<div class="comments package-comments">
+	<div class="comments-header">
+		<h3>
+			<span class="text">Pinned Comments</span>
+		</h3>
+	</div>
+	<h4 id="comment-761023" class="comment-header">
+		<a href="/account/jguer">jguer</a> commented on <a href="#comment-761023" class="date">2020-09-12 10:30 (UTC)</a>
+	</h4>
+	<div id="comment-761023-content" class="article-content">
+		<div>
+			<p>Report issues on <a href="https://github.com/Jguer/yay/issues">GitHub</a>.</p>
+		</div>
+	</div>
+</div>
+<div class="comments package-comments">
+	<div class="comments-header">
+		<h3>
+			<span class="text">Latest Comments</span>
+		</h3>
+	</div>
+	<h4 id="comment-790001" class="comment-header">
+		<a href="/account/someone">someone</a> commented on <a href="#comment-790001" class="date">2021-03-01 08:00 (UTC)</a>
+	</h4>
+	<div id="comment-790001-content" class="article-content">
+		<div>
+			<p>Fails to build with go 1.16: <code>go: cannot find main module</code></p>
+		</div>
+	</div>
+	<h4 id="comment-789000" class="comment-header">
+		<a href="/account/other">other</a> commented on <a href="#comment-789000" class="date">2021-02-20 18:12 (UTC)</a>
+	</h4>
+	<div id="comment-789000-content" class="article-content">
+		<div>
+			<p>Works &amp; thanks</p>
+		</div>
+	</div>
+</div>`
+
+func TestParse(t *testing.T) {
+	comments := Parse(samplePage)
+	require.Len(t, comments, 3)
+
+	assert.Equal(t, "761023", comments[0].ID)
+	assert.True(t, comments[0].Pinned)
+	assert.Equal(t, "jguer commented on 2020-09-12 10:30 (UTC)", comments[0].Header)
+	assert.Equal(t, "790001", comments[1].ID)
+	assert.False(t, comments[1].Pinned)
+	assert.Equal(t, "<p>Works &amp; thanks</p>", comments[2].Content)
+
+	assert.Empty(t, Parse("<html>no comments</html>"))
+}
+
+func TestFetch(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://aur.archlinux.org").Get("/pkgbase/yay").Reply(200).BodyString(samplePage)
+	gock.New("https://aur.archlinux.org").Get("/pkgbase/missing").Reply(404)
+
+	comments, err := Fetch(http.DefaultClient, "https://aur.archlinux.org/", "yay")
+	require.NoError(t, err)
+	assert.Len(t, comments, 3)
+
+	_, err = Fetch(http.DefaultClient, "https://aur.archlinux.org", "missing")
+	assert.Error(t, err)
+}
+
+func TestPrint(t *testing.T) {
+	text.UseColor = false
+	defer func() { text.UseColor = true }()
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, nil, func() {
+		Print(Parse(samplePage), 1)
+	})
+
+	assert.Equal(t, "(Pinned) jguer commented on 2020-09-12 10:30 (UTC)\n"+
+		"Report issues on GitHub.\n\n"+
+		"someone commented on 2021-03-01 08:00 (UTC)\n"+
+		"Fails to build with go 1.16: go: cannot find main module\n\n", buf.String())
+}
@@ -9,7 +9,10 @@ import (
 type IPackage = alpm.IPackage
 type Depend = alpm.Depend
 
-const PkgReasonExplicit = alpm.PkgReasonExplicit
+const (
+	PkgReasonExplicit = alpm.PkgReasonExplicit
+	PkgReasonDepend   = alpm.PkgReasonDepend
+)
 
 func VerCmp(a, b string) int {
 	return alpm.VerCmp(a, b)
@@ -6,7 +6,17 @@ import (
 	"github.com/Jguer/yay/v10/pkg/db"
 )
 
-type DBMock struct{}
+// DBMock is an empty db.Executor. Local and Sync hold the installed and
+// the sync packages when a test needs some, Depends, OptDepends and
+// Provides their relations by package name.
+type DBMock struct {
+	Local []db.IPackage
+	Sync  []db.IPackage
+
+	Depends    map[string][]db.Depend
+	OptDepends map[string][]db.Depend
+	Provides   map[string][]db.Depend
+}
 
 var _ db.Executor = &DBMock{}
 
@@ -19,22 +29,35 @@ func (m *DBMock) SetHideMenus(bool) {}
 
 func (m *DBMock) Cleanup() {}
 
-func (m *DBMock) BiggestPackages() []db.IPackage                 { return nil }
-func (m *DBMock) IsCorrectVersionInstalled(string, string) bool  { return false }
-func (m *DBMock) LastBuildTime() time.Time                       { return time.Time{} }
-func (m *DBMock) LocalPackage(string) db.IPackage                { return nil }
-func (m *DBMock) LocalPackages() []db.IPackage                   { return nil }
-func (m *DBMock) LocalSatisfierExists(string) bool               { return false }
-func (m *DBMock) PackageConflicts(db.IPackage) []db.Depend       { return nil }
-func (m *DBMock) PackageDepends(db.IPackage) []db.Depend         { return nil }
-func (m *DBMock) SatisfierFromDB(string, string) db.IPackage     { return nil }
-func (m *DBMock) PackageGroups(db.IPackage) []string             { return nil }
-func (m *DBMock) PackageOptionalDepends(db.IPackage) []db.Depend { return nil }
-func (m *DBMock) PackageProvides(db.IPackage) []db.Depend        { return nil }
-func (m *DBMock) PackagesFromGroup(string) []db.IPackage         { return nil }
-func (m *DBMock) RefreshHandle() error                           { return nil }
-func (m *DBMock) RepoUpgrades(bool) ([]db.Upgrade, error)        { return nil, nil }
-func (m *DBMock) SyncPackage(string) db.IPackage                 { return nil }
-func (m *DBMock) SyncPackages(...string) []db.IPackage           { return nil }
-func (m *DBMock) SyncSatisfier(string) db.IPackage               { return nil }
-func (m *DBMock) SyncSatisfierExists(string) bool                { return false }
+func (m *DBMock) BiggestPackages() []db.IPackage                { return nil }
+func (m *DBMock) IsCorrectVersionInstalled(string, string) bool { return false }
+func (m *DBMock) LastBuildTime() time.Time                      { return time.Time{} }
+func (m *DBMock) LocalPackages() []db.IPackage                  { return m.Local }
+func (m *DBMock) LocalSatisfierExists(string) bool              { return false }
+func (m *DBMock) PackageConflicts(db.IPackage) []db.Depend      { return nil }
+func (m *DBMock) SatisfierFromDB(string, string) db.IPackage    { return nil }
+func (m *DBMock) PackageGroups(db.IPackage) []string            { return nil }
+func (m *DBMock) PackagesFromGroup(string) []db.IPackage        { return nil }
+func (m *DBMock) RefreshHandle() error                          { return nil }
+func (m *DBMock) RepoUpgrades(bool) ([]db.Upgrade, error)       { return nil, nil }
+func (m *DBMock) SyncPackage(string) db.IPackage                { return nil }
+func (m *DBMock) SyncPackages(...string) []db.IPackage          { return m.Sync }
+func (m *DBMock) SyncSatisfier(string) db.IPackage              { return nil }
+func (m *DBMock) SyncSatisfierExists(string) bool               { return false }
+
+func (m *DBMock) LocalPackage(name string) db.IPackage {
+	for _, pkg := range m.Local {
+		if pkg.Name() == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+func (m *DBMock) PackageDepends(pkg db.IPackage) []db.Depend { return m.Depends[pkg.Name()] }
+
+func (m *DBMock) PackageOptionalDepends(pkg db.IPackage) []db.Depend {
+	return m.OptDepends[pkg.Name()]
+}
+
+func (m *DBMock) PackageProvides(pkg db.IPackage) []db.Depend { return m.Provides[pkg.Name()] }
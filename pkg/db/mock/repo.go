@@ -6,6 +6,46 @@ import (
 	alpm "github.com/Jguer/go-alpm/v2"
 )
 
+type DB struct {
+	DBName string
+}
+
+var _ alpm.IDB = &DB{}
+
+func (d *DB) Name() string { return d.DBName }
+
+func (d *DB) Unregister() error {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *DB) Servers() []string {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *DB) SetServers(servers []string) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *DB) AddServer(server string) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *DB) SetUsage(usage alpm.Usage) {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *DB) Pkg(name string) alpm.IPackage {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *DB) PkgCache() alpm.IPackageList {
+	panic("not implemented") // TODO: Implement
+}
+
+func (d *DB) Search([]string) alpm.IPackageList {
+	panic("not implemented") // TODO: Implement
+}
+
 type Package struct {
 	PBase         string
 	PBuildDate    time.Time
@@ -17,6 +57,7 @@ type Package struct {
 	PSize         int64
 	PVersion      string
 	PReason       alpm.PkgReason
+	PURL          string
 }
 
 var _ alpm.IPackage = &Package{}
@@ -144,9 +185,7 @@ func (p *Package) Replaces() alpm.DependList {
 }
 
 // URL returns the upstream URL of the package.
-func (p *Package) URL() string {
-	panic("not implemented") // TODO: Implement
-}
+func (p *Package) URL() string { return p.PURL }
 
 // ComputeRequiredBy returns the names of reverse dependencies of a package
 func (p *Package) ComputeRequiredBy() []string {
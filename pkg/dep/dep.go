@@ -8,7 +8,7 @@ import (
 	"github.com/Jguer/yay/v10/pkg/text"
 )
 
-func less(q []*rpc.Pkg, lookfor string) func(i, j int) bool {
+func less(q []*rpc.Pkg, lookfor string, priority func(string) int) func(i, j int) bool {
 	return func(i, j int) bool {
 		if lookfor == q[i].Name {
 			return true
@@ -18,6 +18,10 @@ func less(q []*rpc.Pkg, lookfor string) func(i, j int) bool {
 			return false
 		}
 
+		if pi, pj := priority(q[i].Name), priority(q[j].Name); pi != pj {
+			return pi < pj
+		}
+
 		return text.LessRunes([]rune(q[i].Name), []rune(q[j].Name))
 	}
 }
@@ -20,18 +20,28 @@ type Target struct {
 	Name    string
 	Mod     string
 	Version string
+	// Source is set if DB names a PKGBUILD source instead of a sync
+	// database.
+	Source string
 }
 
-func ToTarget(pkg string) Target {
+// ToTarget parses a target, a db/ prefix naming one of sources becomes its
+// Source.
+func ToTarget(pkg string, sources *query.Sources) Target {
 	dbName, depString := text.SplitDBFromName(pkg)
 	name, mod, depVersion := splitDep(depString)
 
-	return Target{
+	target := Target{
 		DB:      dbName,
 		Name:    name,
 		Mod:     mod,
 		Version: depVersion,
 	}
+	if sources.IsSourceName(dbName) {
+		target.Source = dbName
+	}
+
+	return target
 }
 
 func (t Target) DepString() string {
@@ -54,7 +64,7 @@ type Pool struct {
 	aurCache     map[string]*query.Pkg
 	Groups       []string
 	alpmExecutor db.Executor
-	aur          *query.AUR
+	aur          query.AURBackend
 	warnings     *query.AURWarnings
 }
 
@@ -70,10 +80,11 @@ func (dp *Pool) ResolveTargets(
 	// call
 	aurTargets := stringset.Make()
 
-	pkgs = query.RemoveInvalidTargets(pkgs, mode)
+	sources, _ := dp.aur.(*query.Sources)
+	pkgs = query.RemoveInvalidTargets(pkgs, mode, sources)
 
 	for _, pkg := range pkgs {
-		target := ToTarget(pkg)
+		target := ToTarget(pkg, sources)
 
 		// skip targets already satisfied
 		// even if the user enters db/pkg and aur/pkg the latter will
@@ -86,8 +97,11 @@ func (dp *Pool) ResolveTargets(
 
 		var foundPkg db.IPackage
 
-		// aur/ prefix means we only check the aur
-		if target.DB == "aur" || mode == settings.ModeAUR {
+		// aur/ or another source prefix means we only check that source
+		if target.Source != "" || mode == settings.ModeAUR {
+			if target.Source != "" && sources != nil {
+				sources.Pin(target.Name, target.Source)
+			}
 			dp.targets = append(dp.targets, target)
 			aurTargets.Set(target.DepString())
 			continue
@@ -164,12 +178,17 @@ func (dp *Pool) findProvides(pkgs stringset.StringSet) error {
 		// java-envronment we can search for just java instead and get
 		// more hits.
 		pkg, _, _ = splitDep(pkg) // openimagedenoise-git > ispc-git #1234
-		words := strings.Split(pkg, "-")
 
-		for i := range words {
-			results, err = dp.aur.Search(strings.Join(words[:i+1], "-"))
-			if err == nil {
-				break
+		if finder, ok := dp.aur.(query.ProviderFinder); ok {
+			results, err = finder.Providers(pkg)
+		} else {
+			words := strings.Split(pkg, "-")
+
+			for i := range words {
+				results, err = dp.aur.Search(strings.Join(words[:i+1], "-"))
+				if err == nil {
+					break
+				}
 			}
 		}
 
@@ -335,7 +354,7 @@ func GetPool(
 	pkgs []string,
 	warnings *query.AURWarnings,
 	dbExecutor db.Executor,
-	aur *query.AUR,
+	aur query.AURBackend,
 	mode settings.TargetMode,
 	ignoreProviders, noConfirm, provides bool,
 	rebuild string, splitN int,
@@ -430,13 +449,22 @@ func (dp *Pool) findSatisfierAurCache(dep string, ignoreProviders, noConfirm, pr
 	}
 
 	if len(providerSlice) > 1 {
-		sort.Slice(providerSlice, less(providerSlice, depName))
+		sort.Slice(providerSlice, less(providerSlice, depName, dp.priority))
 		return providerMenu(dep, providerSlice, noConfirm)
 	}
 
 	return nil
 }
 
+// priority ranks a package by the PKGBUILD source it was found in, lower
+// is preferred.
+func (dp *Pool) priority(name string) int {
+	if sources, ok := dp.aur.(*query.Sources); ok {
+		return sources.Priority(name)
+	}
+	return 0
+}
+
 func (dp *Pool) findSatisfierRepo(dep string) db.IPackage {
 	for _, pkg := range dp.repo {
 		if satisfiesRepo(dep, pkg, dp.alpmExecutor) {
@@ -0,0 +1,303 @@
+// Package diff parses the unified diffs of PKGBUILD repositories and renders
+// them with bash syntax highlighting.
+package diff
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// LineKind tells how a line of a hunk changed.
+type LineKind int
+
+const (
+	Context LineKind = iota
+	Added
+	Removed
+	// Whitespace is an added line that only changed its indentation or
+	// trailing whitespace. It follows the WhitespaceOld lines it replaces.
+	Whitespace
+	// WhitespaceOld is the old version of a Whitespace line.
+	WhitespaceOld
+)
+
+type Line struct {
+	Kind LineKind
+	Text string
+}
+
+type Hunk struct {
+	Header string
+	Lines  []Line
+}
+
+// File is the diff of one file. Old or New is /dev/null for created and
+// deleted files, Extra holds git's extended header lines such as mode
+// changes and renames.
+type File struct {
+	Old   string
+	New   string
+	Extra []string
+	Hunks []Hunk
+}
+
+// Path returns the name of the file in the newer version, or in the older
+// version if it was deleted.
+func (f *File) Path() string {
+	if f.New == "" || f.New == "/dev/null" {
+		return f.Old
+	}
+	return f.New
+}
+
+func trimPrefix(name, prefix string) string {
+	if name == "/dev/null" {
+		return name
+	}
+	return strings.TrimPrefix(name, prefix)
+}
+
+// Parse parses the output of git diff run with the a/ and b/ prefixes.
+func Parse(unified string) []File {
+	files := make([]File, 0)
+	var file *File
+	var hunk *Hunk
+
+	for _, line := range strings.Split(unified, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, File{})
+			file = &files[len(files)-1]
+			hunk = nil
+
+			// used when there are no ---/+++ lines, as for mode changes
+			if names := strings.SplitN(strings.TrimPrefix(line, "diff --git "), " b/", 2); len(names) == 2 {
+				file.Old = trimPrefix(names[0], "a/")
+				file.New = names[1]
+			}
+		case file == nil:
+			continue
+		case hunk == nil && strings.HasPrefix(line, "--- "):
+			file.Old = trimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case hunk == nil && strings.HasPrefix(line, "+++ "):
+			file.New = trimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@"):
+			file.Hunks = append(file.Hunks, Hunk{Header: line})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk == nil:
+			if line != "" && !strings.HasPrefix(line, "index ") {
+				file.Extra = append(file.Extra, line)
+			}
+		case strings.HasPrefix(line, "+"):
+			hunk.Lines = append(hunk.Lines, Line{Added, line[1:]})
+		case strings.HasPrefix(line, "-"):
+			hunk.Lines = append(hunk.Lines, Line{Removed, line[1:]})
+		case strings.HasPrefix(line, " "):
+			hunk.Lines = append(hunk.Lines, Line{Context, line[1:]})
+		}
+	}
+
+	for i := range files {
+		for j := range files[i].Hunks {
+			files[i].Hunks[j].Lines = collapseWhitespace(files[i].Hunks[j].Lines)
+		}
+	}
+
+	return files
+}
+
+// sameTrimmed reports whether old and new only differ in leading and
+// trailing whitespace line by line. Whitespace within a line can change the
+// meaning of a shell command and is never ignored.
+func sameTrimmed(old, new []Line) bool {
+	if len(old) != len(new) {
+		return false
+	}
+	for i := range old {
+		if strings.TrimSpace(old[i].Text) != strings.TrimSpace(new[i].Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// collapseWhitespace marks each block of removed lines followed by as many
+// added lines only differing in indentation or trailing whitespace as
+// WhitespaceOld and Whitespace.
+func collapseWhitespace(lines []Line) []Line {
+	collapsed := make([]Line, 0, len(lines))
+
+	for i := 0; i < len(lines); {
+		if lines[i].Kind != Removed {
+			collapsed = append(collapsed, lines[i])
+			i++
+			continue
+		}
+
+		removed := i
+		for i < len(lines) && lines[i].Kind == Removed {
+			i++
+		}
+		added := i
+		for i < len(lines) && lines[i].Kind == Added {
+			i++
+		}
+
+		if sameTrimmed(lines[removed:added], lines[added:i]) {
+			for _, line := range lines[removed:added] {
+				collapsed = append(collapsed, Line{WhitespaceOld, line.Text})
+			}
+			for _, line := range lines[added:i] {
+				collapsed = append(collapsed, Line{Whitespace, line.Text})
+			}
+			continue
+		}
+		collapsed = append(collapsed, lines[removed:i]...)
+	}
+
+	return collapsed
+}
+
+// Render writes files with bash syntax highlighting. Changed lines of the
+// source and checksum arrays are marked.
+func Render(w io.Writer, dir string, files []File) {
+	for i := range files {
+		f := &files[i]
+
+		header := dir + f.Path()
+		switch {
+		case f.Old == "/dev/null":
+			header += " " + text.T("(new file)")
+		case f.New == "/dev/null":
+			header += " " + text.T("(deleted)")
+		case f.Old != f.New:
+			header = dir + f.Old + " -> " + header
+		}
+		io.WriteString(w, text.Bold(text.Cyan("==> "+header))+"\n")
+
+		for _, extra := range f.Extra {
+			if strings.HasPrefix(extra, "rename ") || strings.HasPrefix(extra, "similarity ") {
+				continue
+			}
+			io.WriteString(w, text.Blue(extra)+"\n")
+		}
+
+		for j := range f.Hunks {
+			renderHunk(w, &f.Hunks[j])
+		}
+	}
+}
+
+func renderHunk(w io.Writer, h *Hunk) {
+	io.WriteString(w, text.Cyan(h.Header)+"\n")
+
+	// whether the old and new version are in a source or checksum array
+	var oldArray, newArray string
+
+	for _, line := range h.Lines {
+		var array string
+		switch line.Kind {
+		case Context:
+			array = newArray
+			newArray = arrayAfter(newArray, line.Text)
+			oldArray = arrayAfter(oldArray, line.Text)
+		case Added, Whitespace:
+			array = newArray
+			newArray = arrayAfter(newArray, line.Text)
+		case Removed, WhitespaceOld:
+			array = oldArray
+			oldArray = arrayAfter(oldArray, line.Text)
+		}
+		if array == "" {
+			array = arrayOf(line.Text)
+		}
+
+		switch line.Kind {
+		case Context:
+			io.WriteString(w, " "+Highlight(line.Text)+"\n")
+		case Whitespace:
+			io.WriteString(w, text.Blue("~")+Highlight(line.Text)+"\n")
+		case WhitespaceOld:
+			io.WriteString(w, text.Blue("-")+Highlight(line.Text)+"\n")
+		case Added, Removed:
+			marker := text.Bold(text.Green("+"))
+			if line.Kind == Removed {
+				marker = text.Bold(text.Red("-"))
+			}
+
+			if array == "" {
+				io.WriteString(w, marker+Highlight(line.Text)+"\n")
+				continue
+			}
+
+			note := text.T("source changed")
+			if array != "source" {
+				note = text.T("checksum changed")
+			}
+			io.WriteString(w, marker+text.Bold(line.Text)+"  "+text.Bold(text.Red("<- "+note))+"\n")
+		}
+	}
+}
+
+// arrayOf returns source or sums when line assigns the source array or a
+// checksum array.
+func arrayOf(line string) string {
+	line = strings.TrimSpace(line)
+	eq := strings.Index(line, "=")
+	if eq == -1 {
+		return ""
+	}
+
+	name := strings.TrimSuffix(line[:eq], "+")
+	if i := strings.Index(name, "_"); i != -1 && name != "source" {
+		// source_x86_64, sha256sums_x86_64
+		name = name[:i]
+	}
+
+	switch {
+	case name == "source":
+		return "source"
+	case strings.HasSuffix(name, "sums") && strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789") == "":
+		return "sums"
+	}
+	return ""
+}
+
+// arrayAfter returns the array a multi-line assignment continues after line,
+// given the one it was in before.
+func arrayAfter(array, line string) string {
+	if array == "" {
+		array = arrayOf(line)
+		if array == "" {
+			return ""
+		}
+		if i := strings.Index(line, "="); !strings.HasPrefix(strings.TrimSpace(line[i+1:]), "(") {
+			return ""
+		}
+	}
+
+	if strings.Contains(stripComment(line), ")") {
+		return ""
+	}
+	return array
+}
+
+func stripComment(line string) string {
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '#' && (i == 0 || unicode.IsSpace(rune(line[i-1]))):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+const unified = `diff --git a/PKGBUILD b/PKGBUILD
+index 1111111..2222222 100644
+--- a/PKGBUILD
++++ b/PKGBUILD
+@@ -1,9 +1,9 @@
+ pkgname=foo
+-pkgver=1.0
++pkgver=1.1
+ source=("https://example.org/foo-$pkgver.tar.gz"
+-        "foo.patch")
+-sha256sums=('1111'
++        "https://example.net/foo.patch")
++sha256sums=('2222'
+             'SKIP')
+ package() {
+-    make install
++	make install
+ }
+diff --git a/foo.install b/foo.install
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/foo.install
+@@ -0,0 +1 @@
++post_install() { echo hi; }
+\ No newline at end of file
+`
+
+func TestParse(t *testing.T) {
+	files := Parse(unified)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "PKGBUILD", files[0].Old)
+	assert.Equal(t, "PKGBUILD", files[0].Path())
+	assert.Empty(t, files[0].Extra)
+	require.Len(t, files[0].Hunks, 1)
+	assert.Equal(t, []Line{
+		{Context, "pkgname=foo"},
+		{Removed, "pkgver=1.0"},
+		{Added, "pkgver=1.1"},
+		{Context, `source=("https://example.org/foo-$pkgver.tar.gz"`},
+		{Removed, `        "foo.patch")`},
+		{Removed, `sha256sums=('1111'`},
+		{Added, `        "https://example.net/foo.patch")`},
+		{Added, `sha256sums=('2222'`},
+		{Context, `            'SKIP')`},
+		{Context, "package() {"},
+		{WhitespaceOld, "    make install"},
+		{Whitespace, "\tmake install"},
+		{Context, "}"},
+	}, files[0].Hunks[0].Lines)
+
+	assert.Equal(t, "/dev/null", files[1].Old)
+	assert.Equal(t, "foo.install", files[1].Path())
+	assert.Equal(t, []string{"new file mode 100644"}, files[1].Extra)
+}
+
+func TestRender(t *testing.T) {
+	defer func(useColor bool) { text.UseColor = useColor }(text.UseColor)
+	text.UseColor = false
+
+	var b strings.Builder
+	Render(&b, "/build/foo/", Parse(unified))
+	lines := strings.Split(b.String(), "\n")
+
+	assert.Equal(t, "==> /build/foo/PKGBUILD", lines[0])
+	assert.Equal(t, "-pkgver=1.0", lines[3])
+	assert.Equal(t, `-        "foo.patch")  <- source changed`, lines[6])
+	assert.Equal(t, `+sha256sums=('2222'  <- checksum changed`, lines[9])
+	assert.Equal(t, "-    make install", lines[12])
+	assert.Equal(t, "~\tmake install", lines[13])
+	assert.Equal(t, "==> /build/foo/foo.install (new file)", lines[15])
+}
+
+func TestCollapseWhitespace(t *testing.T) {
+	lines := func(kinds ...interface{}) []Line {
+		l := make([]Line, 0, len(kinds)/2)
+		for i := 0; i < len(kinds); i += 2 {
+			l = append(l, Line{kinds[i].(LineKind), kinds[i+1].(string)})
+		}
+		return l
+	}
+
+	// indentation and trailing whitespace only
+	assert.Equal(t, lines(WhitespaceOld, "  cd foo", WhitespaceOld, "make ", Whitespace, "\tcd foo", Whitespace, "\tmake"),
+		collapseWhitespace(lines(Removed, "  cd foo", Removed, "make ", Added, "\tcd foo", Added, "\tmake")))
+
+	// whitespace within a line changes the command
+	for _, change := range [][2]string{
+		{"rm -rf $pkgdir/usr", "rm -rf $pkgdir /usr"},
+		{"a=b", "a= b"},
+		{`echo "a  b"`, `echo "a b"`},
+	} {
+		changed := lines(Removed, change[0], Added, change[1])
+		assert.Equal(t, changed, collapseWhitespace(changed))
+	}
+
+	// lines moved between each other are not whitespace changes
+	joined := lines(Removed, "foo \\", Removed, "  bar", Added, "foo bar")
+	assert.Equal(t, joined, collapseWhitespace(joined))
+}
+
+func TestHighlight(t *testing.T) {
+	defer func(useColor bool) { text.UseColor = useColor }(text.UseColor)
+
+	text.UseColor = false
+	line := `  if [ -n "$foo" ]; then cd "${srcdir}/x" # build`
+	assert.Equal(t, line, Highlight(line))
+
+	text.UseColor = true
+	assert.Equal(t, "  "+text.Bold("if")+` [ -n `+text.Magenta(`"`)+text.Cyan("$foo")+text.Magenta(`"`)+
+		` ]; `+text.Bold("then")+` cd `+text.Magenta(`"`)+text.Cyan("${srcdir}")+text.Magenta(`/x"`)+
+		" "+text.Blue("# build"), Highlight(line))
+	assert.Equal(t, "url="+text.Magenta("'https://example.org/#x'"), Highlight("url='https://example.org/#x'"))
+}
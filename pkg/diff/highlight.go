@@ -0,0 +1,153 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+var keywords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "fi": true,
+	"for": true, "in": true, "do": true, "done": true, "while": true, "until": true,
+	"case": true, "esac": true, "function": true, "select": true,
+	"local": true, "return": true, "export": true, "declare": true, "readonly": true,
+}
+
+func isWordRune(r byte) bool {
+	return r == '_' || r == '-' || r == '.' || r == '/' || r == '+' ||
+		'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+}
+
+func isNameRune(r byte) bool {
+	return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+}
+
+// variable returns the length of the parameter expansion at the start of s,
+// or 0 if there is none.
+func variable(s string) int {
+	if len(s) < 2 || s[0] != '$' {
+		return 0
+	}
+
+	switch {
+	case s[1] == '{':
+		if end := strings.IndexByte(s, '}'); end != -1 {
+			return end + 1
+		}
+		return len(s)
+	case s[1] == '(':
+		return 0
+	case isNameRune(s[1]):
+		n := 1
+		for n < len(s) && isNameRune(s[n]) {
+			n++
+		}
+		return n
+	case strings.IndexByte("@*#?$!0123456789", s[1]) != -1:
+		return 2
+	}
+	return 0
+}
+
+// doubleQuoted highlights the double quoted string at the start of s and
+// returns its length.
+func doubleQuoted(b *strings.Builder, s string) int {
+	start := 0
+	n := 1
+	for n < len(s) {
+		switch {
+		case s[n] == '\\' && n+1 < len(s):
+			n += 2
+			continue
+		case s[n] == '"':
+			n++
+			b.WriteString(text.Magenta(s[start:n]))
+			return n
+		}
+
+		if v := variable(s[n:]); v > 0 {
+			b.WriteString(text.Magenta(s[start:n]))
+			b.WriteString(text.Cyan(s[n : n+v]))
+			n += v
+			start = n
+			continue
+		}
+		n++
+	}
+
+	b.WriteString(text.Magenta(s[start:]))
+	return len(s)
+}
+
+// Highlight returns a line of bash with comments, strings, parameter
+// expansions and keywords coloured. Strings spanning several lines are only
+// coloured on their first line.
+func Highlight(line string) string {
+	if !text.UseColor {
+		return line
+	}
+
+	var b strings.Builder
+	// whether a word can start at i, so # starts a comment and words are
+	// keywords
+	wordStart := true
+
+	for i := 0; i < len(line); {
+		c := line[i]
+
+		switch {
+		case c == '#' && wordStart:
+			b.WriteString(text.Blue(line[i:]))
+			return b.String()
+		case c == '\\' && i+1 < len(line):
+			b.WriteString(line[i : i+2])
+			i += 2
+			wordStart = false
+			continue
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end == -1 {
+				b.WriteString(text.Magenta(line[i:]))
+				return b.String()
+			}
+			b.WriteString(text.Magenta(line[i : i+end+2]))
+			i += end + 2
+			wordStart = false
+			continue
+		case c == '"':
+			i += doubleQuoted(&b, line[i:])
+			wordStart = false
+			continue
+		}
+
+		if v := variable(line[i:]); v > 0 {
+			b.WriteString(text.Cyan(line[i : i+v]))
+			i += v
+			wordStart = false
+			continue
+		}
+
+		if wordStart && isWordRune(c) {
+			n := i
+			for n < len(line) && isWordRune(line[n]) {
+				n++
+			}
+			word := line[i:n]
+			if keywords[word] {
+				b.WriteString(text.Bold(word))
+			} else {
+				b.WriteString(word)
+			}
+			i = n
+			wordStart = false
+			continue
+		}
+
+		b.WriteByte(c)
+		wordStart = unicode.IsSpace(rune(c)) || strings.IndexByte(";&|(){}", c) != -1
+		i++
+	}
+
+	return b.String()
+}
@@ -0,0 +1,162 @@
+package hold
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Hold keeps a package from being upgraded.
+//
+// With Version set only that exact new version is skipped, with Until set
+// the package is held until that unix time. A hold with neither set is kept
+// forever. Auto holds are placed by yay itself and released once their
+// cause is gone.
+type Hold struct {
+	Version string `json:"version,omitempty"`
+	Until   int64  `json:"until,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Auto    bool   `json:"auto,omitempty"`
+}
+
+// Active reports whether the hold prevents an upgrade to version at now.
+func (h Hold) Active(version string, now time.Time) bool {
+	switch {
+	case h.Version != "":
+		return h.Version == version
+	case h.Until != 0:
+		return now.Unix() < h.Until
+	default:
+		return true
+	}
+}
+
+// Expired reports whether the hold can never be active again. Version holds
+// never expire as the held version may still show up.
+func (h Hold) Expired(now time.Time) bool {
+	return h.Version == "" && h.Until != 0 && now.Unix() >= h.Until
+}
+
+func (h Hold) String() string {
+	var s string
+	switch {
+	case h.Version != "":
+		s = text.Tf("skip version %s", h.Version)
+	case h.Until != 0:
+		s = text.Tf("until %s", text.FormatTime(int(h.Until)))
+	default:
+		s = text.T("forever")
+	}
+
+	if h.Reason != "" {
+		s += " (" + h.Reason + ")"
+	}
+	return s
+}
+
+// Store is the list of holds by package name backed by a json file.
+type Store struct {
+	Holds    map[string]Hold
+	FilePath string
+}
+
+func NewStore(filePath string) *Store {
+	return &Store{
+		Holds:    map[string]Hold{},
+		FilePath: filePath,
+	}
+}
+
+// Held returns the hold of pkg if it prevents an upgrade to version. It is
+// safe to call on a nil Store.
+func (s *Store) Held(pkg, version string) (Hold, bool) {
+	if s == nil {
+		return Hold{}, false
+	}
+
+	h, ok := s.Holds[pkg]
+	if !ok || !h.Active(version, time.Now()) {
+		return Hold{}, false
+	}
+	return h, true
+}
+
+// Set adds or replaces the hold of pkg.
+func (s *Store) Set(pkg string, h Hold) {
+	s.Holds[pkg] = h
+}
+
+// SetAuto places an auto hold on pkg unless it is already held. It reports
+// whether a hold was added.
+func (s *Store) SetAuto(pkg, reason string) bool {
+	if _, ok := s.Holds[pkg]; ok {
+		return false
+	}
+	s.Holds[pkg] = Hold{Reason: reason, Auto: true}
+	return true
+}
+
+// ReleaseAuto removes the hold of pkg if it is an auto hold and reports
+// whether it did.
+func (s *Store) ReleaseAuto(pkg string) bool {
+	if h, ok := s.Holds[pkg]; !ok || !h.Auto {
+		return false
+	}
+	delete(s.Holds, pkg)
+	return true
+}
+
+// Remove deletes the holds of pkgs and reports whether any was removed.
+func (s *Store) Remove(pkgs ...string) bool {
+	removed := false
+	for _, pkg := range pkgs {
+		if _, ok := s.Holds[pkg]; ok {
+			delete(s.Holds, pkg)
+			removed = true
+		}
+	}
+	return removed
+}
+
+func (s *Store) Save() error {
+	marshalledinfo, err := json.MarshalIndent(s.Holds, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.FilePath), 0o755); err != nil {
+		return err
+	}
+	in, err := os.OpenFile(s.FilePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if _, err = in.Write(append(marshalledinfo, '\n')); err != nil {
+		return err
+	}
+	return in.Sync()
+}
+
+func (s *Store) Load() error {
+	hfile, err := os.Open(s.FilePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(text.Tf("failed to open hold file '%s': %s", s.FilePath, err))
+	}
+	defer hfile.Close()
+
+	if err = json.NewDecoder(hfile).Decode(&s.Holds); err != nil {
+		return fmt.Errorf(text.Tf("failed to read hold file '%s': %s", s.FilePath, err))
+	}
+	// a file containing null leaves no map
+	if s.Holds == nil {
+		s.Holds = make(map[string]Hold)
+	}
+	return nil
+}
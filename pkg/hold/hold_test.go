@@ -0,0 +1,88 @@
+package hold
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHold_Active(t *testing.T) {
+	now := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		hold    Hold
+		version string
+		want    bool
+		expired bool
+	}{
+		{name: "forever", hold: Hold{Reason: "broken"}, version: "2.0-1", want: true},
+		{name: "version match", hold: Hold{Version: "2.0-1"}, version: "2.0-1", want: true},
+		{name: "version mismatch", hold: Hold{Version: "2.0-1"}, version: "2.0-2", want: false},
+		{name: "until future", hold: Hold{Until: now.Add(time.Hour).Unix()}, version: "2.0-1", want: true},
+		{name: "until past", hold: Hold{Until: now.Add(-time.Hour).Unix()}, version: "2.0-1", want: false, expired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.hold.Active(tt.version, now))
+			assert.Equal(t, tt.expired, tt.hold.Expired(now))
+		})
+	}
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-hold")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the config dir may not exist yet
+	path := filepath.Join(dir, "yay", "holds.json")
+
+	s := NewStore(path)
+	s.Set("foo", Hold{Version: "1.1-1"})
+	s.Set("bar", Hold{Reason: "breaks plugins"})
+	require.NoError(t, s.Save())
+
+	l := NewStore(path)
+	require.NoError(t, l.Load())
+	assert.Equal(t, s.Holds, l.Holds)
+
+	_, held := l.Held("foo", "1.1-1")
+	assert.True(t, held)
+	_, held = l.Held("foo", "1.2-1")
+	assert.False(t, held)
+
+	assert.True(t, l.Remove("foo", "baz"))
+	assert.False(t, l.Remove("baz"))
+
+	var nilStore *Store
+	_, held = nilStore.Held("bar", "1.0-1")
+	assert.False(t, held)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("null\n"), 0o644))
+	l = NewStore(path)
+	require.NoError(t, l.Load())
+	l.Set("foo", Hold{})
+	assert.Len(t, l.Holds, 1)
+}
+
+func TestStore_Auto(t *testing.T) {
+	s := NewStore("")
+	s.Set("foo", Hold{Reason: "manual"})
+
+	assert.False(t, s.SetAuto("foo", "orphaned"))
+	assert.True(t, s.SetAuto("bar", "orphaned"))
+	assert.False(t, s.SetAuto("bar", "removed from the AUR"))
+	assert.Equal(t, Hold{Reason: "orphaned", Auto: true}, s.Holds["bar"])
+
+	assert.False(t, s.ReleaseAuto("foo"))
+	assert.True(t, s.ReleaseAuto("bar"))
+	assert.False(t, s.ReleaseAuto("bar"))
+	assert.Contains(t, s.Holds, "foo")
+}
@@ -42,7 +42,7 @@ func (item *item) print(buildTime time.Time, all, quiet bool) {
 	text.Println(text.Bold(text.Magenta(fd)), text.Bold(strings.TrimSpace(item.Title)))
 
 	if !quiet {
-		desc := strings.TrimSpace(parseNews(item.Description))
+		desc := strings.TrimSpace(ParseHTML(item.Description))
 		text.Println(desc)
 	}
 }
@@ -93,10 +93,11 @@ func PrintNewsFeed(httpGet HttpGetter, cutOffDate time.Time, sortMode int, all,
 	return nil
 }
 
-// Crude html parsing, good enough for the arch news
+// ParseHTML turns html into terminal text. The parsing is crude, good
+// enough for the arch news and AUR comments.
 // This is only displayed in the terminal so there should be no security
 // concerns
-func parseNews(str string) string {
+func ParseHTML(str string) string {
 	var buffer bytes.Buffer
 	var tagBuffer bytes.Buffer
 	var escapeBuffer bytes.Buffer
@@ -109,9 +110,13 @@ func parseNews(str string) string {
 				inTag = false
 				switch tagBuffer.String() {
 				case "code":
-					buffer.WriteString(text.CyanCode)
+					if text.UseColor {
+						buffer.WriteString(text.CyanCode)
+					}
 				case "/code":
-					buffer.WriteString(text.ResetCode)
+					if text.UseColor {
+						buffer.WriteString(text.ResetCode)
+					}
 				case "/p":
 					buffer.WriteRune('\n')
 				}
@@ -152,6 +157,8 @@ func parseNews(str string) string {
 		buffer.WriteRune(char)
 	}
 
-	buffer.WriteString(text.ResetCode)
+	if text.UseColor {
+		buffer.WriteString(text.ResetCode)
+	}
 	return buffer.String()
 }
@@ -0,0 +1,222 @@
+// Package output writes package data for scripts instead of people.
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+const (
+	JSON = "json"
+	TSV  = "tsv"
+)
+
+// Package holds the data of a repo or an AUR package. The json names and
+// the field names used by templates are stable; fields a package does not
+// have are left empty. Times are unix timestamps, 0 when unknown.
+type Package struct {
+	Repository     string   `json:"repository"`
+	Name           string   `json:"name"`
+	Base           string   `json:"base"`
+	Version        string   `json:"version"`
+	Installed      string   `json:"installed"`
+	Description    string   `json:"description"`
+	URL            string   `json:"url"`
+	Maintainer     string   `json:"maintainer"`
+	Votes          int      `json:"votes"`
+	Popularity     float64  `json:"popularity"`
+	OutOfDate      int64    `json:"outofdate"`
+	FirstSubmitted int64    `json:"firstsubmitted"`
+	LastModified   int64    `json:"lastmodified"`
+	BuildDate      int64    `json:"builddate"`
+	Size           int64    `json:"size"`
+	ISize          int64    `json:"isize"`
+	Groups         []string `json:"groups"`
+	Licenses       []string `json:"licenses"`
+	Keywords       []string `json:"keywords"`
+	Provides       []string `json:"provides"`
+	Depends        []string `json:"depends"`
+	MakeDepends    []string `json:"makedepends"`
+	CheckDepends   []string `json:"checkdepends"`
+	OptDepends     []string `json:"optdepends"`
+	Conflicts      []string `json:"conflicts"`
+	Replaces       []string `json:"replaces"`
+}
+
+// normalize keeps empty lists from turning into json nulls.
+func (p Package) normalize() Package {
+	for _, l := range []*[]string{
+		&p.Groups, &p.Licenses, &p.Keywords, &p.Provides, &p.Depends,
+		&p.MakeDepends, &p.CheckDepends, &p.OptDepends, &p.Conflicts, &p.Replaces,
+	} {
+		if *l == nil {
+			*l = []string{}
+		}
+	}
+	return p
+}
+
+func depends(deps []db.Depend) []string {
+	s := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		s = append(s, dep.String())
+	}
+	return s
+}
+
+func installed(dbExecutor db.Executor, name string) string {
+	if pkg := dbExecutor.LocalPackage(name); pkg != nil {
+		return pkg.Version()
+	}
+	return ""
+}
+
+// FromAUR converts a package of the AUR or of another PKGBUILD source.
+func FromAUR(pkg *query.Pkg, repo string, dbExecutor db.Executor) Package {
+	return Package{
+		Repository:     repo,
+		Name:           pkg.Name,
+		Base:           pkg.PackageBase,
+		Version:        pkg.Version,
+		Installed:      installed(dbExecutor, pkg.Name),
+		Description:    pkg.Description,
+		URL:            pkg.URL,
+		Maintainer:     pkg.Maintainer,
+		Votes:          pkg.NumVotes,
+		Popularity:     pkg.Popularity,
+		OutOfDate:      int64(pkg.OutOfDate),
+		FirstSubmitted: int64(pkg.FirstSubmitted),
+		LastModified:   int64(pkg.LastModified),
+		Groups:         pkg.Groups,
+		Licenses:       pkg.License,
+		Keywords:       pkg.Keywords,
+		Provides:       pkg.Provides,
+		Depends:        pkg.Depends,
+		MakeDepends:    pkg.MakeDepends,
+		CheckDepends:   pkg.CheckDepends,
+		OptDepends:     pkg.OptDepends,
+		Conflicts:      pkg.Conflicts,
+		Replaces:       pkg.Replaces,
+	}.normalize()
+}
+
+// FromRepo converts a sync package.
+func FromRepo(pkg db.IPackage, dbExecutor db.Executor) Package {
+	var buildDate int64
+	if !pkg.BuildDate().IsZero() {
+		buildDate = pkg.BuildDate().Unix()
+	}
+
+	return Package{
+		Repository:  pkg.DB().Name(),
+		Name:        pkg.Name(),
+		Base:        pkg.Base(),
+		Version:     pkg.Version(),
+		Installed:   installed(dbExecutor, pkg.Name()),
+		Description: pkg.Description(),
+		URL:         pkg.URL(),
+		BuildDate:   buildDate,
+		Size:        pkg.Size(),
+		ISize:       pkg.ISize(),
+		Groups:      dbExecutor.PackageGroups(pkg),
+		Provides:    depends(dbExecutor.PackageProvides(pkg)),
+		Depends:     depends(dbExecutor.PackageDepends(pkg)),
+		OptDepends:  depends(dbExecutor.PackageOptionalDepends(pkg)),
+		Conflicts:   depends(dbExecutor.PackageConflicts(pkg)),
+	}.normalize()
+}
+
+// FromUpgrade converts an upgrade. Version is the new version.
+func FromUpgrade(u *db.Upgrade) Package {
+	return Package{
+		Repository: u.Repository,
+		Name:       u.Name,
+		Version:    u.RemoteVersion,
+		Installed:  u.LocalVersion,
+	}.normalize()
+}
+
+// Formatter writes packages as a json array, as tab separated lines of
+// repository, name, version, installed version and description or through
+// a text/template run for every package.
+type Formatter struct {
+	format string
+	tmpl   *template.Template
+}
+
+// New returns the Formatter of a --format value.
+func New(format string) (*Formatter, error) {
+	f := &Formatter{format: format}
+
+	switch format {
+	case "":
+		return nil, text.ErrT("no output format given")
+	case JSON, TSV:
+		return f, nil
+	}
+
+	tmpl, err := template.New("format").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(format)
+	if err != nil {
+		return nil, errors.New(text.Tf("invalid output format: %s", err))
+	}
+	f.tmpl = tmpl
+
+	return f, nil
+}
+
+// tsvField keeps tabs and newlines of a value from breaking the columns.
+var tsvField = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// Write writes pkgs to w.
+func (f *Formatter) Write(w io.Writer, pkgs []Package) error {
+	switch f.format {
+	case JSON:
+		if pkgs == nil {
+			pkgs = []Package{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(pkgs)
+	case TSV:
+		for i := range pkgs {
+			pkg := &pkgs[i]
+			fields := []string{pkg.Repository, pkg.Name, pkg.Version, pkg.Installed, pkg.Description}
+			for j := range fields {
+				fields[j] = tsvField.Replace(fields[j])
+			}
+			if _, err := io.WriteString(w, strings.Join(fields, "\t")+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var buf strings.Builder
+	for i := range pkgs {
+		buf.Reset()
+		if err := f.tmpl.Execute(&buf, &pkgs[i]); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(buf.String(), "\n") {
+			buf.WriteByte('\n')
+		}
+		if _, err := io.WriteString(w, buf.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
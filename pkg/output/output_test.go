@@ -0,0 +1,83 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/db/mock"
+	"github.com/Jguer/yay/v10/pkg/query"
+)
+
+func testPackages() []Package {
+	dbExecutor := &mock.DBMock{Local: []db.IPackage{
+		&mock.Package{PName: "yay", PVersion: "10.1-1"},
+	}}
+
+	return []Package{
+		FromRepo(&mock.Package{
+			PName: "go", PBase: "go", PVersion: "2:1.16-1", PDescription: "Go\ttools",
+			PDB: &mock.DB{DBName: "community"}, PSize: 100, PISize: 400,
+			PBuildDate: time.Unix(1614556800, 0), PURL: "https://golang.org",
+		}, dbExecutor),
+		FromAUR(&query.Pkg{
+			Name: "yay", PackageBase: "yay", Version: "10.2-1", Description: "AUR helper",
+			Maintainer: "jguer", NumVotes: 1500, Popularity: 40.5, Depends: []string{"git"},
+		}, "aur", dbExecutor),
+		FromUpgrade(&db.Upgrade{Name: "git", Repository: "extra", LocalVersion: "2.30-1", RemoteVersion: "2.31-1"}),
+	}
+}
+
+func TestFormatter_TSV(t *testing.T) {
+	f, err := New(TSV)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, f.Write(buf, testPackages()))
+	assert.Equal(t, "community\tgo\t2:1.16-1\t\tGo tools\n"+
+		"aur\tyay\t10.2-1\t10.1-1\tAUR helper\n"+
+		"extra\tgit\t2.31-1\t2.30-1\t\n", buf.String())
+}
+
+func TestFormatter_JSON(t *testing.T) {
+	f, err := New(JSON)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, f.Write(buf, testPackages()[2:]))
+	assert.JSONEq(t, `[{
+		"repository": "extra", "name": "git", "base": "", "version": "2.31-1",
+		"installed": "2.30-1", "description": "", "url": "", "maintainer": "",
+		"votes": 0, "popularity": 0, "outofdate": 0, "firstsubmitted": 0,
+		"lastmodified": 0, "builddate": 0, "size": 0, "isize": 0,
+		"groups": [], "licenses": [], "keywords": [], "provides": [], "depends": [],
+		"makedepends": [], "checkdepends": [], "optdepends": [], "conflicts": [],
+		"replaces": []
+	}]`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, f.Write(buf, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestFormatter_Template(t *testing.T) {
+	f, err := New(`{{.Repository}}/{{.Name}} {{.Votes}} {{join .Depends ","}} {{json .URL}}`)
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, f.Write(buf, testPackages()[:2]))
+	assert.Equal(t, "community/go 0  \"https://golang.org\"\naur/yay 1500 git \"\"\n", buf.String())
+
+	_, err = New("{{.Name")
+	assert.Error(t, err)
+	_, err = New("")
+	assert.Error(t, err)
+
+	f, err = New("{{.Color}}")
+	require.NoError(t, err)
+	assert.Error(t, f.Write(buf, testPackages()))
+}
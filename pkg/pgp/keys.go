@@ -3,7 +3,12 @@ package pgp
 import (
 	"bytes"
 	"errors"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	gosrc "github.com/Morganamilo/go-srcinfo"
@@ -31,42 +36,31 @@ func (set pgpKeySet) set(key string, p dep.Base) {
 	set[upperKey] = append(set[upperKey], p)
 }
 
-func (set pgpKeySet) get(key string) bool {
-	upperKey := strings.ToUpper(key)
-	_, exists := set[upperKey]
-	return exists
-}
-
 // CheckPgpKeys iterates through the keys listed in the PKGBUILDs and if needed,
-// asks the user whether yay should try to import them.
+// asks the user whether yay should try to import them. Missing keys are
+// looked for in keyDir, then in the keys/pgp directory of the package bases
+// in buildDir, then through the Web Key Directory and at last on the
+// keyservers.
 func CheckPgpKeys(bases []dep.Base, srcinfos map[string]*gosrc.Srcinfo,
-	gpgBin, gpgFlags string, noConfirm bool) error {
-	// Let's check the keys individually, and then we can offer to import
-	// the problematic ones.
-	problematic := make(pgpKeySet)
-	args := append(strings.Fields(gpgFlags), "--list-keys")
-
+	gpgBin, gpgFlags, keyDir, buildDir string, noConfirm bool) error {
 	// Mapping all the keys.
+	keys := make(pgpKeySet)
 	for _, base := range bases {
 		pkg := base.Pkgbase()
 		srcinfo := srcinfos[pkg]
 
 		for _, key := range srcinfo.ValidPGPKeys {
-			// If key already marked as problematic, indicate the current
-			// PKGBUILD requires it.
-			if problematic.get(key) {
-				problematic.set(key, base)
-				continue
-			}
-
-			cmd := exec.Command(gpgBin, append(args, key)...)
-			err := cmd.Run()
-			if err != nil {
-				problematic.set(key, base)
-			}
+			keys.set(key, base)
 		}
 	}
 
+	// Let's check the keys all at once, and then we can offer to import
+	// the problematic ones.
+	problematic := make(pgpKeySet)
+	for _, key := range missingKeys(keys.toSlice(), gpgBin, gpgFlags) {
+		problematic[key] = keys[key]
+	}
+
 	// No key issues!
 	if len(problematic) == 0 {
 		return nil
@@ -80,10 +74,158 @@ func CheckPgpKeys(bases []dep.Base, srcinfos map[string]*gosrc.Srcinfo,
 	text.Println()
 	text.Println(str)
 
-	if text.ContinueTask(text.T("Import?"), true, noConfirm) {
-		return importKeys(problematic.toSlice(), gpgBin, gpgFlags)
+	if !text.ContinueTask(text.T("Import?"), true, noConfirm) {
+		return nil
+	}
+
+	dirs := []func(pkgbase string) string{
+		func(string) string { return keyDir },
+		func(pkgbase string) string {
+			if buildDir == "" {
+				return ""
+			}
+			return filepath.Join(buildDir, pkgbase, "keys", "pgp")
+		},
+	}
+	for _, dir := range dirs {
+		for _, file := range keyFiles(problematic, dir) {
+			if err := importKeyFile(file, gpgBin, gpgFlags); err != nil {
+				text.Warnln(err)
+			}
+		}
+		if problematic = stillMissing(problematic, gpgBin, gpgFlags); len(problematic) == 0 {
+			return nil
+		}
+	}
+
+	for key, emails := range keyEmails(problematic, buildDir) {
+		if err := locateKey(key, emails, gpgBin, gpgFlags); err != nil {
+			text.Warnln(err)
+		}
+	}
+	if problematic = stillMissing(problematic, gpgBin, gpgFlags); len(problematic) == 0 {
+		return nil
+	}
+
+	return importKeys(problematic.toSlice(), gpgBin, gpgFlags)
+}
+
+// missingKeys returns the keys not in the keyring. All keys are checked by
+// a single gpg call.
+func missingKeys(keys []string, gpgBin, gpgFlags string) []string {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := append(strings.Fields(gpgFlags), "--with-colons", "--list-keys", "--")
+	// gpg fails if any key is missing but still lists the others
+	out, _ := exec.Command(gpgBin, append(args, keys...)...).Output()
+
+	fingerprints := make([]string, 0)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, ":")
+		if fields[0] == "fpr" && len(fields) > 9 {
+			fingerprints = append(fingerprints, strings.ToUpper(fields[9]))
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, key := range keys {
+		id := strings.TrimPrefix(strings.ToUpper(key), "0X")
+		found := false
+		for _, fpr := range fingerprints {
+			if id != "" && strings.HasSuffix(fpr, id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+// stillMissing returns the part of keys that is not in the keyring.
+func stillMissing(keys pgpKeySet, gpgBin, gpgFlags string) pgpKeySet {
+	missing := make(pgpKeySet)
+	for _, key := range missingKeys(keys.toSlice(), gpgBin, gpgFlags) {
+		missing[key] = keys[key]
+	}
+	return missing
+}
+
+// keyFiles returns the existing <key>.asc files of keys in the directories
+// dir returns for the package bases requiring them.
+func keyFiles(keys pgpKeySet, dir func(pkgbase string) string) []string {
+	files := make([]string, 0)
+	for key, bases := range keys {
+		for _, base := range bases {
+			d := dir(base.Pkgbase())
+			if d == "" {
+				continue
+			}
+
+			file := filepath.Join(d, key+".asc")
+			if _, err := os.Stat(file); err == nil {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// importKeyFile imports the keys of an armored key file.
+func importKeyFile(file, gpgBin, gpgFlags string) error {
+	text.OperationInfoln(text.Tf("Importing keys from %s...", file))
+
+	args := append(strings.Fields(gpgFlags), "--import", file)
+	if out, err := exec.Command(gpgBin, args...).CombinedOutput(); err != nil {
+		return errors.New(text.Tf("problem importing %s: %s", file, strings.TrimSpace(string(out))))
+	}
+	return nil
+}
+
+var emailRe = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+
+// keyEmails finds the addresses written next to keys in the PKGBUILDs of
+// the bases requiring them, as in
+//
+//	validpgpkeys=('ABAF11C65A2970B130ABE3C479BE3E4300411886') # Linus Torvalds <torvalds@kernel.org>
+func keyEmails(keys pgpKeySet, buildDir string) map[string][]string {
+	emails := make(map[string][]string)
+	for key, bases := range keys {
+		for _, base := range bases {
+			content, err := ioutil.ReadFile(filepath.Join(buildDir, base.Pkgbase(), "PKGBUILD"))
+			if err != nil {
+				continue
+			}
+
+			for _, line := range strings.Split(string(content), "\n") {
+				if strings.Contains(strings.ToUpper(line), key) {
+					emails[key] = append(emails[key], emailRe.FindAllString(line, -1)...)
+				}
+			}
+		}
+	}
+	return emails
+}
+
+// locateKey looks up the addresses of a key in their Web Key Directory.
+func locateKey(key string, emails []string, gpgBin, gpgFlags string) error {
+	if len(emails) == 0 {
+		return nil
 	}
 
+	text.OperationInfoln(text.Tf("Looking up %s in the Web Key Directory...", key))
+
+	args := append(strings.Fields(gpgFlags), "--auto-key-locate", "clear,nodefault,wkd", "--locate-external-keys")
+	if out, err := exec.Command(gpgBin, append(args, emails...)...).CombinedOutput(); err != nil {
+		return errors.New(text.Tf("problem looking up %s: %s", key, strings.TrimSpace(string(out))))
+	}
 	return nil
 }
 
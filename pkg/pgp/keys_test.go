@@ -254,7 +254,7 @@ func TestCheckPgpKeys(t *testing.T) {
 			text.CaptureOutput(buf, nil, func() {
 
 				err := CheckPgpKeys([]dep.Base{tt.pkgs}, tt.srcinfos, "gpg",
-					fmt.Sprintf("--homedir %s --keyserver 127.0.0.1", keyringDir), true)
+					fmt.Sprintf("--homedir %s --keyserver 127.0.0.1", keyringDir), "", "", true)
 				if !tt.wantError {
 					if err != nil {
 						t.Fatalf("Got error %q, want no error", err)
@@ -274,3 +274,86 @@ func TestCheckPgpKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckPgpKeys_Files(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "yay-test-keys")
+	if err != nil {
+		t.Fatalf("Unable to init test dir: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyringDir := path.Join(dir, "keyring")
+	keyDir := path.Join(dir, "keys")
+	buildDir := path.Join(dir, "build")
+	repoKeyDir := path.Join(buildDir, "dummy-1", "keys", "pgp")
+	for _, d := range []string{keyringDir, keyDir, repoKeyDir} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 11E521D646982372EB577A1F8F0871F202119294: Tom Stellard, in the key directory.
+	// C52048C0C0748FEE227D47A2702353E0F7E48EDB: Thomas Dickey, shipped with dummy-1.
+	writeKey := func(dir, key string) {
+		if err := ioutil.WriteFile(path.Join(dir, key+".asc"), []byte(getPgpKey(key)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeKey(keyDir, "11E521D646982372EB577A1F8F0871F202119294")
+	writeKey(repoKeyDir, "C52048C0C0748FEE227D47A2702353E0F7E48EDB")
+
+	// no keyserver is running, all keys have to come from the files
+	gpgFlags := fmt.Sprintf("--homedir %s --keyserver 127.0.0.1", keyringDir)
+	srcinfos := map[string]*gosrc.Srcinfo{
+		"dummy-1": makeSrcinfo("dummy-1", "11e521d646982372eb577a1f8f0871f202119294", "C52048C0C0748FEE227D47A2702353E0F7E48EDB"),
+	}
+
+	buf := &bytes.Buffer{}
+	text.CaptureOutput(buf, buf, func() {
+		err = CheckPgpKeys([]dep.Base{{newPkg("dummy-1")}}, srcinfos, "gpg", gpgFlags, keyDir, buildDir, true)
+	})
+	if err != nil {
+		t.Fatalf("Got error %q, want no error\n%s", err, buf.String())
+	}
+	if strings.Contains(buf.String(), "Importing keys with gpg") {
+		t.Fatalf("keyservers were used:\n%s", buf.String())
+	}
+
+	missing := missingKeys([]string{"11E521D646982372EB577A1F8F0871F202119294", "F202119294", "ABAF11C65A2970B130ABE3C479BE3E4300411886"}, "gpg", gpgFlags)
+	if len(missing) != 1 || missing[0] != "ABAF11C65A2970B130ABE3C479BE3E4300411886" {
+		t.Fatalf("Got missing keys %v", missing)
+	}
+}
+
+func TestKeyEmails(t *testing.T) {
+	buildDir, err := ioutil.TempDir("/tmp", "yay-test-build")
+	if err != nil {
+		t.Fatalf("Unable to init test dir: %v\n", err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	pkgbuild := `pkgname=linux-dummy
+validpgpkeys=(
+  'ABAF11C65A2970B130ABE3C479BE3E4300411886' # Linus Torvalds <torvalds@kernel.org>
+  '647F28654894E3BD457199BE38DBBDC86092693E'  # Greg Kroah-Hartman
+)
+`
+	if err := os.MkdirAll(path.Join(buildDir, "linux-dummy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(buildDir, "linux-dummy", "PKGBUILD"), []byte(pkgbuild), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make(pgpKeySet)
+	keys.set("abaf11c65a2970b130abe3c479be3e4300411886", dep.Base{newPkg("linux-dummy")})
+	keys.set("647F28654894E3BD457199BE38DBBDC86092693E", dep.Base{newPkg("linux-dummy")})
+
+	emails := keyEmails(keys, buildDir)
+	if got := emails["ABAF11C65A2970B130ABE3C479BE3E4300411886"]; len(got) != 1 || got[0] != "torvalds@kernel.org" {
+		t.Fatalf("Got emails %v", got)
+	}
+	if got := emails["647F28654894E3BD457199BE38DBBDC86092693E"]; len(got) != 0 {
+		t.Fatalf("Got emails %v", got)
+	}
+}
@@ -0,0 +1,65 @@
+package pgp
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// SignFile writes a detached binary signature of file made with key to
+// file.sig, as makepkg --sign does.
+func SignFile(file, key, gpgBin, gpgFlags string) error {
+	args := append(strings.Fields(gpgFlags), "--detach-sign", "--use-agent", "--no-armor", "--yes",
+		"--local-user", key, "--output", file+".sig", file)
+	cmd := exec.Command(gpgBin, args...)
+	// gpg may ask for the passphrase of the key
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = text.AllPorts()
+
+	if err := cmd.Run(); err != nil {
+		return errors.New(text.Tf("problem signing %s", file))
+	}
+	return nil
+}
+
+// SignRepo signs the package and file databases of the repository db, as
+// repo-add --sign does. db is the database archive such as
+// custom.db.tar.gz, the unversioned custom.db and custom.files links get
+// signature links as well.
+func SignRepo(db, key, gpgBin, gpgFlags string) error {
+	dir, base := filepath.Split(db)
+	i := strings.Index(base, ".db")
+	if i == -1 {
+		return errors.New(text.Tf("%s is not a repository database", db))
+	}
+	name, ext := base[:i], base[i+len(".db"):]
+
+	for _, kind := range []string{".db", ".files"} {
+		archive := filepath.Join(dir, name+kind+ext)
+		if _, err := os.Stat(archive); os.IsNotExist(err) && kind == ".files" {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if err := SignFile(archive, key, gpgBin, gpgFlags); err != nil {
+			return err
+		}
+
+		if ext == "" {
+			continue
+		}
+		link := filepath.Join(dir, name+kind+".sig")
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Symlink(name+kind+ext+".sig", link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
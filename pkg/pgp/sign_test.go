@@ -0,0 +1,65 @@
+package pgp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+)
+
+func TestSignRepo(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "yay-test-sign")
+	if err != nil {
+		t.Fatalf("Unable to init test dir: %v\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyringDir := path.Join(dir, "keyring")
+	repoDir := path.Join(dir, "repo")
+	for _, d := range []string{keyringDir, repoDir} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer exec.Command("gpgconf", "--homedir", keyringDir, "--kill", "gpg-agent").Run()
+
+	gpgFlags := fmt.Sprintf("--homedir %s --batch", keyringDir)
+	gen := exec.Command("gpg", "--homedir", keyringDir, "--batch", "--passphrase", "",
+		"--quick-gen-key", "yay <yay@example.org>", "ed25519", "sign", "never")
+	if out, err := gen.CombinedOutput(); err != nil {
+		t.Skipf("unable to generate a key: %s", out)
+	}
+
+	for _, file := range []string{"custom.db.tar.gz", "custom.files.tar.gz", "foo-1-1-any.pkg.tar.zst"} {
+		if err := ioutil.WriteFile(path.Join(repoDir, file), []byte(file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkg := path.Join(repoDir, "foo-1-1-any.pkg.tar.zst")
+	if err := SignFile(pkg, "yay@example.org", "gpg", gpgFlags); err != nil {
+		t.Fatal(err)
+	}
+	if err := SignRepo(path.Join(repoDir, "custom.db.tar.gz"), "yay@example.org", "gpg", gpgFlags); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"foo-1-1-any.pkg.tar.zst", "custom.db.tar.gz", "custom.files.tar.gz"} {
+		file = path.Join(repoDir, file)
+		if out, err := exec.Command("gpg", "--homedir", keyringDir, "--verify", file+".sig", file).CombinedOutput(); err != nil {
+			t.Fatalf("signature of %s does not verify: %s", file, out)
+		}
+	}
+
+	for link, target := range map[string]string{"custom.db.sig": "custom.db.tar.gz.sig", "custom.files.sig": "custom.files.tar.gz.sig"} {
+		if got, err := os.Readlink(path.Join(repoDir, link)); err != nil || got != target {
+			t.Fatalf("Got link %s -> %q (%v), want %s", link, got, err, target)
+		}
+	}
+
+	if err := SignRepo(path.Join(repoDir, "custom.tar.gz"), "yay@example.org", "gpg", gpgFlags); err == nil {
+		t.Fatal("Got no error signing a file that is no database")
+	}
+}
@@ -0,0 +1,226 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// infoCacheKeep is how long an entry is kept after it expired. Expired
+// entries are served if the AUR can not be reached or in offline mode.
+const infoCacheKeep = 30 * 24 * time.Hour
+
+// ConditionalInfoProvider is an AURInfoProvider able to revalidate responses.
+type ConditionalInfoProvider interface {
+	InfoConditional([]string, Validator) ([]Pkg, Validator, error)
+}
+
+type cachedPkg struct {
+	// Pkg is nil for packages not found in the AUR.
+	Pkg     *Pkg  `json:"pkg,omitempty"`
+	Fetched int64 `json:"fetched"`
+}
+
+type infoCacheData struct {
+	Pkgs       map[string]cachedPkg `json:"pkgs"`
+	Validators map[string]Validator `json:"validators"`
+}
+
+// InfoCache is an AURInfoProvider keeping info responses on disk.
+//
+// Entries younger than TTL are served without a request. Older entries are
+// revalidated, and served stale if the AUR can not be reached. In Offline
+// mode only cached entries are served.
+type InfoCache struct {
+	Upstream ConditionalInfoProvider
+	FilePath string
+	TTL      time.Duration
+	Offline  bool
+
+	mux  sync.Mutex
+	data infoCacheData
+}
+
+func NewInfoCache(upstream ConditionalInfoProvider, filePath string, ttl time.Duration, offline bool) *InfoCache {
+	return &InfoCache{
+		Upstream: upstream,
+		FilePath: filePath,
+		TTL:      ttl,
+		Offline:  offline,
+		data: infoCacheData{
+			Pkgs:       map[string]cachedPkg{},
+			Validators: map[string]Validator{},
+		},
+	}
+}
+
+// requestKey identifies a request for the validators of its response.
+func requestKey(names []string) string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
+
+// Info returns the info of names from the cache, requesting what is missing
+// or expired.
+func (c *InfoCache) Info(names []string) ([]Pkg, error) {
+	c.mux.Lock()
+	now := time.Now()
+
+	var expired, uncached []string
+	for _, name := range names {
+		cached, ok := c.data.Pkgs[name]
+		switch {
+		case !ok:
+			uncached = append(uncached, name)
+		case now.Sub(time.Unix(cached.Fetched, 0)) >= c.TTL:
+			expired = append(expired, name)
+		}
+	}
+	c.mux.Unlock()
+
+	if c.Offline && len(uncached) > 0 {
+		return nil, errors.New(text.Tf("not in the AUR cache while offline: %s", strings.Join(uncached, ", ")))
+	}
+
+	if !c.Offline && len(expired)+len(uncached) > 0 {
+		if err := c.refresh(expired, uncached, now); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	pkgs := make([]Pkg, 0, len(names))
+	for _, name := range names {
+		if cached := c.data.Pkgs[name]; cached.Pkg != nil {
+			pkgs = append(pkgs, *cached.Pkg)
+		}
+	}
+	return pkgs, nil
+}
+
+func (c *InfoCache) refresh(expired, uncached []string, now time.Time) error {
+	request := append(append([]string(nil), expired...), uncached...)
+	key := requestKey(request)
+
+	// only revalidate responses the cache holds in full
+	var validator Validator
+	if len(uncached) == 0 {
+		c.mux.Lock()
+		validator = c.data.Validators[key]
+		c.mux.Unlock()
+	}
+
+	pkgs, newValidator, err := c.Upstream.InfoConditional(request, validator)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	switch {
+	case errors.Is(err, ErrNotModified):
+		for _, name := range request {
+			cached := c.data.Pkgs[name]
+			cached.Fetched = now.Unix()
+			c.data.Pkgs[name] = cached
+		}
+	case err != nil:
+		if len(uncached) > 0 {
+			return err
+		}
+		text.Warnln(text.Tf("using cached AUR info: %s", err))
+		return nil
+	default:
+		for _, name := range request {
+			c.data.Pkgs[name] = cachedPkg{Fetched: now.Unix()}
+		}
+		for i := range pkgs {
+			c.data.Pkgs[pkgs[i].Name] = cachedPkg{Pkg: &pkgs[i], Fetched: now.Unix()}
+		}
+		if newValidator != (Validator{}) {
+			c.data.Validators[key] = newValidator
+		} else {
+			delete(c.data.Validators, key)
+		}
+	}
+
+	// the fetched info is good even if it can not be kept
+	if err := c.save(now); err != nil {
+		text.Warnln(text.Tf("unable to save the AUR cache: %s", err))
+	}
+	return nil
+}
+
+// prune drops entries expired for longer than infoCacheKeep and the
+// validators of responses no longer held in full.
+func (c *InfoCache) prune(now time.Time) {
+	for name, cached := range c.data.Pkgs {
+		if now.Sub(time.Unix(cached.Fetched, 0)) >= c.TTL+infoCacheKeep {
+			delete(c.data.Pkgs, name)
+		}
+	}
+
+	for key := range c.data.Validators {
+		for _, name := range strings.Fields(key) {
+			if _, ok := c.data.Pkgs[name]; !ok {
+				delete(c.data.Validators, key)
+				break
+			}
+		}
+	}
+}
+
+func (c *InfoCache) save(now time.Time) error {
+	c.prune(now)
+
+	marshalledinfo, err := json.Marshal(c.data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.FilePath), 0o755); err != nil {
+		return err
+	}
+
+	// written to a temporary file first so a concurrent run never reads
+	// half a cache
+	tmp := c.FilePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, marshalledinfo, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.FilePath)
+}
+
+func (c *InfoCache) Load() error {
+	cfile, err := os.Open(c.FilePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(text.Tf("failed to open AUR cache '%s': %s", c.FilePath, err))
+	}
+	defer cfile.Close()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if err = json.NewDecoder(cfile).Decode(&c.data); err != nil {
+		return fmt.Errorf(text.Tf("failed to read AUR cache '%s': %s", c.FilePath, err))
+	}
+	if c.data.Pkgs == nil {
+		c.data.Pkgs = map[string]cachedPkg{}
+	}
+	if c.data.Validators == nil {
+		c.data.Validators = map[string]Validator{}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package query_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+type fakeUpstream struct {
+	pkgs       map[string]query.Pkg
+	err        error
+	notChanged bool
+	requests   [][]string
+	validators []query.Validator
+}
+
+func (f *fakeUpstream) InfoConditional(names []string, v query.Validator) ([]query.Pkg, query.Validator, error) {
+	f.requests = append(f.requests, names)
+	f.validators = append(f.validators, v)
+
+	if f.err != nil {
+		return nil, v, f.err
+	}
+	if f.notChanged && v.ETag != "" {
+		return nil, v, query.ErrNotModified
+	}
+
+	pkgs := []query.Pkg{}
+	for _, name := range names {
+		if pkg, ok := f.pkgs[name]; ok {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs, query.Validator{ETag: `"v1"`}, nil
+}
+
+func newTestCache(t *testing.T, upstream *fakeUpstream, ttl time.Duration) (*query.InfoCache, func()) {
+	dir, err := ioutil.TempDir("", "yay-aurcache")
+	require.NoError(t, err)
+
+	return query.NewInfoCache(upstream, filepath.Join(dir, "aurinfo.json"), ttl, false),
+		func() { os.RemoveAll(dir) }
+}
+
+func TestInfoCache_Fresh(t *testing.T) {
+	upstream := &fakeUpstream{pkgs: map[string]query.Pkg{"yay": {Name: "yay", Version: "10.2-1"}}}
+	c, cleanup := newTestCache(t, upstream, time.Hour)
+	defer cleanup()
+
+	pkgs, err := c.Info([]string{"yay", "gone"})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay", Version: "10.2-1"}}, pkgs)
+
+	// missing packages are cached as well
+	pkgs, err = c.Info([]string{"gone", "yay"})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay", Version: "10.2-1"}}, pkgs)
+	assert.Len(t, upstream.requests, 1)
+
+	l := query.NewInfoCache(upstream, c.FilePath, time.Hour, true)
+	require.NoError(t, l.Load())
+	pkgs, err = l.Info([]string{"yay"})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay", Version: "10.2-1"}}, pkgs)
+	assert.Len(t, upstream.requests, 1)
+}
+
+func TestInfoCache_Revalidate(t *testing.T) {
+	upstream := &fakeUpstream{pkgs: map[string]query.Pkg{"yay": {Name: "yay", Version: "10.2-1"}}}
+	c, cleanup := newTestCache(t, upstream, 0)
+	defer cleanup()
+
+	_, err := c.Info([]string{"yay"})
+	require.NoError(t, err)
+
+	upstream.notChanged = true
+	upstream.pkgs = nil
+	pkgs, err := c.Info([]string{"yay"})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay", Version: "10.2-1"}}, pkgs)
+
+	assert.Equal(t, []query.Validator{{}, {ETag: `"v1"`}}, upstream.validators)
+
+	// a request with uncached packages can not be revalidated
+	_, err = c.Info([]string{"yay", "paru"})
+	require.NoError(t, err)
+	assert.Equal(t, query.Validator{}, upstream.validators[2])
+}
+
+func TestInfoCache_Stale(t *testing.T) {
+	upstream := &fakeUpstream{pkgs: map[string]query.Pkg{"yay": {Name: "yay", Version: "10.2-1"}}}
+	c, cleanup := newTestCache(t, upstream, 0)
+	defer cleanup()
+
+	_, err := c.Info([]string{"yay"})
+	require.NoError(t, err)
+
+	upstream.err = errors.New("network is unreachable")
+
+	var pkgs []query.Pkg
+	text.CaptureOutput(nil, nil, func() {
+		pkgs, err = c.Info([]string{"yay"})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay", Version: "10.2-1"}}, pkgs)
+
+	_, err = c.Info([]string{"yay", "paru"})
+	assert.Equal(t, upstream.err, err)
+}
+
+func TestInfoCache_Offline(t *testing.T) {
+	upstream := &fakeUpstream{pkgs: map[string]query.Pkg{"yay": {Name: "yay", Version: "10.2-1"}}}
+	c, cleanup := newTestCache(t, upstream, 0)
+	defer cleanup()
+
+	_, err := c.Info([]string{"yay"})
+	require.NoError(t, err)
+
+	c.Offline = true
+
+	pkgs, err := c.Info([]string{"yay"})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay", Version: "10.2-1"}}, pkgs)
+
+	_, err = c.Info([]string{"yay", "paru"})
+	assert.Error(t, err)
+	assert.Len(t, upstream.requests, 1)
+}
+
+func TestInfoCache_Save(t *testing.T) {
+	upstream := &fakeUpstream{pkgs: map[string]query.Pkg{"yay": {Name: "yay", Version: "10.2-1"}}}
+	dir, err := ioutil.TempDir("", "yay-aurcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the cache dir may not exist yet
+	path := filepath.Join(dir, "yay", "aurinfo.json")
+	c := query.NewInfoCache(upstream, path, time.Hour, false)
+	_, err = c.Info([]string{"yay"})
+	require.NoError(t, err)
+	require.FileExists(t, path)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"pkgs":{"old":{"fetched":1}}}`), 0o644))
+	c = query.NewInfoCache(upstream, path, time.Hour, false)
+	require.NoError(t, c.Load())
+	_, err = c.Info([]string{"yay"})
+	require.NoError(t, err)
+
+	// long expired entries are dropped
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"yay"`)
+	assert.NotContains(t, string(content), `"old"`)
+
+	// info is returned even if it can not be saved
+	c = query.NewInfoCache(upstream, filepath.Join(path, "aurinfo.json"), time.Hour, false)
+	var pkgs []query.Pkg
+	text.CaptureOutput(nil, nil, func() {
+		pkgs, err = c.Info([]string{"yay"})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay", Version: "10.2-1"}}, pkgs)
+}
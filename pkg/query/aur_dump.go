@@ -0,0 +1,315 @@
+package query
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mikkeloscar/aur"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// dumpName is the name of the AUR metadata dump including dependencies.
+const dumpName = "packages-meta-ext-v1.json.gz"
+
+// ProviderFinder is implemented by backends able to list the packages
+// providing a name without searching.
+type ProviderFinder interface {
+	Providers(string) ([]Pkg, error)
+}
+
+// CoMaintainerFinder is implemented by backends knowing the co-maintainers
+// of package bases, which the rpc interface does not report.
+type CoMaintainerFinder interface {
+	CoMaintainers(pkgbase string) ([]string, bool)
+}
+
+// AURBackend answers AUR queries.
+type AURBackend interface {
+	AURInfoProvider
+	Search(string) ([]Pkg, error)
+	SearchBy(string, aur.By) ([]Pkg, error)
+}
+
+type dumpIndex struct {
+	LastModified string
+	Pkgs         []Pkg
+	Names        map[string]int
+	Provides     map[string][]int
+	// CoMaintainers by package base, nil in copies saved before they were
+	// kept.
+	CoMaintainers map[string][]string
+}
+
+// dumpPkg is a package of the dump, which has more fields than Pkg.
+type dumpPkg struct {
+	Pkg
+	CoMaintainers []string `json:"CoMaintainers"`
+}
+
+// Dump is an AURBackend answering queries from a local copy of the AUR
+// metadata dump. The copy is downloaded on first use and kept up to date by
+// Update.
+type Dump struct {
+	URL      string
+	FilePath string
+	Client   *http.Client
+
+	mu    sync.Mutex
+	index *dumpIndex
+}
+
+func NewDump(aurURL, filePath string, client *http.Client) *Dump {
+	return &Dump{
+		URL:      strings.TrimSuffix(aurURL, "/") + "/" + dumpName,
+		FilePath: filePath,
+		Client:   client,
+	}
+}
+
+// depName strips the version constraint or optdepends description of a
+// dependency.
+func depName(dep string) string {
+	if i := strings.IndexAny(dep, "<>=:"); i != -1 {
+		return dep[:i]
+	}
+	return dep
+}
+
+func newDumpIndex(pkgs []Pkg, lastModified string) *dumpIndex {
+	index := &dumpIndex{
+		LastModified: lastModified,
+		Pkgs:         pkgs,
+		Names:        make(map[string]int, len(pkgs)),
+		Provides:     make(map[string][]int),
+	}
+
+	for i := range pkgs {
+		index.Names[pkgs[i].Name] = i
+		for _, provide := range pkgs[i].Provides {
+			name := depName(provide)
+			index.Provides[name] = append(index.Provides[name], i)
+		}
+	}
+
+	return index
+}
+
+// Update downloads the dump if it changed since the last download, or
+// unconditionally with force.
+func (d *Dump) Update(force bool) error {
+	req, err := http.NewRequest(http.MethodGet, d.URL, nil)
+	if err != nil {
+		return err
+	}
+	if d.index != nil && d.index.LastModified != "" && !force {
+		req.Header.Set("If-Modified-Since", d.index.LastModified)
+	}
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	text.OperationInfoln(text.T("Downloading AUR metadata..."))
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil
+	default:
+		return errors.New(text.Tf("failed to download AUR metadata: %s", resp.Status))
+	}
+
+	body, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var dumped []dumpPkg
+	if err := json.NewDecoder(body).Decode(&dumped); err != nil {
+		return errors.New(text.Tf("failed to read AUR metadata: %s", err))
+	}
+
+	pkgs := make([]Pkg, 0, len(dumped))
+	coMaintainers := make(map[string][]string)
+	for i := range dumped {
+		pkgs = append(pkgs, dumped[i].Pkg)
+		coMaintainers[dumped[i].PackageBase] = dumped[i].CoMaintainers
+	}
+
+	index := newDumpIndex(pkgs, resp.Header.Get("Last-Modified"))
+	index.CoMaintainers = coMaintainers
+	if err := d.save(index); err != nil {
+		return err
+	}
+
+	d.index = index
+	return nil
+}
+
+func (d *Dump) save(index *dumpIndex) error {
+	if err := os.MkdirAll(filepath.Dir(d.FilePath), 0o755); err != nil {
+		return err
+	}
+
+	tmp := d.FilePath + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := gob.NewEncoder(out).Encode(index); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, d.FilePath)
+}
+
+// Load reads the local copy of the dump, downloading it if there is none.
+// A failed load is tried again on the next call.
+func (d *Dump) Load() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.index != nil {
+		return nil
+	}
+
+	in, err := os.Open(d.FilePath)
+	if os.IsNotExist(err) {
+		return d.Update(true)
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	index := new(dumpIndex)
+	if err := gob.NewDecoder(in).Decode(index); err != nil {
+		return errors.New(text.Tf("failed to read AUR metadata '%s': %s", d.FilePath, err))
+	}
+	d.index = index
+	return nil
+}
+
+func (d *Dump) Info(names []string) ([]Pkg, error) {
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	return d.index.info(names), nil
+}
+
+// CoMaintainers returns the co-maintainers of pkgbase and whether they are
+// known.
+func (d *Dump) CoMaintainers(pkgbase string) ([]string, bool) {
+	if err := d.Load(); err != nil || d.index.CoMaintainers == nil {
+		return nil, false
+	}
+	co, ok := d.index.CoMaintainers[pkgbase]
+	return co, ok
+}
+
+// Providers returns the packages named name or providing it.
+func (d *Dump) Providers(name string) ([]Pkg, error) {
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	return d.index.providers(name), nil
+}
+
+func (d *Dump) Search(query string) ([]Pkg, error) {
+	return d.SearchBy(query, aur.NameDesc)
+}
+
+// SearchBy matches packages the way the rpc interface does. Names and
+// descriptions are matched by substring, anything else exactly.
+func (d *Dump) SearchBy(query string, by aur.By) ([]Pkg, error) {
+	if err := d.Load(); err != nil {
+		return nil, err
+	}
+	return d.index.searchBy(query, by)
+}
+
+func (index *dumpIndex) info(names []string) []Pkg {
+	pkgs := make([]Pkg, 0, len(names))
+	for _, name := range names {
+		if i, ok := index.Names[name]; ok {
+			pkgs = append(pkgs, index.Pkgs[i])
+		}
+	}
+	return pkgs
+}
+
+func (index *dumpIndex) providers(name string) []Pkg {
+	pkgs := make([]Pkg, 0)
+	if i, ok := index.Names[name]; ok {
+		pkgs = append(pkgs, index.Pkgs[i])
+	}
+	for _, i := range index.Provides[name] {
+		if index.Pkgs[i].Name != name {
+			pkgs = append(pkgs, index.Pkgs[i])
+		}
+	}
+	return pkgs
+}
+
+func (index *dumpIndex) searchBy(query string, by aur.By) ([]Pkg, error) {
+	query = strings.ToLower(query)
+	hasDep := func(deps []string) bool {
+		for _, dep := range deps {
+			if depName(dep) == query {
+				return true
+			}
+		}
+		return false
+	}
+
+	var match func(pkg *Pkg) bool
+	switch by {
+	case aur.Name:
+		match = func(pkg *Pkg) bool { return strings.Contains(pkg.Name, query) }
+	case aur.NameDesc:
+		match = func(pkg *Pkg) bool {
+			return strings.Contains(pkg.Name, query) || strings.Contains(strings.ToLower(pkg.Description), query)
+		}
+	case aur.Maintainer:
+		match = func(pkg *Pkg) bool { return strings.ToLower(pkg.Maintainer) == query }
+	case aur.Depends:
+		match = func(pkg *Pkg) bool { return hasDep(pkg.Depends) }
+	case aur.MakeDepends:
+		match = func(pkg *Pkg) bool { return hasDep(pkg.MakeDepends) }
+	case aur.OptDepends:
+		match = func(pkg *Pkg) bool { return hasDep(pkg.OptDepends) }
+	case aur.CheckDepends:
+		match = func(pkg *Pkg) bool { return hasDep(pkg.CheckDepends) }
+	default:
+		return nil, errors.New(text.Tf("unsupported search by %d", by))
+	}
+
+	pkgs := make([]Pkg, 0)
+	for i := range index.Pkgs {
+		if match(&index.Pkgs[i]) {
+			pkgs = append(pkgs, index.Pkgs[i])
+		}
+	}
+	return pkgs, nil
+}
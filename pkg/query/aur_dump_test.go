@@ -0,0 +1,186 @@
+package query_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	rpc "github.com/mikkeloscar/aur"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+var dumpPkgs = []query.Pkg{
+	{Name: "yay", PackageBase: "yay", Description: "Yet another yogurt", Maintainer: "jguer", Depends: []string{"pacman>5", "git"}},
+	{Name: "yay-bin", PackageBase: "yay-bin", Description: "Yet another yogurt (binary)", Maintainer: "jguer", Provides: []string{"yay=10.2"}},
+	{Name: "paru", PackageBase: "paru", Description: "AUR helper", Maintainer: "morganamilo", MakeDepends: []string{"cargo"}},
+	{Name: "orphan-tool", PackageBase: "orphan-tool", Description: "Nobody cares", OptDepends: []string{"git: vcs support"}},
+}
+
+// dumpJSON adds the co-maintainers to dumpPkgs, which Pkg has no field for.
+func dumpJSON() interface{} {
+	type dumped struct {
+		query.Pkg
+		CoMaintainers []string
+	}
+	out := make([]dumped, 0, len(dumpPkgs))
+	for _, pkg := range dumpPkgs {
+		d := dumped{Pkg: pkg, CoMaintainers: []string{}}
+		if pkg.Name == "yay" {
+			d.CoMaintainers = []string{"morganamilo"}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+const lastModified = "Mon, 01 Mar 2021 00:00:00 GMT"
+
+func dumpServer(t *testing.T, downloads *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/packages-meta-ext-v1.json.gz", r.URL.Path)
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		atomic.AddInt32(downloads, 1)
+		w.Header().Set("Last-Modified", lastModified)
+		gz := gzip.NewWriter(w)
+		_ = json.NewEncoder(gz).Encode(dumpJSON())
+		gz.Close()
+	}))
+}
+
+func names(pkgs []query.Pkg) []string {
+	n := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		n = append(n, pkg.Name)
+	}
+	return n
+}
+
+func TestDump(t *testing.T) {
+	var downloads int32
+	ts := dumpServer(t, &downloads)
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "yay-dump")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := query.NewDump(ts.URL, filepath.Join(dir, "aurdump.gob"), ts.Client())
+
+	var pkgs []query.Pkg
+	text.CaptureOutput(nil, nil, func() {
+		pkgs, err = d.Info([]string{"paru", "missing", "yay"})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{dumpPkgs[2], dumpPkgs[0]}, pkgs)
+
+	tests := []struct {
+		query string
+		by    rpc.By
+		want  []string
+	}{
+		{"yay", rpc.Name, []string{"yay", "yay-bin"}},
+		{"YOGURT", rpc.NameDesc, []string{"yay", "yay-bin"}},
+		{"helper", rpc.Name, []string{}},
+		{"helper", rpc.NameDesc, []string{"paru"}},
+		{"", rpc.Maintainer, []string{"orphan-tool"}},
+		{"jguer", rpc.Maintainer, []string{"yay", "yay-bin"}},
+		{"pacman", rpc.Depends, []string{"yay"}},
+		{"cargo", rpc.MakeDepends, []string{"paru"}},
+		{"git", rpc.OptDepends, []string{"orphan-tool"}},
+		{"git", rpc.CheckDepends, []string{}},
+	}
+	for _, tt := range tests {
+		pkgs, err := d.SearchBy(tt.query, tt.by)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, names(pkgs), "%s by %s", tt.query, tt.by)
+	}
+
+	co, ok := d.CoMaintainers("yay")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"morganamilo"}, co)
+	co, ok = d.CoMaintainers("paru")
+	assert.True(t, ok)
+	assert.Empty(t, co)
+	_, ok = d.CoMaintainers("missing")
+	assert.False(t, ok)
+
+	pkgs, err = d.Providers("yay")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yay", "yay-bin"}, names(pkgs))
+
+	text.CaptureOutput(nil, nil, func() {
+		err = d.Update(false)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+
+	// a new instance reads the local copy
+	l := query.NewDump(ts.URL, d.FilePath, ts.Client())
+	pkgs, err = l.Search("paru")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"paru"}, names(pkgs))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+	co, ok = l.CoMaintainers("yay")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"morganamilo"}, co)
+
+	text.CaptureOutput(nil, nil, func() {
+		err = l.Update(true)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&downloads))
+}
+
+func TestDump_Retry(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server sends no Last-Modified, so there is nothing to revalidate
+		_, sent := r.Header["If-Modified-Since"]
+		assert.False(t, sent)
+
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gz := gzip.NewWriter(w)
+		_ = json.NewEncoder(gz).Encode(dumpPkgs)
+		gz.Close()
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "yay-dump")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := query.NewDump(ts.URL, filepath.Join(dir, "aurdump.gob"), ts.Client())
+
+	text.CaptureOutput(nil, nil, func() {
+		_, err = d.Info([]string{"yay"})
+	})
+	assert.Error(t, err)
+
+	// a failed download is not remembered
+	var pkgs []query.Pkg
+	text.CaptureOutput(nil, nil, func() {
+		pkgs, err = d.Info([]string{"yay"})
+		require.NoError(t, err)
+		err = d.Update(false)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yay"}, names(pkgs))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
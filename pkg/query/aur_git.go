@@ -0,0 +1,179 @@
+package query
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+	"github.com/mikkeloscar/aur"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// GitSource is an AURBackend answering queries from a checkout of a git
+// repository holding one directory with a .SRCINFO per package base.
+type GitSource struct {
+	Name string
+	URL  string
+	Dir  string
+	// Pull clones url into dir or fast-forwards an existing checkout.
+	Pull func(url, dir string) error
+
+	mu    sync.Mutex
+	index *dumpIndex
+}
+
+func NewGitSource(name, url, dir string, pull func(url, dir string) error) *GitSource {
+	return &GitSource{
+		Name: name,
+		URL:  url,
+		Dir:  dir,
+		Pull: pull,
+	}
+}
+
+// archValues returns the values of an ArchString slice that apply to
+// every architecture.
+func archValues(values []gosrc.ArchString) []string {
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		if v.Arch == "" {
+			s = append(s, v.Value)
+		}
+	}
+	return s
+}
+
+// srcinfoPkgs turns a .SRCINFO into one Pkg per split package.
+func srcinfoPkgs(source string, srcinfo *gosrc.Srcinfo) []Pkg {
+	pkgs := make([]Pkg, 0, len(srcinfo.Packages))
+	for _, split := range srcinfo.SplitPackages() {
+		pkgs = append(pkgs, Pkg{
+			Name:        split.Pkgname,
+			PackageBase: srcinfo.Pkgbase,
+			Version:     srcinfo.Version(),
+			Description: split.Pkgdesc,
+			URL:         split.URL,
+			// There are no accounts in a plain git repository, the source
+			// itself is the maintainer.
+			Maintainer:   source,
+			Depends:      archValues(split.Depends),
+			MakeDepends:  archValues(srcinfo.MakeDepends),
+			CheckDepends: archValues(srcinfo.CheckDepends),
+			OptDepends:   archValues(split.OptDepends),
+			Provides:     archValues(split.Provides),
+			Conflicts:    archValues(split.Conflicts),
+			Replaces:     archValues(split.Replaces),
+			Groups:       split.Groups,
+			License:      split.License,
+		})
+	}
+	return pkgs
+}
+
+func (g *GitSource) read() (*dumpIndex, error) {
+	files, err := ioutil.ReadDir(g.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]Pkg, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		srcinfo, err := gosrc.ParseFile(filepath.Join(g.Dir, file.Name(), ".SRCINFO"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			text.Warnln(text.Tf("%s: failed to parse .SRCINFO of %s: %s", g.Name, file.Name(), err))
+			continue
+		}
+
+		pkgs = append(pkgs, srcinfoPkgs(g.Name, srcinfo)...)
+	}
+
+	return newDumpIndex(pkgs, ""), nil
+}
+
+// Load reads the checkout of the source, cloning it if there is none.
+func (g *GitSource) Load() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.index != nil {
+		return nil
+	}
+
+	if _, err := os.Stat(g.Dir); os.IsNotExist(err) {
+		if err := g.pull(); err != nil {
+			return err
+		}
+	}
+
+	index, err := g.read()
+	if err != nil {
+		return err
+	}
+	g.index = index
+	return nil
+}
+
+// Update fast-forwards the checkout of the source. The checkout is always
+// brought up to date so force has no effect.
+func (g *GitSource) Update(force bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.pull(); err != nil {
+		return err
+	}
+
+	index, err := g.read()
+	if err != nil {
+		return err
+	}
+	g.index = index
+	return nil
+}
+
+func (g *GitSource) pull() error {
+	if g.Pull == nil {
+		return errors.New(text.Tf("%s: no way to fetch %s", g.Name, g.URL))
+	}
+
+	text.OperationInfoln(text.Tf("Fetching PKGBUILD source %s...", text.Cyan(g.Name)))
+	return g.Pull(g.URL, g.Dir)
+}
+
+func (g *GitSource) Info(names []string) ([]Pkg, error) {
+	if err := g.Load(); err != nil {
+		return nil, err
+	}
+	return g.index.info(names), nil
+}
+
+// Providers returns the packages named name or providing it.
+func (g *GitSource) Providers(name string) ([]Pkg, error) {
+	if err := g.Load(); err != nil {
+		return nil, err
+	}
+	return g.index.providers(name), nil
+}
+
+func (g *GitSource) Search(query string) ([]Pkg, error) {
+	return g.SearchBy(query, aur.NameDesc)
+}
+
+func (g *GitSource) SearchBy(query string, by aur.By) ([]Pkg, error) {
+	if err := g.Load(); err != nil {
+		return nil, err
+	}
+	return g.index.searchBy(query, by)
+}
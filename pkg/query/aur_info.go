@@ -1,39 +1,53 @@
 package query
 
 import (
+	"errors"
 	"sync"
 
 	"github.com/Jguer/yay/v10/pkg/intrange"
 	"github.com/Jguer/yay/v10/pkg/multierror"
+	"github.com/Jguer/yay/v10/pkg/stringset"
 	"github.com/Jguer/yay/v10/pkg/text"
 )
 
 type AURInfoProvider interface{ Info([]string) ([]Pkg, error) }
 
+// maxConcurrentRequests caps the number of rpc requests AURInfo keeps in
+// flight to stay clear of the AUR rate limit.
+const maxConcurrentRequests = 4
+
 // Queries the aur for information about specified packages.
 // All packages should be queried in a single rpc request except when the number
 // of packages exceeds the number set in config.RequestSplitN.
 // If the number does exceed config.RequestSplitN multiple rpc requests will be
 // performed concurrently.
+// If some requests fail the info of the others is still returned along with
+// a multierror.MultiError holding an error per failed request.
 func AURInfo(a AURInfoProvider, names []string, warnings *AURWarnings, splitN int) ([]*Pkg, error) { // what about splitN == 0 ??
 	info := make([]*Pkg, 0, len(names))
 	seen := make(map[string]int)
+	failed := stringset.Make()
 	var mux sync.Mutex
 	var wg sync.WaitGroup
 	var errs multierror.MultiError
+	sem := make(chan struct{}, maxConcurrentRequests)
 
 	makeRequest := func(n, max int) {
 		defer wg.Done()
-		tempInfo, requestErr := a.Info(names[n:max]) // TODO(jmh): be careful with data race of aur.AURURL
-		errs.Add(requestErr)
+		sem <- struct{}{}
+		defer func() { <-sem }()
+
+		tempInfo, requestErr := a.Info(names[n:max])
+		mux.Lock()
+		defer mux.Unlock()
 		if requestErr != nil {
+			errs.Add(errors.New(text.Tf("failed to query packages %s to %s: %s", names[n], names[max-1], requestErr)))
+			failed.Extend(names[n:max]...)
 			return
 		}
-		mux.Lock() // Mutex not necessary as writes are disjoint
 		for i := range tempInfo {
 			info = append(info, &tempInfo[i])
 		}
-		mux.Unlock()
 	}
 
 	for n := 0; n < len(names); n += splitN {
@@ -44,15 +58,16 @@ func AURInfo(a AURInfoProvider, names []string, warnings *AURWarnings, splitN in
 
 	wg.Wait()
 
-	if err := errs.Return(); err != nil {
-		return info, err
-	}
-
 	for k, pkg := range info {
 		seen[pkg.Name] = k
 	}
 
 	for _, name := range names {
+		if failed.Get(name) {
+			warnings.Failed = append(warnings.Failed, name)
+			continue
+		}
+
 		i, ok := seen[name]
 		if !ok && !warnings.Ignore.Get(name) {
 			warnings.Missing = append(warnings.Missing, name)
@@ -69,7 +84,7 @@ func AURInfo(a AURInfoProvider, names []string, warnings *AURWarnings, splitN in
 		}
 	}
 
-	return info, nil
+	return info, errs.Return()
 }
 
 func AURInfoPrint(a AURInfoProvider, names []string, splitN int) ([]*Pkg, error) {
@@ -77,11 +92,8 @@ func AURInfoPrint(a AURInfoProvider, names []string, splitN int) ([]*Pkg, error)
 
 	warnings := &AURWarnings{}
 	info, err := AURInfo(a, names, warnings, splitN)
-	if err != nil {
-		return info, err
-	}
 
 	warnings.Print()
 
-	return info, nil
+	return info, err
 }
@@ -0,0 +1,69 @@
+package query_test
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Jguer/yay/v10/pkg/multierror"
+	"github.com/Jguer/yay/v10/pkg/query"
+)
+
+type chunkProvider struct {
+	mux      sync.Mutex
+	requests int
+}
+
+func (c *chunkProvider) Info(names []string) ([]query.Pkg, error) {
+	c.mux.Lock()
+	c.requests++
+	c.mux.Unlock()
+
+	pkgs := []query.Pkg{}
+	for _, name := range names {
+		switch {
+		case strings.HasPrefix(name, "bad"):
+			return nil, errors.New("connection reset")
+		case strings.HasPrefix(name, "gone"):
+		case strings.HasPrefix(name, "orphan"):
+			pkgs = append(pkgs, query.Pkg{Name: name})
+		default:
+			pkgs = append(pkgs, query.Pkg{Name: name, Maintainer: "bob"})
+		}
+	}
+	return pkgs, nil
+}
+
+func TestAURInfo_Partial(t *testing.T) {
+	names := []string{"yay", "bad-1", "orphan", "gone", "bad-2", "gone-bad"}
+	provider := &chunkProvider{}
+	warnings := query.NewWarnings()
+
+	info, err := query.AURInfo(provider, names, warnings, 2)
+
+	var multi *multierror.MultiError
+	if assert.True(t, errors.As(err, &multi)) {
+		msgs := strings.Split(multi.Error(), "\n")
+		sort.Strings(msgs)
+		assert.Equal(t, []string{
+			"failed to query packages bad-2 to gone-bad: connection reset",
+			"failed to query packages yay to bad-1: connection reset",
+		}, msgs)
+	}
+
+	got := []string{}
+	for _, pkg := range info {
+		got = append(got, pkg.Name)
+	}
+	assert.ElementsMatch(t, []string{"orphan"}, got)
+
+	// failed requests are not reported as missing
+	assert.Equal(t, []string{"gone"}, warnings.Missing)
+	assert.Equal(t, []string{"orphan"}, warnings.Orphans)
+	assert.Equal(t, []string{"yay", "bad-1", "bad-2", "gone-bad"}, warnings.Failed)
+	assert.Equal(t, 3, provider.requests)
+}
@@ -1,38 +1,235 @@
 package query
 
-import "github.com/mikkeloscar/aur"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikkeloscar/aur"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
 
 type Pkg = aur.Pkg
 
+// rpcVersion is the version of the AUR RPC interface spoken by AUR.
+const rpcVersion = "5"
+
+const (
+	defaultUserAgent = "yay"
+	defaultTimeout   = 30 * time.Second
+	defaultRetries   = 3
+	defaultBackoff   = time.Second
+
+	// maxRetryAfter caps the wait requested by the AUR through Retry-After.
+	maxRetryAfter = time.Minute
+)
+
+// ErrNotModified is returned by conditional requests if the response did not
+// change since the validator was issued.
+var ErrNotModified = errors.New("not modified")
+
+// ErrRateLimited is returned when the AUR keeps refusing requests with
+// HTTP 429 after all retries.
+var ErrRateLimited = errors.New("AUR rate limit exceeded")
+
+// transientError is an error worth retrying the request for. after is the
+// wait requested by the server, if any.
+type transientError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Validator holds the HTTP cache validators of a response.
+type Validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastmodified,omitempty"`
+}
+
+type rpcResponse struct {
+	Error       string `json:"error"`
+	Version     int    `json:"version"`
+	Type        string `json:"type"`
+	ResultCount int    `json:"resultcount"`
+	Results     []Pkg  `json:"results"`
+}
+
+// AUR is a client of the AUR RPC interface. It holds no global state so
+// clients for different endpoints can be used concurrently.
 type AUR struct {
-	URL string
+	// URL is the RPC endpoint, e.g. https://aur.archlinux.org/rpc.php
+	URL       string
+	Client    *http.Client
+	UserAgent string
+	// Timeout limits each request, 0 disables it.
+	Timeout time.Duration
+	// Retries is the number of times a failed request is retried, waiting
+	// Backoff before the first retry and doubling the wait after each one.
+	Retries int
+	Backoff time.Duration
 }
 
-func (a AUR) Info(pkgs []string) ([]Pkg, error) {
-	//backup := aur.AURURL
-	aur.AURURL = a.URL
-	p, err := aur.Info(pkgs)
-	//aur.AURURL = backup
-	return p, err
+// NewAUR returns a client for the RPC interface of the AUR at aurURL.
+func NewAUR(aurURL string, client *http.Client) *AUR {
+	return &AUR{
+		URL:       strings.TrimSuffix(aurURL, "/") + "/rpc.php",
+		Client:    client,
+		UserAgent: defaultUserAgent,
+		Timeout:   defaultTimeout,
+		Retries:   defaultRetries,
+		Backoff:   defaultBackoff,
+	}
 }
-func (a AUR) Orphans() ([]Pkg, error) {
-	//backup := aur.AURURL
-	aur.AURURL = a.URL
-	p, err := aur.Orphans()
-	//aur.AURURL = backup
-	return p, err
+
+func (a *AUR) get(values url.Values) ([]Pkg, error) {
+	pkgs, _, err := a.getConditional(values, Validator{})
+	return pkgs, err
 }
-func (a AUR) Search(query string) ([]Pkg, error) {
-	//backup := aur.AURURL
-	aur.AURURL = a.URL
-	p, err := aur.Search(query)
-	//aur.AURURL = backup
-	return p, err
+
+// getConditional sends a request, retrying it on network errors, rate limits
+// and unavailable servers. All rpc requests are idempotent.
+func (a *AUR) getConditional(values url.Values, validator Validator) ([]Pkg, Validator, error) {
+	values.Set("v", rpcVersion)
+
+	for attempt := 0; ; attempt++ {
+		pkgs, newValidator, err := a.do(values, validator)
+
+		var transient *transientError
+		if !errors.As(err, &transient) {
+			return pkgs, newValidator, err
+		}
+		if attempt >= a.Retries {
+			return nil, validator, transient.err
+		}
+
+		wait := a.Backoff << attempt
+		if transient.after > 0 {
+			wait = transient.after
+		}
+		time.Sleep(wait)
+	}
 }
-func (a AUR) SearchBy(query string, by aur.By) ([]Pkg, error) {
-	//backup := aur.AURURL
-	aur.AURURL = a.URL
-	p, err := aur.SearchBy(query, by)
-	//aur.AURURL = backup
+
+// retryAfter parses a Retry-After header given in seconds or as a date.
+func retryAfter(header string) time.Duration {
+	var after time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		after = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		after = time.Until(date)
+	}
+
+	if after > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return after
+}
+
+func (a *AUR) do(values url.Values, validator Validator) ([]Pkg, Validator, error) {
+	ctx := context.Background()
+	if a.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(a.URL, "?")+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, validator, err
+	}
+	if a.UserAgent != "" {
+		req.Header.Set("User-Agent", a.UserAgent)
+	}
+	if validator.ETag != "" {
+		req.Header.Set("If-None-Match", validator.ETag)
+	}
+	if validator.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validator.LastModified)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, validator, &transientError{err: err}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, validator, ErrNotModified
+	case http.StatusTooManyRequests:
+		return nil, validator, &transientError{ErrRateLimited, retryAfter(resp.Header.Get("Retry-After"))}
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, validator, &transientError{aur.ErrServiceUnavailable, retryAfter(resp.Header.Get("Retry-After"))}
+	default:
+		return nil, validator, errors.New(text.Tf("AUR responded with %s", resp.Status))
+	}
+
+	result := new(rpcResponse)
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, validator, err
+	}
+
+	if result.Error != "" {
+		return nil, validator, errors.New(result.Error)
+	}
+
+	return result.Results, Validator{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// Info returns the info of pkgs. Packages missing from the AUR are left out.
+func (a *AUR) Info(pkgs []string) ([]Pkg, error) {
+	p, _, err := a.InfoConditional(pkgs, Validator{})
 	return p, err
 }
+
+// InfoConditional is Info revalidating a previous response. It returns
+// ErrNotModified if the response described by validator is still current.
+func (a *AUR) InfoConditional(pkgs []string, validator Validator) ([]Pkg, Validator, error) {
+	v := url.Values{}
+	v.Set("type", "info")
+	for _, arg := range pkgs {
+		v.Add("arg[]", arg)
+	}
+	return a.getConditional(v, validator)
+}
+
+func (a *AUR) Orphans() ([]Pkg, error) {
+	return a.SearchBy("", aur.Maintainer)
+}
+
+// Search searches packages by name and description.
+func (a *AUR) Search(query string) ([]Pkg, error) {
+	return a.searchBy(query, "")
+}
+
+func (a *AUR) SearchBy(query string, by aur.By) ([]Pkg, error) {
+	return a.searchBy(query, by.String())
+}
+
+func (a *AUR) searchBy(query, by string) ([]Pkg, error) {
+	v := url.Values{}
+	v.Set("type", "search")
+	v.Set("arg", query)
+	if by != "" {
+		v.Set("by", by)
+	}
+	return a.get(v)
+}
@@ -0,0 +1,218 @@
+package query_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rpc "github.com/mikkeloscar/aur"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+)
+
+// rpcServer answers info requests with a package per arg, tagged with the
+// server name so responses from different endpoints can be told apart.
+func rpcServer(t *testing.T, name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rpc.php", r.URL.Path)
+		assert.Equal(t, "5", r.URL.Query().Get("v"))
+		assert.Equal(t, "yay", r.UserAgent())
+
+		q := r.URL.Query()
+		results := []query.Pkg{}
+		switch q.Get("type") {
+		case "info":
+			for _, arg := range q["arg[]"] {
+				results = append(results, query.Pkg{Name: arg, Description: name})
+			}
+		case "search":
+			results = append(results, query.Pkg{Name: q.Get("arg") + "-" + q.Get("by"), Description: name})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]string{"type": "error", "error": "Incorrect request type specified."})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"version": 5, "type": q.Get("type"), "resultcount": len(results), "results": results,
+		})
+	}))
+}
+
+func TestAUR_Info(t *testing.T) {
+	ts := rpcServer(t, "main")
+	defer ts.Close()
+
+	a := query.NewAUR(ts.URL+"/", ts.Client())
+	pkgs, err := a.Info([]string{"yay", "yay-bin"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []query.Pkg{
+		{Name: "yay", Description: "main"},
+		{Name: "yay-bin", Description: "main"},
+	}, pkgs)
+}
+
+func TestAUR_SearchBy(t *testing.T) {
+	ts := rpcServer(t, "main")
+	defer ts.Close()
+
+	a := query.NewAUR(ts.URL, ts.Client())
+
+	pkgs, err := a.SearchBy("yay", rpc.Maintainer)
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay-maintainer", Description: "main"}}, pkgs)
+
+	pkgs, err = a.Search("yay")
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{{Name: "yay-", Description: "main"}}, pkgs)
+}
+
+func TestAUR_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+	}{
+		{
+			name: "unavailable",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			want: rpc.ErrServiceUnavailable,
+		},
+		{
+			name: "rpc error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"type":"error","error":"Too many package results."}`)
+			},
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+
+			a := query.NewAUR(ts.URL, ts.Client())
+			a.Timeout = 50 * time.Millisecond
+			a.Backoff = time.Millisecond
+
+			_, err := a.Info([]string{"yay"})
+			require.Error(t, err)
+			if tt.want != nil {
+				assert.Equal(t, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAUR_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		status   int
+		header   string
+		wantErr  error
+		wantFail bool
+		minWait  time.Duration
+		requests int32
+	}{
+		{name: "unavailable once", failures: 1, status: http.StatusServiceUnavailable, requests: 2},
+		{name: "gateway timeout", failures: 3, status: http.StatusGatewayTimeout, requests: 4},
+		{
+			name: "unavailable", failures: 4, status: http.StatusBadGateway,
+			wantErr: rpc.ErrServiceUnavailable, requests: 4,
+		},
+		{
+			name: "rate limited", failures: 1, status: http.StatusTooManyRequests,
+			header: "1", minWait: time.Second, requests: 2,
+		},
+		{
+			name: "rate limit exceeded", failures: 4, status: http.StatusTooManyRequests,
+			wantErr: query.ErrRateLimited, requests: 4,
+		},
+		{name: "not found", failures: 1, status: http.StatusNotFound, wantFail: true, requests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(atomic.AddInt32(&requests, 1)) <= tt.failures {
+					if tt.header != "" {
+						w.Header().Set("Retry-After", tt.header)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				fmt.Fprint(w, `{"version":5,"type":"multiinfo","resultcount":1,"results":[{"Name":"yay"}]}`)
+			}))
+			defer ts.Close()
+
+			a := query.NewAUR(ts.URL, ts.Client())
+			a.Backoff = time.Millisecond
+
+			start := time.Now()
+			pkgs, err := a.Info([]string{"yay"})
+
+			assert.Equal(t, tt.requests, atomic.LoadInt32(&requests))
+			assert.True(t, time.Since(start) >= tt.minWait)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.Equal(t, tt.wantErr, err)
+			case tt.wantFail:
+				assert.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, []query.Pkg{{Name: "yay"}}, pkgs)
+			}
+		})
+	}
+}
+
+func TestAUR_Concurrent(t *testing.T) {
+	main := rpcServer(t, "main")
+	defer main.Close()
+	mirror := rpcServer(t, "mirror")
+	defer mirror.Close()
+
+	clients := map[string]*query.AUR{
+		"main":   query.NewAUR(main.URL, main.Client()),
+		"mirror": query.NewAUR(mirror.URL, mirror.Client()),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for name, a := range clients {
+			wg.Add(1)
+			go func(name string, a *query.AUR) {
+				defer wg.Done()
+				pkgs, err := a.Info([]string{"yay"})
+				if assert.NoError(t, err) && assert.Len(t, pkgs, 1) {
+					assert.Equal(t, name, pkgs[0].Description)
+				}
+			}(name, a)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,288 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/mikkeloscar/aur"
+
+	"github.com/Jguer/yay/v10/pkg/multierror"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Kinds of PKGBUILD sources. An AUR source is queried like the AUR and has
+// a git repository per package base, a git source is a single repository
+// with a directory per package base.
+const (
+	SourceAUR = "aur"
+	SourceGit = "git"
+)
+
+// Updater is implemented by backends keeping a local copy of their source.
+type Updater interface {
+	Load() error
+	Update(force bool) error
+}
+
+// Source is a named origin of PKGBUILDs.
+type Source struct {
+	Name    string
+	Kind    string
+	URL     string
+	Backend AURBackend
+	// Cache answers info requests where stale data is acceptable, Backend
+	// is used if it is nil.
+	Cache AURInfoProvider
+	// Dir is the checkout of a git source.
+	Dir string
+}
+
+// CloneURL returns the git repository of a package base of an AUR source.
+func (s *Source) CloneURL(pkgbase string) string {
+	return s.URL + "/" + pkgbase + ".git"
+}
+
+// Sources is an AURBackend querying several sources in order of priority.
+// A package found in more than one source is taken from the first one,
+// unless it was pinned to another.
+type Sources struct {
+	List []*Source
+
+	mu     sync.Mutex
+	origin map[string]*Source
+	pinned map[string]*Source
+}
+
+// NewSources combines sources, the first one having the highest priority.
+// The source names become valid target prefixes.
+func NewSources(sources ...*Source) *Sources {
+	return &Sources{
+		List:   sources,
+		origin: make(map[string]*Source),
+		pinned: make(map[string]*Source),
+	}
+}
+
+// Source returns the source called name or nil.
+func (s *Sources) Source(name string) *Source {
+	for _, src := range s.List {
+		if src.Name == name {
+			return src
+		}
+	}
+	return nil
+}
+
+// IsSourceName reports whether a target prefix names a PKGBUILD source
+// rather than a sync database. Without sources only aur does.
+func (s *Sources) IsSourceName(name string) bool {
+	if s == nil {
+		return name == "aur"
+	}
+	return s.Source(name) != nil
+}
+
+// Pin restricts queries for pkg to the source called name. It reports
+// false if there is no such source.
+func (s *Sources) Pin(pkg, name string) bool {
+	src := s.Source(name)
+	if src == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	s.pinned[pkg] = src
+	s.mu.Unlock()
+	return true
+}
+
+// Origin returns the source a package or package base was found in. Names
+// not seen yet are attributed to the AUR.
+func (s *Sources) Origin(name string) *Source {
+	s.mu.Lock()
+	src, ok := s.origin[name]
+	s.mu.Unlock()
+	if ok {
+		return src
+	}
+
+	if src := s.Source("aur"); src != nil {
+		return src
+	}
+	return s.List[len(s.List)-1]
+}
+
+// Priority returns the position of the origin of a package, lower is
+// preferred.
+func (s *Sources) Priority(name string) int {
+	origin := s.Origin(name)
+	for i, src := range s.List {
+		if src == origin {
+			return i
+		}
+	}
+	return len(s.List)
+}
+
+// CoMaintainers returns the co-maintainers of pkgbase if its source knows
+// them.
+func (s *Sources) CoMaintainers(pkgbase string) ([]string, bool) {
+	finder, ok := s.Origin(pkgbase).Backend.(CoMaintainerFinder)
+	if !ok {
+		return nil, false
+	}
+	return finder.CoMaintainers(pkgbase)
+}
+
+func (s *Sources) record(src *Source, pkgs []Pkg) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range pkgs {
+		s.origin[pkgs[i].Name] = src
+		s.origin[pkgs[i].PackageBase] = src
+	}
+}
+
+func (s *Sources) info(names []string, cached bool) ([]Pkg, error) {
+	var errs multierror.MultiError
+	info := make([]Pkg, 0, len(names))
+	remaining := names
+
+	for _, src := range s.List {
+		if len(remaining) == 0 {
+			break
+		}
+
+		s.mu.Lock()
+		toQuery := make([]string, 0, len(remaining))
+		for _, name := range remaining {
+			if pin, ok := s.pinned[name]; !ok || pin == src {
+				toQuery = append(toQuery, name)
+			}
+		}
+		s.mu.Unlock()
+
+		if len(toQuery) == 0 {
+			continue
+		}
+
+		var provider AURInfoProvider = src.Backend
+		if cached && src.Cache != nil {
+			provider = src.Cache
+		}
+
+		// Names queried from a failing source are not looked up in the
+		// following ones, a package of a lower priority source must not
+		// stand in for it.
+		found := make(map[string]bool, len(toQuery))
+		pkgs, err := provider.Info(toQuery)
+		if err != nil {
+			if len(s.List) > 1 {
+				err = errors.New(text.Tf("%s: %s", src.Name, err))
+			}
+			errs.Add(err)
+			for _, name := range toQuery {
+				found[name] = true
+			}
+		} else {
+			s.record(src, pkgs)
+			info = append(info, pkgs...)
+			for i := range pkgs {
+				found[pkgs[i].Name] = true
+			}
+		}
+		left := make([]string, 0, len(remaining))
+		for _, name := range remaining {
+			if !found[name] {
+				left = append(left, name)
+			}
+		}
+		remaining = left
+	}
+
+	return info, errs.Return()
+}
+
+func (s *Sources) Info(names []string) ([]Pkg, error) {
+	return s.info(names, false)
+}
+
+type cachedSources struct {
+	*Sources
+}
+
+func (c cachedSources) Info(names []string) ([]Pkg, error) {
+	return c.info(names, true)
+}
+
+// Cached returns an AURInfoProvider preferring the cache of each source.
+func (s *Sources) Cached() AURInfoProvider {
+	return cachedSources{s}
+}
+
+// merge collects the results of every source, dropping packages already
+// found in a source of higher priority. Failing sources only make the
+// query fail if no source returned anything.
+func (s *Sources) merge(query func(*Source) ([]Pkg, error)) ([]Pkg, error) {
+	var errs multierror.MultiError
+	seen := make(map[string]bool)
+	merged := make([]Pkg, 0)
+
+	for _, src := range s.List {
+		pkgs, err := query(src)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		fresh := make([]Pkg, 0, len(pkgs))
+		for i := range pkgs {
+			if !seen[pkgs[i].Name] {
+				seen[pkgs[i].Name] = true
+				fresh = append(fresh, pkgs[i])
+			}
+		}
+		s.record(src, fresh)
+		merged = append(merged, fresh...)
+	}
+
+	if err := errs.Return(); err != nil {
+		if len(merged) == 0 {
+			return nil, err
+		}
+		text.Warnln(err)
+	}
+	return merged, nil
+}
+
+func (s *Sources) Search(query string) ([]Pkg, error) {
+	return s.merge(func(src *Source) ([]Pkg, error) {
+		return src.Backend.Search(query)
+	})
+}
+
+func (s *Sources) SearchBy(query string, by aur.By) ([]Pkg, error) {
+	return s.merge(func(src *Source) ([]Pkg, error) {
+		return src.Backend.SearchBy(query, by)
+	})
+}
+
+// Providers returns the packages named name or providing it. Sources unable
+// to list providers are searched for growing prefixes of name instead.
+func (s *Sources) Providers(name string) ([]Pkg, error) {
+	return s.merge(func(src *Source) (pkgs []Pkg, err error) {
+		if finder, ok := src.Backend.(ProviderFinder); ok {
+			return finder.Providers(name)
+		}
+
+		words := strings.Split(name, "-")
+		for i := range words {
+			pkgs, err = src.Backend.Search(strings.Join(words[:i+1], "-"))
+			if err == nil {
+				break
+			}
+		}
+		return pkgs, err
+	})
+}
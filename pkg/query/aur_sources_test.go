@@ -0,0 +1,218 @@
+package query_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rpc "github.com/mikkeloscar/aur"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+type fakeBackend struct {
+	pkgs    []query.Pkg
+	err     error
+	queried [][]string
+}
+
+func (f *fakeBackend) Info(names []string) ([]query.Pkg, error) {
+	f.queried = append(f.queried, names)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	pkgs := []query.Pkg{}
+	for _, name := range names {
+		for _, pkg := range f.pkgs {
+			if pkg.Name == name {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	}
+	return pkgs, nil
+}
+
+func (f *fakeBackend) Search(query string) ([]query.Pkg, error) {
+	return f.SearchBy(query, rpc.NameDesc)
+}
+
+func (f *fakeBackend) SearchBy(q string, by rpc.By) ([]query.Pkg, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	pkgs := []query.Pkg{}
+	for _, pkg := range f.pkgs {
+		if strings.Contains(pkg.Name, q) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs, nil
+}
+
+func testSources() (*query.Sources, *fakeBackend, *fakeBackend) {
+	private := &fakeBackend{pkgs: []query.Pkg{
+		{Name: "yay", PackageBase: "yay", Version: "11.0-1"},
+		{Name: "tool", PackageBase: "tool", Version: "1.0-1"},
+	}}
+	aur := &fakeBackend{pkgs: []query.Pkg{
+		{Name: "yay", PackageBase: "yay", Version: "10.2-1"},
+		{Name: "yay-bin", PackageBase: "yay-bin", Version: "10.2-1"},
+	}}
+
+	return query.NewSources(
+		&query.Source{Name: "private", Kind: query.SourceAUR, Backend: private},
+		&query.Source{Name: "aur", Kind: query.SourceAUR, Backend: aur},
+	), private, aur
+}
+
+func TestSources_Info(t *testing.T) {
+	sources, _, aur := testSources()
+
+	pkgs, err := sources.Info([]string{"yay", "yay-bin", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yay", "yay-bin"}, names(pkgs))
+	assert.Equal(t, "11.0-1", pkgs[0].Version)
+	assert.Equal(t, [][]string{{"yay-bin", "missing"}}, aur.queried)
+
+	assert.Equal(t, "private", sources.Origin("yay").Name)
+	assert.Equal(t, "aur", sources.Origin("yay-bin").Name)
+	assert.Equal(t, "aur", sources.Origin("unknown").Name)
+	assert.Equal(t, 0, sources.Priority("yay"))
+	assert.Equal(t, 1, sources.Priority("yay-bin"))
+
+	assert.True(t, sources.IsSourceName("private"))
+	assert.True(t, sources.IsSourceName("aur"))
+	assert.False(t, sources.IsSourceName("core"))
+
+	var none *query.Sources
+	assert.True(t, none.IsSourceName("aur"))
+	assert.False(t, none.IsSourceName("private"))
+}
+
+func TestSources_Pin(t *testing.T) {
+	sources, private, _ := testSources()
+
+	assert.False(t, sources.Pin("yay", "nowhere"))
+	assert.True(t, sources.Pin("yay", "aur"))
+
+	pkgs, err := sources.Info([]string{"yay", "tool"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tool", "yay"}, names(pkgs))
+	assert.Equal(t, "10.2-1", pkgs[1].Version)
+	assert.Equal(t, "aur", sources.Origin("yay").Name)
+	assert.Equal(t, [][]string{{"tool"}}, private.queried)
+}
+
+func TestSources_Failing(t *testing.T) {
+	sources, private, aur := testSources()
+	private.err = errors.New("connection refused")
+
+	// packages of a failing source must not be taken from another one
+	pkgs, err := sources.Info([]string{"yay"})
+	assert.EqualError(t, err, "private: connection refused")
+	assert.Empty(t, pkgs)
+	assert.Empty(t, aur.queried)
+
+	text.CaptureOutput(nil, nil, func() {
+		pkgs, err = sources.Search("yay")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yay", "yay-bin"}, names(pkgs))
+	assert.Equal(t, "10.2-1", pkgs[0].Version)
+
+	aur.err = private.err
+	_, err = sources.Search("yay")
+	assert.Error(t, err)
+}
+
+func TestSources_Search(t *testing.T) {
+	sources, _, _ := testSources()
+
+	pkgs, err := sources.Search("yay")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yay", "yay-bin"}, names(pkgs))
+	assert.Equal(t, "11.0-1", pkgs[0].Version)
+
+	pkgs, err = sources.Providers("yay-bin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yay", "yay-bin"}, names(pkgs))
+	assert.Equal(t, "aur", sources.Origin("yay-bin").Name)
+}
+
+const toolSrcinfo = `pkgbase = tool
+	pkgdesc = A private tool
+	pkgver = 1.0
+	pkgrel = 2
+	arch = x86_64
+	makedepends = go
+	depends = glibc
+	depends_x86_64 = lib32-glibc
+
+pkgname = tool
+
+pkgname = tool-docs
+	pkgdesc = Documentation of tool
+	depends =
+`
+
+func TestGitSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-gitsource")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pulls := 0
+	checkout := filepath.Join(dir, "private")
+	pull := func(url, dir string) error {
+		pulls++
+		assert.Equal(t, "https://git.example.com/pkgbuilds.git", url)
+		assert.Equal(t, checkout, dir)
+		if err := os.MkdirAll(filepath.Join(dir, "tool"), 0o755); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "README"), 0o755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(dir, "tool", ".SRCINFO"), []byte(toolSrcinfo), 0o644)
+	}
+
+	g := query.NewGitSource("private", "https://git.example.com/pkgbuilds.git", checkout, pull)
+
+	var pkgs []query.Pkg
+	text.CaptureOutput(nil, nil, func() {
+		pkgs, err = g.Info([]string{"tool", "tool-docs", "missing"})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []query.Pkg{
+		{
+			Name: "tool", PackageBase: "tool", Version: "1.0-2", Description: "A private tool",
+			Maintainer: "private", Depends: []string{"glibc"}, MakeDepends: []string{"go"},
+			CheckDepends: []string{}, OptDepends: []string{}, Provides: []string{},
+			Conflicts: []string{}, Replaces: []string{},
+		},
+		{
+			Name: "tool-docs", PackageBase: "tool", Version: "1.0-2", Description: "Documentation of tool",
+			Maintainer: "private", Depends: []string{}, MakeDepends: []string{"go"},
+			CheckDepends: []string{}, OptDepends: []string{}, Provides: []string{},
+			Conflicts: []string{}, Replaces: []string{},
+		},
+	}, pkgs)
+
+	pkgs, err = g.SearchBy("go", rpc.MakeDepends)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tool", "tool-docs"}, names(pkgs))
+	assert.Equal(t, 1, pulls)
+
+	text.CaptureOutput(nil, nil, func() {
+		err = g.Update(false)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pulls)
+}
@@ -1,6 +1,8 @@
 package query
 
 import (
+	"strings"
+
 	"github.com/Jguer/yay/v10/pkg/stringset"
 	"github.com/Jguer/yay/v10/pkg/text"
 )
@@ -9,6 +11,7 @@ type AURWarnings struct {
 	Orphans   []string
 	OutOfDate []string
 	Missing   []string
+	Failed    []string
 	Ignore    stringset.StringSet
 }
 
@@ -17,6 +20,8 @@ func NewWarnings() *AURWarnings {
 }
 
 func (warnings *AURWarnings) Print() {
+	warnings.PrintFailed()
+
 	if len(warnings.Missing) > 0 {
 		text.Warn(text.T("Missing AUR Packages:"))
 		printRange(warnings.Missing)
@@ -33,6 +38,18 @@ func (warnings *AURWarnings) Print() {
 	}
 }
 
+// PrintFailed prints the packages that could not be queried to stderr, so
+// update checks printing no other warnings can report them too.
+func (warnings *AURWarnings) PrintFailed() {
+	if len(warnings.Failed) > 0 {
+		names := make([]string, 0, len(warnings.Failed))
+		for _, name := range warnings.Failed {
+			names = append(names, text.Cyan(name))
+		}
+		text.EPrintln(text.SprintWarn(text.T("Unable to query AUR Packages:"), " "+strings.Join(names, "  ")))
+	}
+}
+
 func printRange(names []string) {
 	for _, name := range names {
 		text.Print("  " + text.Cyan(name))
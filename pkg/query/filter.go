@@ -31,18 +31,18 @@ func GetRemotePackages(dbExecutor db.Executor) (remote []db.IPackage, remoteName
 	return remote, remoteNames
 }
 
-func RemoveInvalidTargets(targets []string, mode settings.TargetMode) []string {
+func RemoveInvalidTargets(targets []string, mode settings.TargetMode, sources *Sources) []string {
 	filteredTargets := make([]string, 0, len(targets))
 
 	for _, target := range targets {
 		dbName, _ := text.SplitDBFromName(target)
 
-		if dbName == "aur" && mode == settings.ModeRepo {
+		if sources.IsSourceName(dbName) && mode == settings.ModeRepo {
 			text.Warnln(text.Tf("%s: can't use target with option --repo -- skipping", text.Cyan(target)))
 			continue
 		}
 
-		if dbName != "aur" && dbName != "" && mode == settings.ModeAUR {
+		if !sources.IsSourceName(dbName) && dbName != "" && mode == settings.ModeAUR {
 			text.Warnln(text.Tf("%s: can't use target with option --aur -- skipping", text.Cyan(target)))
 			continue
 		}
@@ -11,6 +11,27 @@ import (
 // and unneeded by the system
 // removeOptional decides whether optional dependencies are counted or not
 func HangingPackages(removeOptional bool, dbExecutor db.Executor) (hanging []string) {
+	for _, pkg := range UnneededPackages(removeOptional, nil, dbExecutor) {
+		hanging = append(hanging, pkg.Name)
+	}
+	return hanging
+}
+
+// Unneeded is a package installed as a dependency that no needed package
+// depends on, with the unneeded packages still depending on it.
+type Unneeded struct {
+	Name string
+	// RequiredBy are the unneeded packages depending on it.
+	RequiredBy []string
+	// OptionalFor are the packages optionally depending on it, only set
+	// when optional dependencies are not counted.
+	OptionalFor []string
+}
+
+// UnneededPackages is HangingPackages also treating the packages satisfying
+// the dependencies in keep as needed, such as the make dependencies of
+// installed AUR packages.
+func UnneededPackages(removeOptional bool, keep []string, dbExecutor db.Executor) []Unneeded {
 	// safePackages represents every package in the system in one of 3 states
 	// State = 0 - Remove package from the system
 	// State = 1 - Keep package in the system; need to iterate over dependencies
@@ -33,6 +54,22 @@ func HangingPackages(removeOptional bool, dbExecutor db.Executor) (hanging []str
 		}
 	}
 
+	// satisfiers returns the installed packages named or providing name
+	satisfiers := func(name string) []string {
+		if _, ok := safePackages[name]; ok {
+			return []string{name}
+		}
+		return provides[name].ToSlice()
+	}
+
+	for _, dep := range keep {
+		for _, p := range satisfiers(depName(dep)) {
+			if safePackages[p] == 0 {
+				safePackages[p] = 1
+			}
+		}
+	}
+
 	iterateAgain := true
 
 	for iterateAgain {
@@ -74,13 +111,38 @@ func HangingPackages(removeOptional bool, dbExecutor db.Executor) (hanging []str
 	}
 
 	// Build list of packages to be removed
+	unneeded := make([]Unneeded, 0)
+	index := make(map[string]int)
 	for _, pkg := range packages {
 		if safePackages[pkg.Name()] == 0 {
-			hanging = append(hanging, pkg.Name())
+			index[pkg.Name()] = len(unneeded)
+			unneeded = append(unneeded, Unneeded{Name: pkg.Name()})
 		}
 	}
 
-	return hanging
+	// Record who still depends on them
+	for _, pkg := range packages {
+		for _, dep := range dbExecutor.PackageDepends(pkg) {
+			for _, p := range satisfiers(dep.Name) {
+				if i, ok := index[p]; ok && p != pkg.Name() {
+					unneeded[i].RequiredBy = append(unneeded[i].RequiredBy, pkg.Name())
+				}
+			}
+		}
+
+		if !removeOptional {
+			continue
+		}
+		for _, dep := range dbExecutor.PackageOptionalDepends(pkg) {
+			for _, p := range satisfiers(dep.Name) {
+				if i, ok := index[p]; ok && p != pkg.Name() {
+					unneeded[i].OptionalFor = append(unneeded[i].OptionalFor, pkg.Name())
+				}
+			}
+		}
+	}
+
+	return unneeded
 }
 
 // Statistics returns statistics about packages installed in system
@@ -3,6 +3,7 @@ package query_test
 import (
 	"testing"
 
+	"github.com/Jguer/yay/v10/pkg/db"
 	dbmock "github.com/Jguer/yay/v10/pkg/db/mock"
 	"github.com/Jguer/yay/v10/pkg/settings"
 
@@ -38,7 +39,7 @@ func TestHangingPackages(t *testing.T) {
 }
 
 func TestRemoveInvalidTargets(t *testing.T) {
-	ss := query.RemoveInvalidTargets([]string{}, settings.ModeAUR)
+	ss := query.RemoveInvalidTargets([]string{}, settings.ModeAUR, nil)
 
 	assert.Empty(t, ss)
 }
@@ -59,3 +60,46 @@ func TestAURInfo(t *testing.T) {
 
 	_, _ = query.AURInfo(aurMock{}, []string{}, nil, 0)
 }
+
+func TestUnneededPackages(t *testing.T) {
+	dep := func(names ...string) []db.Depend {
+		deps := make([]db.Depend, 0, len(names))
+		for _, name := range names {
+			deps = append(deps, db.Depend{Name: name})
+		}
+		return deps
+	}
+
+	mock := &dbmock.DBMock{
+		Local: []db.IPackage{
+			&dbmock.Package{PName: "foo-git", PReason: db.PkgReasonExplicit},
+			&dbmock.Package{PName: "zlib", PReason: db.PkgReasonDepend},
+			&dbmock.Package{PName: "cmake", PReason: db.PkgReasonDepend},
+			&dbmock.Package{PName: "jsoncpp", PReason: db.PkgReasonDepend},
+			&dbmock.Package{PName: "ninja", PReason: db.PkgReasonDepend},
+			&dbmock.Package{PName: "python-docs", PReason: db.PkgReasonDepend},
+		},
+		Depends: map[string][]db.Depend{
+			"foo-git": dep("zlib"),
+			"cmake":   dep("jsoncpp"),
+		},
+		OptDepends: map[string][]db.Depend{
+			"foo-git": dep("python-docs"),
+		},
+		Provides: map[string][]db.Depend{
+			"ninja": dep("ninja-build"),
+		},
+	}
+
+	assert.Equal(t, []string{"cmake", "jsoncpp", "ninja"}, query.HangingPackages(false, mock))
+
+	assert.Equal(t, []query.Unneeded{
+		{Name: "cmake"},
+		{Name: "jsoncpp", RequiredBy: []string{"cmake"}},
+		{Name: "ninja"},
+		{Name: "python-docs", OptionalFor: []string{"foo-git"}},
+	}, query.UnneededPackages(true, nil, mock))
+
+	// make dependencies of AUR packages, by name or provides
+	assert.Empty(t, query.UnneededPackages(false, []string{"cmake>=3.10", "ninja-build"}, mock))
+}
@@ -0,0 +1,222 @@
+// Package retention decides which built packages and sources of the build
+// directory are removed by yay -Sc. It only plans, deleting is left to the
+// caller.
+package retention
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// File is a built package or a source in the directory of a package base.
+// The signature of a package is part of it.
+type File struct {
+	Path string
+	Size int64
+	// Time is the modification time, for packages when they were built.
+	Time time.Time
+}
+
+// Base is what the build directory holds for a package base.
+type Base struct {
+	Name     string
+	Packages []File
+	// Sources are the downloaded sources and build leftovers not tracked by
+	// the PKGBUILD repository.
+	Sources []File
+}
+
+func newest(files []File) time.Time {
+	var last time.Time
+	for _, f := range files {
+		if f.Time.After(last) {
+			last = f.Time
+		}
+	}
+	return last
+}
+
+// LastBuilt returns when the newest package of the base was built. Bases
+// without packages were last built when their newest source was written.
+func (b *Base) LastBuilt() time.Time {
+	if len(b.Packages) == 0 {
+		return newest(b.Sources)
+	}
+	return newest(b.Packages)
+}
+
+// Policy tells what to keep. Zero values keep everything.
+type Policy struct {
+	// Versions is the number of built versions kept per base.
+	Versions int
+	// MaxSize is the size in bytes all built packages have to fit in.
+	// Older versions are removed first, the newest version of each base
+	// last.
+	MaxSize int64
+	// SourceDays removes the sources of bases not built in that many days.
+	SourceDays int
+}
+
+// Empty reports whether the policy keeps everything.
+func (p Policy) Empty() bool {
+	return p.Versions <= 0 && p.MaxSize <= 0 && p.SourceDays <= 0
+}
+
+// Removal is what is removed of a package base.
+type Removal struct {
+	Base     string
+	Packages []File
+	Sources  []File
+}
+
+// Size returns the space the removal reclaims.
+func (r *Removal) Size() int64 {
+	var size int64
+	for _, f := range r.Packages {
+		size += f.Size
+	}
+	for _, f := range r.Sources {
+		size += f.Size
+	}
+	return size
+}
+
+// PackageVersion returns the pkgver-pkgrel of a package file named
+// <pkgname>-<pkgver>-<pkgrel>-<arch>.pkg.tar<.ext>.
+func PackageVersion(path string) (string, bool) {
+	name := path[strings.LastIndex(path, "/")+1:]
+	i := strings.Index(name, ".pkg.tar")
+	if i == -1 {
+		return "", false
+	}
+
+	parts := strings.Split(name[:i], "-")
+	if len(parts) < 4 {
+		return "", false
+	}
+	return parts[len(parts)-3] + "-" + parts[len(parts)-2], true
+}
+
+// version is the packages of a base built from one version.
+type version struct {
+	base     int
+	newest   bool
+	packages []File
+	time     time.Time
+	size     int64
+}
+
+// versions groups the packages of a base by version, newest first.
+func versions(b *Base, index int) []*version {
+	byVersion := make(map[string]*version)
+	list := make([]*version, 0, 1)
+
+	for _, pkg := range b.Packages {
+		v, ok := PackageVersion(pkg.Path)
+		if !ok {
+			v = pkg.Path
+		}
+
+		ver := byVersion[v]
+		if ver == nil {
+			ver = &version{base: index}
+			byVersion[v] = ver
+			list = append(list, ver)
+		}
+		ver.packages = append(ver.packages, pkg)
+		ver.size += pkg.Size
+		if pkg.Time.After(ver.time) {
+			ver.time = pkg.Time
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].time.After(list[j].time) })
+	if len(list) > 0 {
+		list[0].newest = true
+	}
+	return list
+}
+
+// Plan returns what policy removes from bases at now. Bases losing nothing
+// are left out.
+func Plan(bases []Base, policy Policy, now time.Time) []Removal {
+	removals := make([]Removal, len(bases))
+	kept := make([]*version, 0, len(bases))
+
+	for i := range bases {
+		b := &bases[i]
+		removals[i].Base = b.Name
+
+		for n, ver := range versions(b, i) {
+			if policy.Versions > 0 && n >= policy.Versions {
+				removals[i].Packages = append(removals[i].Packages, ver.packages...)
+				continue
+			}
+			kept = append(kept, ver)
+		}
+
+		if policy.SourceDays > 0 && now.Sub(b.LastBuilt()) > time.Duration(policy.SourceDays)*24*time.Hour {
+			removals[i].Sources = append(removals[i].Sources, b.Sources...)
+		}
+	}
+
+	if policy.MaxSize > 0 {
+		var size int64
+		for _, ver := range kept {
+			size += ver.size
+		}
+
+		// oldest first, the newest version of each base only when the older
+		// ones are not enough
+		sort.SliceStable(kept, func(i, j int) bool {
+			if kept[i].newest != kept[j].newest {
+				return !kept[i].newest
+			}
+			return kept[i].time.Before(kept[j].time)
+		})
+
+		for _, ver := range kept {
+			if size <= policy.MaxSize {
+				break
+			}
+			removals[ver.base].Packages = append(removals[ver.base].Packages, ver.packages...)
+			size -= ver.size
+		}
+	}
+
+	plan := make([]Removal, 0, len(removals))
+	for _, r := range removals {
+		if len(r.Packages) > 0 || len(r.Sources) > 0 {
+			plan = append(plan, r)
+		}
+	}
+	return plan
+}
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// ParseSize parses sizes such as 512M, 10G or 1.5GiB. Units are powers of
+// 1024, a number without unit is in bytes.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	unit := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimLeft(s, "0123456789.")), "B"), "I")
+	number := strings.TrimRight(s, "BbiKkMmGgTt")
+
+	factor, ok := sizeUnits[unit]
+	n, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil || n < 0 {
+		return 0, errors.New(text.Tf("invalid size: %s", s))
+	}
+	return int64(n * float64(factor)), nil
+}
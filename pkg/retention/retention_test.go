@@ -0,0 +1,105 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC)
+
+func daysAgo(n int) time.Time {
+	return now.Add(-time.Duration(n) * 24 * time.Hour)
+}
+
+func testBases() []Base {
+	return []Base{{
+		Name: "foo",
+		Packages: []File{
+			{"foo/foo-1.0-1-x86_64.pkg.tar.zst", 100, daysAgo(30)},
+			{"foo/foo-debug-1.0-1-x86_64.pkg.tar.zst", 10, daysAgo(30)},
+			{"foo/foo-1.1-1-x86_64.pkg.tar.zst", 100, daysAgo(20)},
+			{"foo/foo-1:2.0-1-x86_64.pkg.tar.zst", 100, daysAgo(10)},
+		},
+		Sources: []File{{"foo/foo-2.0.tar.gz", 1000, daysAgo(10)}},
+	}, {
+		Name:     "bar-git",
+		Packages: []File{{"bar-git/bar-git-r10.abc-1-any.pkg.tar.xz", 50, daysAgo(60)}},
+		Sources:  []File{{"bar-git/bar", 500, daysAgo(60)}, {"bar-git/src", 200, daysAgo(60)}},
+	}, {
+		Name:    "baz",
+		Sources: []File{{"baz/baz.tar.gz", 10, daysAgo(1)}},
+	}}
+}
+
+func TestPackageVersion(t *testing.T) {
+	v, ok := PackageVersion("/build/foo/python-foo-bar-1:2.0.r3-1-x86_64.pkg.tar.zst")
+	assert.True(t, ok)
+	assert.Equal(t, "1:2.0.r3-1", v)
+
+	_, ok = PackageVersion("foo-2.0.tar.gz")
+	assert.False(t, ok)
+}
+
+func TestPlan_Versions(t *testing.T) {
+	plan := Plan(testBases(), Policy{Versions: 2}, now)
+	require.Len(t, plan, 1)
+	assert.Equal(t, "foo", plan[0].Base)
+	assert.Equal(t, []File{
+		{"foo/foo-1.0-1-x86_64.pkg.tar.zst", 100, daysAgo(30)},
+		{"foo/foo-debug-1.0-1-x86_64.pkg.tar.zst", 10, daysAgo(30)},
+	}, plan[0].Packages)
+	assert.Empty(t, plan[0].Sources)
+	assert.Equal(t, int64(110), plan[0].Size())
+}
+
+func TestPlan_MaxSize(t *testing.T) {
+	// 360 bytes of packages, the older foo versions go first
+	plan := Plan(testBases(), Policy{MaxSize: 200}, now)
+	require.Len(t, plan, 1)
+	assert.Len(t, plan[0].Packages, 3)
+
+	// then the newest versions, oldest first
+	plan = Plan(testBases(), Policy{MaxSize: 100}, now)
+	require.Len(t, plan, 2)
+	assert.Equal(t, "bar-git", plan[1].Base)
+	assert.Len(t, plan[0].Packages, 3)
+
+	assert.Empty(t, Plan(testBases(), Policy{MaxSize: 1000}, now))
+}
+
+func TestPlan_SourceDays(t *testing.T) {
+	plan := Plan(testBases(), Policy{SourceDays: 14}, now)
+	require.Len(t, plan, 1)
+	assert.Equal(t, "bar-git", plan[0].Base)
+	assert.Empty(t, plan[0].Packages)
+	assert.Equal(t, int64(700), plan[0].Size())
+
+	plan = Plan(testBases(), Policy{Versions: 1, SourceDays: 5}, now)
+	require.Len(t, plan, 2)
+	assert.Equal(t, int64(210+1000), plan[0].Size())
+	assert.Equal(t, int64(700), plan[1].Size())
+
+	assert.True(t, Policy{}.Empty())
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"100":    100,
+		"512M":   512 << 20,
+		"10G":    10 << 30,
+		"1.5GiB": 3 << 29,
+		"2gb":    2 << 30,
+	} {
+		got, err := ParseSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	for _, s := range []string{"", "G", "10X", "-1G"} {
+		_, err := ParseSize(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,133 @@
+// Package review keeps a ledger of the PKGBUILD commits that were reviewed
+// in the diff menu. The ledger is a plain json file so a team can share it.
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Approval is a reviewed commit of the PKGBUILD repo of a package base.
+type Approval struct {
+	Commit   string `json:"commit"`
+	Reviewer string `json:"reviewer"`
+	Date     int64  `json:"date"`
+}
+
+// Ledger is the list of approvals by package base backed by a json file.
+type Ledger struct {
+	Approvals map[string][]Approval
+	FilePath  string
+}
+
+func NewLedger(filePath string) *Ledger {
+	return &Ledger{
+		Approvals: map[string][]Approval{},
+		FilePath:  filePath,
+	}
+}
+
+// Approve records that reviewer approved commit of pkgbase at date.
+func (l *Ledger) Approve(pkgbase, commit, reviewer string, date time.Time) {
+	l.Approvals[pkgbase] = merge(l.Approvals[pkgbase], []Approval{{commit, reviewer, date.Unix()}})
+}
+
+// Latest returns the newest approval of pkgbase whose commit exists. It is
+// safe to call on a nil Ledger.
+func (l *Ledger) Latest(pkgbase string, exists func(commit string) bool) (Approval, bool) {
+	if l == nil {
+		return Approval{}, false
+	}
+
+	approvals := l.Approvals[pkgbase]
+	for i := len(approvals) - 1; i >= 0; i-- {
+		if exists(approvals[i].Commit) {
+			return approvals[i], true
+		}
+	}
+	return Approval{}, false
+}
+
+// merge returns the approvals of a and b oldest first. An approval of the
+// same commit by the same reviewer is kept once.
+func merge(a, b []Approval) []Approval {
+	type key struct{ commit, reviewer string }
+	seen := make(map[key]int)
+
+	merged := make([]Approval, 0, len(a)+len(b))
+	for _, approval := range append(append([]Approval{}, a...), b...) {
+		k := key{approval.Commit, approval.Reviewer}
+		if i, ok := seen[k]; ok {
+			if approval.Date < merged[i].Date {
+				merged[i].Date = approval.Date
+			}
+			continue
+		}
+		seen[k] = len(merged)
+		merged = append(merged, approval)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Date < merged[j].Date })
+	return merged
+}
+
+func (l *Ledger) read() (map[string][]Approval, error) {
+	approvals := map[string][]Approval{}
+
+	lfile, err := os.Open(l.FilePath)
+	if os.IsNotExist(err) {
+		return approvals, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(text.Tf("failed to open review ledger '%s': %s", l.FilePath, err))
+	}
+	defer lfile.Close()
+
+	if err = json.NewDecoder(lfile).Decode(&approvals); err != nil {
+		return nil, fmt.Errorf(text.Tf("failed to read review ledger '%s': %s", l.FilePath, err))
+	}
+	return approvals, nil
+}
+
+func (l *Ledger) Load() error {
+	approvals, err := l.read()
+	if err != nil {
+		return err
+	}
+	l.Approvals = approvals
+	return nil
+}
+
+// Save writes the ledger. Approvals others added to the file since it was
+// loaded are kept.
+func (l *Ledger) Save() error {
+	current, err := l.read()
+	if err != nil {
+		return err
+	}
+	for pkgbase, approvals := range current {
+		l.Approvals[pkgbase] = merge(approvals, l.Approvals[pkgbase])
+	}
+
+	marshalledinfo, err := json.MarshalIndent(l.Approvals, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.FilePath), 0o755); err != nil {
+		return err
+	}
+
+	// written to a temporary file first so syncing never sees half a ledger
+	tmp := l.FilePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(marshalledinfo, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.FilePath)
+}
@@ -0,0 +1,64 @@
+package review
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger_Latest(t *testing.T) {
+	l := NewLedger("")
+	l.Approve("foo", "aaa", "alice", time.Unix(100, 0))
+	l.Approve("foo", "bbb", "bob", time.Unix(200, 0))
+	l.Approve("foo", "aaa", "alice", time.Unix(300, 0))
+
+	assert.Len(t, l.Approvals["foo"], 2)
+
+	approval, ok := l.Latest("foo", func(string) bool { return true })
+	assert.True(t, ok)
+	assert.Equal(t, Approval{"bbb", "bob", 200}, approval)
+
+	approval, ok = l.Latest("foo", func(commit string) bool { return commit == "aaa" })
+	assert.True(t, ok)
+	assert.Equal(t, "aaa", approval.Commit)
+
+	_, ok = l.Latest("bar", func(string) bool { return true })
+	assert.False(t, ok)
+
+	var none *Ledger
+	_, ok = none.Latest("foo", func(string) bool { return true })
+	assert.False(t, ok)
+}
+
+func TestLedger_SaveMerges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-review")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "team", "reviews.json")
+
+	alice := NewLedger(path)
+	require.NoError(t, alice.Load())
+	bob := NewLedger(path)
+	require.NoError(t, bob.Load())
+
+	alice.Approve("foo", "aaa", "alice", time.Unix(100, 0))
+	require.NoError(t, alice.Save())
+
+	// bob loaded the ledger before alice saved hers
+	bob.Approve("foo", "bbb", "bob", time.Unix(200, 0))
+	bob.Approve("bar", "ccc", "bob", time.Unix(200, 0))
+	require.NoError(t, bob.Save())
+
+	l := NewLedger(path)
+	require.NoError(t, l.Load())
+	assert.Equal(t, map[string][]Approval{
+		"foo": {{"aaa", "alice", 100}, {"bbb", "bob", 200}},
+		"bar": {{"ccc", "bob", 200}},
+	}, l.Approvals)
+}
@@ -0,0 +1,250 @@
+// Package scan looks for risky patterns in PKGBUILDs and install scripts
+// before they are built.
+package scan
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Severity rates how likely a finding is to be harmful.
+type Severity int
+
+const (
+	Low Severity = iota + 1
+	Medium
+	High
+	// Never is above every finding, a threshold that never fails.
+	Never
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Low:
+		return "low"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	case Never:
+		return "never"
+	}
+	return "none"
+}
+
+// ParseSeverity parses low, medium, high or never.
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range []Severity{Low, Medium, High, Never} {
+		if strings.EqualFold(s, sev.String()) {
+			return sev, nil
+		}
+	}
+	return 0, errors.New(text.Tf("invalid severity '%s', expected low, medium, high or never", s))
+}
+
+// Finding is a risky line of a PKGBUILD or an install script.
+type Finding struct {
+	File     string
+	Line     int // 0 when the finding is not about a single line
+	Severity Severity
+	Rule     string
+	Message  string
+	Text     string
+}
+
+// Worst returns the highest severity of findings, 0 if there are none.
+func Worst(findings []Finding) Severity {
+	var worst Severity
+	for i := range findings {
+		if findings[i].Severity > worst {
+			worst = findings[i].Severity
+		}
+	}
+	return worst
+}
+
+const systemDirs = `/(etc|usr|opt|var|home|root|boot|bin|sbin|lib|lib32|lib64|srv|mnt)(/|\b)|~/|\$HOME\b|\$\{HOME\}`
+
+var (
+	funcRe     = regexp.MustCompile(`^\s*(function\s+)?([A-Za-z_][\w-]*)\s*\(\s*\)`)
+	pipeRe     = regexp.MustCompile(`\b(curl|wget)\b[^|#]*\|\s*(sudo\s+)?(ba|z|da|k)?sh\b|\b(ba|z)?sh\s+(-c\s+["']?\$\(|<\(\s*)(curl|wget)\b`)
+	sudoRe     = regexp.MustCompile(`(^|[;&|(\s])(sudo|doas|pkexec|su)\s`)
+	networkRe  = regexp.MustCompile(`\b(curl|wget|rsync|scp|ssh|nc|git\s+(clone|fetch|pull)|pip3?\s+install|npm\s+(install|ci)|cargo\s+(fetch|install)|go\s+(get|mod\s+download)|svn\s+(checkout|co))\b`)
+	decodeRe   = regexp.MustCompile(`\bbase64\s+(-\w*d\w*|--decode)\b|\bopenssl\s+(base64|enc)\b.*\s-d\b|\bxxd\s+-r\b`)
+	blobRe     = regexp.MustCompile(`[A-Za-z0-9+/]{120,}={0,2}`)
+	hexRe      = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	redirectRe = regexp.MustCompile(`>>?\s*["']?(` + systemDirs + `)`)
+	systemRe   = regexp.MustCompile(`^(` + systemDirs + `)`)
+	segmentRe  = regexp.MustCompile(`&&|\|\||[;|]`)
+	assignRe   = regexp.MustCompile(`^\s*[A-Za-z_]\w*\+?=`)
+)
+
+// writers maps commands writing files to whether only their last argument
+// is written to.
+var writers = map[string]bool{
+	"cp": true, "mv": true, "ln": true, "install": true,
+	"rm": false, "mkdir": false, "touch": false, "tee": false, "chmod": false, "chown": false,
+}
+
+// writesOutside reports whether a command line writes to a system path
+// instead of $pkgdir or $srcdir.
+func writesOutside(line string) bool {
+	if redirectRe.MatchString(line) {
+		return true
+	}
+
+	for _, segment := range segmentRe.Split(line, -1) {
+		words := strings.Fields(segment)
+		for len(words) > 0 && (words[0] == "sudo" || words[0] == "doas" || words[0] == "command") {
+			words = words[1:]
+		}
+		if len(words) < 2 {
+			continue
+		}
+		lastOnly, ok := writers[words[0]]
+		if !ok {
+			continue
+		}
+
+		args := words[1:]
+		if lastOnly {
+			args = args[len(args)-1:]
+		}
+		for _, arg := range args {
+			if systemRe.MatchString(strings.Trim(arg, `"'`)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Scan looks for risky lines in a PKGBUILD or, for file names ending in
+// .install, in an install script.
+func Scan(file, content string) []Finding {
+	install := strings.HasSuffix(file, ".install")
+	findings := make([]Finding, 0)
+
+	var (
+		fn    string
+		depth int
+		open  bool
+	)
+
+	add := func(n int, line string, sev Severity, rule, msg string) {
+		findings = append(findings, Finding{
+			File:     file,
+			Line:     n,
+			Severity: sev,
+			Rule:     rule,
+			Message:  msg,
+			Text:     strings.TrimSpace(line),
+		})
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if m := funcRe.FindStringSubmatch(line); m != nil && fn == "" {
+			fn, depth, open = m[2], 0, false
+		}
+		if fn != "" {
+			depth += strings.Count(line, "{") - strings.Count(line, "}")
+			open = open || strings.Contains(line, "{")
+		}
+		inPackage := fn == "package" || strings.HasPrefix(fn, "package_")
+		// plain variables such as pkgdesc are not run
+		command := fn != "" || !assignRe.MatchString(line) ||
+			strings.Contains(line, "$(") || strings.Contains(line, "`")
+
+		switch {
+		case !command:
+		case pipeRe.MatchString(line):
+			add(n, line, High, "pipe-shell", text.T("downloaded code is piped into a shell"))
+		case sudoRe.MatchString(line):
+			add(n, line, High, "sudo", text.T("runs commands as another user"))
+		case networkRe.MatchString(line) && install:
+			add(n, line, High, "network", text.T("install script accesses the network"))
+		case networkRe.MatchString(line) && inPackage:
+			add(n, line, Medium, "network", text.Tf("accesses the network in %s()", fn))
+		}
+
+		if command && !install && writesOutside(line) {
+			add(n, line, High, "outside-pkgdir", text.T("writes outside of $pkgdir"))
+		}
+
+		if decodeRe.MatchString(line) {
+			add(n, line, High, "base64", text.T("decodes hidden data"))
+		} else {
+			for _, blob := range blobRe.FindAllString(line, -1) {
+				if !hexRe.MatchString(strings.TrimRight(blob, "=")) {
+					add(n, line, Medium, "base64", text.T("contains a long encoded blob"))
+					break
+				}
+			}
+		}
+
+		if fn != "" && open && depth <= 0 {
+			fn = ""
+		}
+	}
+
+	return findings
+}
+
+// Domain returns the host of a source entry, "" for local files.
+func Domain(source string) string {
+	if i := strings.Index(source, "::"); i != -1 {
+		source = source[i+2:]
+	}
+	if !strings.Contains(source, "://") {
+		return ""
+	}
+	if i := strings.Index(source, "+"); i != -1 && i < strings.Index(source, "://") {
+		source = source[i+1:]
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// NewDomains reports the sources of current downloaded from a domain none
+// of the sources of previous used.
+func NewDomains(previous, current []string) []Finding {
+	known := stringset.Make()
+	for _, source := range previous {
+		known.Set(Domain(source))
+	}
+
+	findings := make([]Finding, 0)
+	reported := stringset.Make()
+	for _, source := range current {
+		domain := Domain(source)
+		if domain == "" || known.Get(domain) || reported.Get(domain) {
+			continue
+		}
+		reported.Set(domain)
+		findings = append(findings, Finding{
+			File:     "PKGBUILD",
+			Severity: Medium,
+			Rule:     "new-domain",
+			Message:  text.Tf("downloads from the new domain %s", domain),
+			Text:     source,
+		})
+	}
+
+	return findings
+}
@@ -0,0 +1,115 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pkgbuild = `# Maintainer: someone <someone@example.org>
+pkgname=foo
+pkgver=1.0
+pkgrel=1
+pkgdesc="Runs things with sudo and curl"
+source=("foo-$pkgver.tar.gz::https://example.org/foo.tar.gz")
+sha512sums=('cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e')
+
+build() {
+  cd "$srcdir/foo"
+  curl -s https://example.org/setup.sh | bash
+  make
+}
+
+package() {
+  cd "$srcdir/foo"
+  make DESTDIR="$pkgdir" install
+  install -Dm644 foo.conf "$pkgdir/etc/foo.conf"
+  ln -s /usr/lib/foo/foo "$pkgdir/usr/bin/foo"
+  wget https://example.org/extra
+  install -Dm644 foo.conf /etc/foo.conf
+  echo "eval" >> ~/.bashrc
+  sudo rm -rf /var/lib/foo
+  echo ZWNobyBoZWxsbwo= | base64 -d | sh
+}
+`
+
+func rules(findings []Finding) []string {
+	s := make([]string, 0, len(findings))
+	for i := range findings {
+		s = append(s, findings[i].Rule+":"+strings.Fields(findings[i].Text)[0])
+	}
+	return s
+}
+
+func TestScan(t *testing.T) {
+	findings := Scan("PKGBUILD", pkgbuild)
+	assert.Equal(t, []string{
+		"pipe-shell:curl",
+		"network:wget",
+		"outside-pkgdir:install",
+		"outside-pkgdir:echo",
+		"sudo:sudo",
+		"outside-pkgdir:sudo",
+		"base64:echo",
+	}, rules(findings))
+
+	assert.Equal(t, 11, findings[0].Line)
+	assert.Equal(t, High, findings[0].Severity)
+	assert.Equal(t, Medium, findings[1].Severity)
+	assert.Equal(t, High, Worst(findings))
+}
+
+func TestScan_Install(t *testing.T) {
+	install := `post_install() {
+  echo "Configure /etc/foo.conf" > /dev/null
+  cp /usr/share/foo/foo.conf /etc/foo.conf
+  curl -s https://example.org/ping
+}
+`
+	findings := Scan("foo.install", install)
+	assert.Equal(t, []string{"network:curl"}, rules(findings))
+	assert.Equal(t, High, findings[0].Severity)
+}
+
+func TestScan_Blob(t *testing.T) {
+	blob := strings.Repeat("aGVsbG8gd29ybGQK", 10)
+	findings := Scan("PKGBUILD", "prepare() {\n  x="+blob+"\n}\n")
+	assert.Equal(t, []string{"base64:x=" + blob}, rules(findings))
+
+	assert.Empty(t, Scan("PKGBUILD", "b2sums=('"+strings.Repeat("0123456789abcdef", 8)+"')\n"))
+	assert.Equal(t, Severity(0), Worst(nil))
+}
+
+func TestNewDomains(t *testing.T) {
+	previous := []string{
+		"foo-1.0.tar.gz::https://example.org/foo-1.0.tar.gz",
+		"git+https://github.com/foo/foo.git",
+		"foo.patch",
+	}
+	current := []string{
+		"foo-1.1.tar.gz::https://EXAMPLE.org/foo-1.1.tar.gz",
+		"git+https://github.com/foo/foo.git#tag=1.1",
+		"https://evil.example.net/a",
+		"https://evil.example.net/b",
+		"foo.install",
+	}
+
+	findings := NewDomains(previous, current)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "new-domain", findings[0].Rule)
+	assert.Equal(t, "https://evil.example.net/a", findings[0].Text)
+	assert.Equal(t, Medium, findings[0].Severity)
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []Severity{Low, Medium, High, Never} {
+		got, err := ParseSeverity(strings.ToUpper(s.String()))
+		require.NoError(t, err)
+		assert.Equal(t, s, got)
+	}
+
+	_, err := ParseSeverity("critical")
+	assert.Error(t, err)
+}
@@ -0,0 +1,545 @@
+// Package search implements the query language of package searches.
+//
+// A query is a list of terms, all of which have to match. Terms can be
+// combined with OR (or |), negated with NOT, ! or a leading - and grouped
+// with parentheses. A term is a word matched against the default field, or
+// field:value for another field. Values are substrings unless written as
+// =value for an exact match or /regex/ for a case insensitive regular
+// expression. Numeric fields take a comparison like votes:>100, the updated
+// field an age like updated:<30d.
+package search
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Fields of a package that can be searched.
+const (
+	NameDesc     = "name-desc"
+	Name         = "name"
+	Desc         = "desc"
+	Maintainer   = "maintainer"
+	Depends      = "depends"
+	MakeDepends  = "makedepends"
+	OptDepends   = "optdepends"
+	CheckDepends = "checkdepends"
+	Provides     = "provides"
+	Keyword      = "keyword"
+	Group        = "group"
+	License      = "license"
+	Repo         = "repo"
+	Votes        = "votes"
+	Popularity   = "popularity"
+	Updated      = "updated"
+)
+
+var listFields = map[string]bool{
+	Depends: true, MakeDepends: true, OptDepends: true, CheckDepends: true,
+	Provides: true, Keyword: true, Group: true, License: true,
+}
+
+var fields = map[string]bool{
+	NameDesc: true, Name: true, Desc: true, Maintainer: true, Repo: true,
+	Votes: true, Popularity: true, Updated: true,
+}
+
+func init() {
+	for field := range listFields {
+		fields[field] = true
+	}
+}
+
+// seedFields can be handed to the AUR rpc search.
+var seedFields = map[string]bool{
+	NameDesc: true, Name: true, Maintainer: true,
+	Depends: true, MakeDepends: true, OptDepends: true, CheckDepends: true,
+}
+
+// Package holds the searchable fields of a repo or AUR package. List fields
+// hold dependency names without version constraints.
+type Package struct {
+	Name        string
+	Description string
+	Maintainer  string
+	Repo        string
+	Lists       map[string][]string
+	// Rated is false for packages without votes and popularity.
+	Rated      bool
+	Votes      int
+	Popularity float64
+	Updated    time.Time
+}
+
+// Seed is a term a remote search can start from. Field is the searchby
+// name of the field.
+type Seed struct {
+	Field string
+	Value string
+}
+
+type op int
+
+const (
+	opTerm op = iota
+	opAnd
+	opOr
+	opNot
+)
+
+type node struct {
+	op   op
+	kids []*node
+	term *term
+}
+
+type term struct {
+	field string
+	value string
+	exact bool
+	re    *regexp.Regexp
+	// comparison of numeric and date fields
+	cmp string
+	num float64
+	age time.Duration
+}
+
+// Query is a parsed search query.
+type Query struct {
+	root *node
+	// Now is the reference of relative dates.
+	Now time.Time
+}
+
+// Parse parses the words of a query. Bare words are matched against
+// defaultField.
+func Parse(words []string, defaultField string) (*Query, error) {
+	if !fields[defaultField] {
+		defaultField = NameDesc
+	}
+
+	p := &parser{tokens: tokenize(strings.Join(words, " ")), defaultField: defaultField}
+	if len(p.tokens) == 0 {
+		return nil, text.ErrT("no search terms given")
+	}
+
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, errors.New(text.Tf("unexpected '%s' in query", p.tokens[p.pos]))
+	}
+
+	return &Query{root: root, Now: time.Now()}, nil
+}
+
+// tokenize splits a query at whitespace and parentheses outside of regular
+// expressions. Double quotes group words into a single token.
+func tokenize(input string) []string {
+	tokens := make([]string, 0)
+	var cur strings.Builder
+	quoted := false
+
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case (r == '(' || r == ')') && strings.Count(cur.String(), "/")%2 == 0:
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+type parser struct {
+	tokens       []string
+	pos          int
+	defaultField string
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) or() (*node, error) {
+	n, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	kids := []*node{n}
+	for p.peek() == "OR" || p.peek() == "|" {
+		p.pos++
+		n, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		kids = append(kids, n)
+	}
+
+	if len(kids) == 1 {
+		return kids[0], nil
+	}
+	return &node{op: opOr, kids: kids}, nil
+}
+
+func (p *parser) and() (*node, error) {
+	kids := make([]*node, 0, 1)
+	for {
+		switch tok := p.peek(); tok {
+		case "", ")", "OR", "|":
+			if len(kids) == 0 {
+				if tok == "" {
+					return nil, text.ErrT("unexpected end of query")
+				}
+				return nil, errors.New(text.Tf("unexpected '%s' in query", tok))
+			}
+			if len(kids) == 1 {
+				return kids[0], nil
+			}
+			return &node{op: opAnd, kids: kids}, nil
+		case "AND":
+			p.pos++
+			continue
+		}
+
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		kids = append(kids, n)
+	}
+}
+
+func (p *parser) unary() (*node, error) {
+	tok := p.peek()
+	switch {
+	case tok == "NOT" || tok == "!":
+		p.pos++
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{op: opNot, kids: []*node{n}}, nil
+	case tok == "(":
+		p.pos++
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, text.ErrT("missing ')' in query")
+		}
+		p.pos++
+		return n, nil
+	case len(tok) > 1 && (tok[0] == '-' || tok[0] == '!'):
+		p.pos++
+		t, err := p.term(tok[1:])
+		if err != nil {
+			return nil, err
+		}
+		return &node{op: opNot, kids: []*node{{op: opTerm, term: t}}}, nil
+	}
+
+	p.pos++
+	t, err := p.term(tok)
+	if err != nil {
+		return nil, err
+	}
+	return &node{op: opTerm, term: t}, nil
+}
+
+func (p *parser) term(tok string) (*term, error) {
+	t := &term{field: p.defaultField, value: tok}
+	if i := strings.IndexByte(tok, ':'); i > 0 {
+		field := strings.ToLower(tok[:i])
+		if field == "keywords" {
+			field = Keyword
+		}
+		if !fields[field] {
+			return nil, errors.New(text.Tf("unknown search field '%s'", tok[:i]))
+		}
+		t.field, t.value = field, tok[i+1:]
+	}
+
+	switch t.field {
+	case Votes, Popularity:
+		return t, t.parseNumber()
+	case Updated:
+		return t, t.parseAge()
+	}
+
+	switch {
+	case len(t.value) > 1 && strings.HasPrefix(t.value, "/") && strings.HasSuffix(t.value, "/"):
+		re, err := regexp.Compile("(?i)" + t.value[1:len(t.value)-1])
+		if err != nil {
+			return nil, errors.New(text.Tf("invalid regex '%s': %s", t.value, err))
+		}
+		t.re = re
+	case strings.HasPrefix(t.value, "="):
+		t.exact = true
+		t.value = t.value[1:]
+	}
+
+	if t.re == nil && t.value == "" {
+		return nil, errors.New(text.Tf("missing value in '%s'", tok))
+	}
+	t.value = strings.ToLower(t.value)
+
+	return t, nil
+}
+
+func splitComparison(value string) (cmp, rest string) {
+	for _, c := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, c) {
+			return c, value[len(c):]
+		}
+	}
+	return "=", value
+}
+
+func (t *term) parseNumber() error {
+	var rest string
+	t.cmp, rest = splitComparison(t.value)
+
+	num, err := strconv.ParseFloat(rest, 64)
+	if err != nil {
+		return errors.New(text.Tf("invalid number in '%s:%s'", t.field, t.value))
+	}
+	t.num = num
+	return nil
+}
+
+var ageUnits = map[byte]time.Duration{
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+	'm': 30 * 24 * time.Hour,
+	'y': 365 * 24 * time.Hour,
+}
+
+func (t *term) parseAge() error {
+	var rest string
+	t.cmp, rest = splitComparison(t.value)
+
+	invalid := errors.New(text.Tf("invalid age in '%s:%s', use e.g. <30d", t.field, t.value))
+	if rest == "" {
+		return invalid
+	}
+
+	unit, ok := ageUnits[rest[len(rest)-1]]
+	if !ok {
+		return invalid
+	}
+
+	n, err := strconv.Atoi(rest[:len(rest)-1])
+	if err != nil {
+		return invalid
+	}
+
+	t.age = time.Duration(n) * unit
+	return nil
+}
+
+func (t *term) matchString(s string) bool {
+	switch {
+	case t.re != nil:
+		return t.re.MatchString(s)
+	case t.exact:
+		return strings.ToLower(s) == t.value
+	default:
+		return strings.Contains(strings.ToLower(s), t.value)
+	}
+}
+
+func (t *term) matchList(values []string) bool {
+	for _, v := range values {
+		if t.re != nil && t.re.MatchString(v) || t.re == nil && strings.ToLower(v) == t.value {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(cmp string, a, b float64) bool {
+	switch cmp {
+	case ">":
+		return a > b
+	case "<":
+		return a < b
+	case ">=":
+		return a >= b
+	case "<=":
+		return a <= b
+	default:
+		return a == b
+	}
+}
+
+func (t *term) match(pkg *Package, now time.Time) bool {
+	switch t.field {
+	case NameDesc:
+		return t.matchString(pkg.Name) || !t.exact && t.matchString(pkg.Description)
+	case Name:
+		return t.matchString(pkg.Name)
+	case Desc:
+		return t.matchString(pkg.Description)
+	case Maintainer:
+		if t.re == nil {
+			return strings.ToLower(pkg.Maintainer) == t.value
+		}
+		return t.matchString(pkg.Maintainer)
+	case Repo:
+		if t.re == nil {
+			return strings.ToLower(pkg.Repo) == t.value
+		}
+		return t.matchString(pkg.Repo)
+	case Votes:
+		return pkg.Rated && compare(t.cmp, float64(pkg.Votes), t.num)
+	case Popularity:
+		return pkg.Rated && compare(t.cmp, pkg.Popularity, t.num)
+	case Updated:
+		if pkg.Updated.IsZero() {
+			return false
+		}
+		// a smaller age is a more recent update
+		return compare(t.cmp, float64(now.Sub(pkg.Updated)), float64(t.age))
+	default:
+		return t.matchList(pkg.Lists[t.field])
+	}
+}
+
+func (n *node) match(pkg *Package, now time.Time) bool {
+	switch n.op {
+	case opAnd:
+		for _, kid := range n.kids {
+			if !kid.match(pkg, now) {
+				return false
+			}
+		}
+		return true
+	case opOr:
+		for _, kid := range n.kids {
+			if kid.match(pkg, now) {
+				return true
+			}
+		}
+		return false
+	case opNot:
+		return !n.kids[0].match(pkg, now)
+	default:
+		return n.term.match(pkg, now)
+	}
+}
+
+// Match reports whether a package matches the query.
+func (q *Query) Match(pkg *Package) bool {
+	return q.root.match(pkg, q.Now)
+}
+
+// Uses reports whether the query looks at a field. List fields are costly
+// to gather for repo packages and are only needed when used.
+func (q *Query) Uses(field string) bool {
+	var uses func(n *node) bool
+	uses = func(n *node) bool {
+		if n.term != nil {
+			return n.term.field == field
+		}
+		for _, kid := range n.kids {
+			if uses(kid) {
+				return true
+			}
+		}
+		return false
+	}
+	return uses(q.root)
+}
+
+// Seeds returns alternative sets of seeds in order of preference. Searching
+// for every seed of a set and matching the union of the results against
+// the query finds every match.
+func (q *Query) Seeds() [][]Seed {
+	return seeds(q.root)
+}
+
+func seeds(n *node) [][]Seed {
+	switch n.op {
+	case opTerm:
+		t := n.term
+		if t.re != nil || !seedFields[t.field] {
+			return nil
+		}
+		return [][]Seed{{{Field: t.field, Value: t.value}}}
+	case opAnd:
+		// any of the terms narrows the search
+		var alternatives [][]Seed
+		for _, kid := range n.kids {
+			alternatives = append(alternatives, seeds(kid)...)
+		}
+		return alternatives
+	case opOr:
+		// every branch has to be searched
+		union := make([]Seed, 0)
+		for _, kid := range n.kids {
+			alternatives := seeds(kid)
+			if len(alternatives) == 0 {
+				return nil
+			}
+			union = append(union, alternatives[0]...)
+		}
+		return [][]Seed{union}
+	default:
+		return nil
+	}
+}
+
+// Words returns the lowercase literal words the query looks for in names
+// and descriptions. Negated and regex terms are left out.
+func (q *Query) Words() []string {
+	words := make([]string, 0)
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		switch n.op {
+		case opNot:
+			return
+		case opTerm:
+			t := n.term
+			if t.re == nil && (t.field == NameDesc || t.field == Name || t.field == Desc) {
+				words = append(words, t.value)
+			}
+		default:
+			for _, kid := range n.kids {
+				walk(kid)
+			}
+		}
+	}
+	walk(q.root)
+
+	return words
+}
@@ -0,0 +1,156 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+var pkgs = []*Package{
+	{
+		Name: "yay", Description: "Yet another yogurt. Pacman wrapper and AUR helper written in go.",
+		Maintainer: "jguer", Repo: "aur", Rated: true, Votes: 1500, Popularity: 40,
+		Updated: now.AddDate(0, 0, -3),
+		Lists: map[string][]string{
+			Depends: {"pacman", "git"}, MakeDepends: {"go"}, Keyword: {"AUR", "helper"},
+		},
+	},
+	{
+		Name: "paru", Description: "Feature packed AUR helper",
+		Maintainer: "Morganamilo", Repo: "aur", Rated: true, Votes: 800, Popularity: 30,
+		Updated: now.AddDate(0, -3, 0),
+		Lists:   map[string][]string{Depends: {"git", "pacman"}, MakeDepends: {"cargo"}},
+	},
+	{
+		Name: "yay-bin", Description: "Yet another yogurt (binary)",
+		Maintainer: "jguer", Repo: "aur", Rated: true, Votes: 90, Popularity: 5,
+		Updated: now.AddDate(-1, 0, 0),
+		Lists:   map[string][]string{Provides: {"yay"}},
+	},
+	{
+		Name: "pacman", Description: "A library-based package manager with dependency support",
+		Repo: "core", Updated: now.AddDate(0, 0, -10),
+		Lists: map[string][]string{Group: {"base-devel"}},
+	},
+}
+
+func matching(t *testing.T, query, defaultField string) []string {
+	q, err := Parse(strings.Fields(query), defaultField)
+	require.NoError(t, err, query)
+	q.Now = now
+
+	names := []string{}
+	for _, pkg := range pkgs {
+		if q.Match(pkg) {
+			names = append(names, pkg.Name)
+		}
+	}
+	return names
+}
+
+func TestQuery_Match(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"yay", []string{"yay", "yay-bin"}},
+		{"YOGURT binary", []string{"yay-bin"}},
+		{"helper", []string{"yay", "paru"}},
+		{"=yay", []string{"yay"}},
+		{"yay OR paru", []string{"yay", "paru", "yay-bin"}},
+		{"yay | paru", []string{"yay", "paru", "yay-bin"}},
+		{"yay NOT bin", []string{"yay"}},
+		{"yay !bin", []string{"yay"}},
+		{"yay -bin", []string{"yay"}},
+		{"helper AND NOT (maintainer:jguer OR votes:<100)", []string{"paru"}},
+		{"name:/^ya?y$/", []string{"yay"}},
+		{"(name:/^(paru|pacman)$/)", []string{"paru", "pacman"}},
+		{"/manager|yogurt/", []string{"yay", "yay-bin", "pacman"}},
+		{"desc:pacman", []string{"yay"}},
+		{"maintainer:JGUER", []string{"yay", "yay-bin"}},
+		{"maintainer:/^morgan/", []string{"paru"}},
+		{"depends:git", []string{"yay", "paru"}},
+		{"makedepends:cargo", []string{"paru"}},
+		{"provides:yay", []string{"yay-bin"}},
+		{"keyword:helper", []string{"yay"}},
+		{"keywords:aur", []string{"yay"}},
+		{"group:base-devel", []string{"pacman"}},
+		{"repo:core", []string{"pacman"}},
+		{"votes:>100", []string{"yay", "paru"}},
+		{"votes:>=90 votes:<=800", []string{"paru", "yay-bin"}},
+		{"popularity:30", []string{"paru"}},
+		{"updated:<30d", []string{"yay", "pacman"}},
+		{"updated:>1m", []string{"paru", "yay-bin"}},
+		{"updated:<2w -repo:aur", []string{"pacman"}},
+		{`"feature packed"`, []string{"paru"}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matching(t, tt.query, NameDesc), tt.query)
+	}
+}
+
+func TestQuery_DefaultField(t *testing.T) {
+	assert.Equal(t, []string{"yay", "yay-bin"}, matching(t, "jguer", Maintainer))
+	assert.Equal(t, []string{"yay", "paru"}, matching(t, "git", Depends))
+	assert.Equal(t, []string{"pacman"}, matching(t, "library", "unknown-field"))
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"(yay",
+		"yay)",
+		"yay OR",
+		"NOT",
+		"color:blue",
+		"name:/[/",
+		"votes:many",
+		"updated:30",
+		"updated:<30x",
+		"name:",
+	} {
+		_, err := Parse(strings.Fields(query), NameDesc)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestQuery_Seeds(t *testing.T) {
+	tests := []struct {
+		query string
+		want  [][]Seed
+	}{
+		{"yay", [][]Seed{{{NameDesc, "yay"}}}},
+		{"votes:>10 Yay depends:git", [][]Seed{{{NameDesc, "yay"}}, {{Depends, "git"}}}},
+		{"yay OR maintainer:jguer", [][]Seed{{{NameDesc, "yay"}, {Maintainer, "jguer"}}}},
+		{"(yay OR /paru/) helper", [][]Seed{{{NameDesc, "helper"}}}},
+		{"NOT yay", nil},
+		{"/yay/ votes:>10", nil},
+	}
+
+	for _, tt := range tests {
+		q, err := Parse(strings.Fields(tt.query), NameDesc)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, q.Seeds(), tt.query)
+	}
+}
+
+func TestQuery_Uses(t *testing.T) {
+	q, err := Parse([]string{"yay", "OR", "(depends:git", "-group:base)"}, NameDesc)
+	require.NoError(t, err)
+
+	assert.True(t, q.Uses(Depends))
+	assert.True(t, q.Uses(Group))
+	assert.False(t, q.Uses(Provides))
+}
+
+func TestQuery_Words(t *testing.T) {
+	q, err := Parse(strings.Fields("Yay OR (name:=paru desc:helper) -bin /go/ maintainer:jguer"), NameDesc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yay", "paru", "helper"}, q.Words())
+}
@@ -60,8 +60,13 @@ type YayConfig struct {
 	SaveConfig     bool
 	Mode           TargetMode
 	SearchMode     SearchMode
+	Downgrade      bool
 	CompletionPath string
 	ConfigPath     string
+	CacheDir       string
+	Offline        bool
+	TrustChanges   bool
+	Format         string
 
 	PersistentYayConfig
 	Targets []string
@@ -92,14 +97,29 @@ type PConf struct {
 	LocalStats    bool
 	News          bool
 	Quiet         bool
+	Watch         bool
+	ForeignHealth bool
+	Comments      bool
+	CacheStats    bool
 
 	Upgrades       bool
 	NumberUpgrades bool
 }
 
 type YConf struct {
-	GenDevDB bool
-	Clean    Trilean
+	GenDevDB  bool
+	Clean     Trilean
+	Hold      bool
+	Unhold    bool
+	Holds     bool
+	HoldUntil string
+	Reason    string
+	Vote      bool
+	Unvote    bool
+	Flag      bool
+	Notify    bool
+	Unnotify  bool
+	Adopt     bool
 }
 
 type GConf struct {
@@ -140,6 +160,25 @@ type PersistentYayConfig struct {
 	RequestSplitN      int    `json:"requestsplitn"`
 	SortMode           int    `json:"sortmode"`
 	CompletionInterval int    `json:"completionrefreshtime"`
+	WatchInterval      int    `json:"watchinterval"`
+	WatchCmd           string `json:"watchcmd"`
+	OutOfDateDays      int    `json:"outofdatedays"`
+	AURCacheTTL        int    `json:"aurcachettl"`
+	AURBackend         string `json:"aurbackend"`
+	SearchRank         string `json:"searchrank"`
+	DiffComments       int    `json:"diffcomments"`
+	AURUser            string `json:"auruser"`
+	AURPassCmd         string `json:"aurpasscmd"`
+	ScanFail           string `json:"scanfail"`
+	PGPKeyDir          string `json:"pgpkeydir"`
+	ReviewLedger       string `json:"reviewledger"`
+	Reviewer           string `json:"reviewer"`
+	SignKey            string `json:"signkey"`
+	LocalRepo          string `json:"localrepo"`
+	CleanKeep          int    `json:"cleankeep"`
+	CleanMaxSize       string `json:"cleanmaxsize"`
+	CleanSourceDays    int    `json:"cleansourcedays"`
+	KeepMakeDeps       bool   `json:"keepmakedeps"`
 	SudoLoop           bool   `json:"sudoloop"`
 	TimeUpdate         bool   `json:"timeupdate"`
 	Devel              bool   `json:"devel"`
@@ -147,16 +186,32 @@ type PersistentYayConfig struct {
 	Provides           bool   `json:"provides"`
 	PGPFetch           bool   `json:"pgpfetch"`
 	UpgradeMenu        bool   `json:"upgrademenu"`
+	UpgradeSelector    bool   `json:"upgradeselector"`
 	CleanMenu          bool   `json:"cleanmenu"`
 	DiffMenu           bool   `json:"diffmenu"`
 	EditMenu           bool   `json:"editmenu"`
 	CombinedUpgrade    bool   `json:"combinedupgrade"`
 	UseAsk             bool   `json:"useask"`
 	BatchInstall       bool   `json:"batchinstall"`
+	AutoHold           bool   `json:"autohold"`
+	PkgbuildScan       bool   `json:"pkgbuildscan"`
+
+	Sources []PkgbuildSource `json:"sources,omitempty"`
 
 	Tar string `json:"tar"`
 }
 
+// PkgbuildSource is a named repository of PKGBUILDs queried besides the
+// AUR. Sources are listed by priority, an entry named aur places the AUR
+// among them. Otherwise the AUR comes last.
+type PkgbuildSource struct {
+	Name string `json:"name"`
+	// Type is either aur for AUR compatible rpc endpoints or git for a git
+	// repository with a directory per package base.
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
 var defaultYayConfig = PersistentYayConfig{
 	AURURL:             "https://aur.archlinux.org",
 	BuildDir:           os.ExpandEnv("$HOME/.cache/yay"),
@@ -175,6 +230,10 @@ var defaultYayConfig = PersistentYayConfig{
 	GitFlags:           "",
 	SortMode:           BottomUp,
 	CompletionInterval: 7,
+	OutOfDateDays:      30,
+	AURBackend:         "rpc",
+	SearchRank:         "blocks",
+	ScanFail:           "never",
 	SortBy:             "votes",
 	SearchBy:           "name-desc",
 	SudoLoop:           false,
@@ -194,11 +253,14 @@ var defaultYayConfig = PersistentYayConfig{
 	RemoveMake:         "ask",
 	Provides:           true,
 	UpgradeMenu:        true,
+	UpgradeSelector:    false,
 	CleanMenu:          true,
 	DiffMenu:           true,
 	EditMenu:           false,
 	UseAsk:             false,
 	CombinedUpgrade:    false,
+	AutoHold:           false,
+	PkgbuildScan:       true,
 }
 
 func Defaults() *PersistentYayConfig {
@@ -289,6 +351,14 @@ func (c *PersistentYayConfig) expandEnv() {
 	c.AnswerEdit = os.ExpandEnv(c.AnswerEdit)
 	c.AnswerUpgrade = os.ExpandEnv(c.AnswerUpgrade)
 	c.RemoveMake = os.ExpandEnv(c.RemoveMake)
+	c.WatchCmd = os.ExpandEnv(c.WatchCmd)
+	c.PGPKeyDir = os.ExpandEnv(c.PGPKeyDir)
+	c.ReviewLedger = os.ExpandEnv(c.ReviewLedger)
+	c.SignKey = os.ExpandEnv(c.SignKey)
+	c.LocalRepo = os.ExpandEnv(c.LocalRepo)
+	for i := range c.Sources {
+		c.Sources[i].URL = os.ExpandEnv(c.Sources[i].URL)
+	}
 }
 
 func (c *PersistentYayConfig) load(configPath string) error {
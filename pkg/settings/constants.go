@@ -29,6 +29,10 @@ If no operation is provided -Y will be assumed
 New options:
        --repo             Assume targets are from the repositories
     -a --aur              Assume targets are from the AUR
+       --downgrade        Pick an older version of AUR targets to install (-S)
+       --offline          Only use cached AUR package info
+       --trustchanges     Accept changed PGP keys, sources and maintainers without asking
+       --format   <fmt>   Print -Ss, -Si and -Qu as json, tsv or a Go template
 
 Permanent configuration options:
     --save                Causes the following options to be saved back to the
@@ -54,6 +58,7 @@ Permanent configuration options:
     --completioninterval  <n> Time in days to refresh completion cache
     --sortby    <field>   Sort AUR results by a specific field during search
     --searchby  <field>   Search for packages using a specified field
+    --searchrank  <rank>  Rank search results by relevance, votes or in blocks
     --answerclean   <a>   Set a predetermined answer for the clean build menu
     --answerdiff    <a>   Set a predetermined answer for the diff menu
     --answeredit    <a>   Set a predetermined answer for the edit pkgbuild menu
@@ -66,10 +71,12 @@ Permanent configuration options:
     --diffmenu            Give the option to show diffs for build files
     --editmenu            Give the option to edit/view PKGBUILDS
     --upgrademenu         Show a detailed list of updates with the option to skip any
+    --upgradeselector     Make the upgrade menu interactive on a terminal
     --nocleanmenu         Don't clean build PKGBUILDS
     --nodiffmenu          Don't show diffs for build files
     --noeditmenu          Don't edit/view PKGBUILDS
     --noupgrademenu       Don't show the upgrade menu
+    --noupgradeselector   Use the plain upgrade menu
     --askremovemake       Ask to remove makedepends after install
     --removemake          Remove makedepends after install
     --noremovemake        Don't remove makedepends after install
@@ -92,6 +99,16 @@ Permanent configuration options:
     --noprovides          Just look for packages by pkgname
     --pgpfetch            Prompt to import PGP keys from PKGBUILDs
     --nopgpfetch          Don't prompt to import PGP keys
+    --pgpkeydir   <dir>   Directory of <fingerprint>.asc keys tried before keyservers
+    --reviewledger <file> File recording reviewed PKGBUILD commits, can be shared
+    --reviewer    <name>  Name to record reviews under (default: user@host)
+    --signkey     <key>   Sign built packages and the local repo with this gpg key
+    --localrepo   <db>    Add built packages to this repo database, e.g. custom.db.tar.gz
+    --cleankeep   <n>     -Sc keeps the last n built versions of each package
+    --cleanmaxsize <size> -Sc keeps only the built packages fitting in size, e.g. 10G
+    --cleansourcedays <n> -Sc removes sources of packages not built in n days
+    --keepmakedeps        -Yc keeps make and check dependencies of AUR packages
+    --nokeepmakedeps      -Yc removes make and check dependencies of AUR packages
     --useask              Automatically resolve conflicts using pacman's ask flag
     --nouseask            Confirm conflicts manually during the install
     --combinedupgrade     Refresh then perform the repo and AUR upgrade together
@@ -107,16 +124,46 @@ Permanent configuration options:
     --timeupdate          Check packages' AUR page for changes during sysupgrade
     --notimeupdate        Do not check packages' AUR page for changes
 
+    --watchinterval <n>   Minutes between checks of --watch, 0 checks once
+    --watchcmd    <cmd>   Command notified by --watch instead of printing JSON
+
+    --autohold            Hold AUR packages that are orphaned or removed from the AUR
+    --noautohold          Do not hold orphaned or removed AUR packages
+    --outofdatedays <n>   Days flagged out of date before a package is a high risk
+    --aurcachettl   <n>   Minutes AUR package info is cached before revalidating
+    --aurbackend    <b>   Query the AUR through rpc or a local metadata dump
+    --diffcomments  <n>   Latest AUR comments shown in the diff menu, 0 for none
+    --auruser    <name>   AUR account used by --vote, --flag and friends
+    --aurpasscmd  <cmd>   Command printing the password of the AUR account
+    --pkgbuildscan        Scan PKGBUILDs for risky commands before building
+    --nopkgbuildscan      Do not scan PKGBUILDs
+    --scanfail <level>    Lowest scan finding failing a --noconfirm build
+
 show specific options:
     -c --complete         Used for completions
     -d --defaultconfig    Print default yay configuration
     -g --currentconfig    Print current yay configuration
     -s --stats            Display system package statistics
     -w --news             Print arch news
+       --watch            Check for upgrades without root and report them
+       --foreign-health   Rank installed AUR packages by maintenance risk
+       --comments         Print the pinned and latest AUR comments of packages
+       --cachestats       Show the disk usage of each package base in the cache
 
 yay specific options:
     -c --clean            Remove unneeded dependencies
        --gendb            Generates development package DB used for updating
+       --hold             Hold upgrades of packages, pkg=version skips one version
+       --unhold           Remove the holds of packages
+       --holds            List held packages
+       --until   <date>   Hold until the given date (YYYY-MM-DD)
+       --reason  <text>   Record why a package is held or flagged out of date
+       --vote             Vote for the AUR packages
+       --unvote           Remove the votes for the AUR packages
+       --flag             Flag the AUR packages out of date, needs --reason
+       --notify           Get notified of comments on the AUR packages
+       --unnotify         Stop notifications of the AUR packages
+       --adopt            Adopt orphaned AUR packages
 
 getpkgbuild specific options:
     -f --force            Force download for existing ABS packages
@@ -278,6 +325,8 @@ const (
 	noPGPFetch
 	upgradeMenu
 	noUpgradeMenu
+	upgradeSelector
+	noUpgradeSelector
 	cleanMenu
 	noCleanMenu
 	diffMenu
@@ -291,6 +340,30 @@ const (
 	batchInstall
 	noBatchInstall
 	tar
+	watchInterval
+	watchCmd
+	autoHold
+	noAutoHold
+	outOfDateDays
+	aurCacheTTL
+	aurBackend
+	searchRank
+	diffComments
+	aurUser
+	aurPassCmd
+	pkgbuildScan
+	noPkgbuildScan
+	scanFail
+	pgpKeyDir
+	reviewLedger
+	reviewer
+	signKey
+	localRepo
+	cleanKeep
+	cleanMaxSize
+	cleanSourceDays
+	keepMakeDeps
+	noKeepMakeDeps
 
 	// Yay Show options (P)
 	complete
@@ -299,20 +372,42 @@ const (
 	stats
 	news
 	fish
+	watch
+	foreignHealth
+	comments
+	cacheStats
 	numberUpgrades // deprecated
 
 	// Yay yay-mode options (Y)
 	yayClean
 	genDB
+	hold
+	unhold
+	holds
+	holdUntil
+	reason
+	vote
+	unvote
+	flag
+	notify
+	unnotify
+	adopt
 
 	// Yay GetPkgbuild options (G)
 	force
 
+	// Yay sync options (S)
+	downgrade
+
 	// Mode
 	aur
 	repo
 	// any
 
+	offline
+	outputFormat
+	trustChanges
+
 	// misc options
 	save
 
@@ -352,7 +447,7 @@ func mapping(option string, mainOp OpMode) parser.Enum {
 			return complete
 		}
 		if mainOp == OpYay {
-			return clean
+			return yayClean
 		}
 		return parser.InvalidOption
 	case "u":
@@ -648,6 +743,10 @@ func mapping(option string, mainOp OpMode) parser.Enum {
 		return upgradeMenu
 	case "noupgrademenu":
 		return noUpgradeMenu
+	case "upgradeselector":
+		return upgradeSelector
+	case "noupgradeselector":
+		return noUpgradeSelector
 	case "cleanmenu":
 		return cleanMenu
 	case "nocleanmenu":
@@ -672,6 +771,12 @@ func mapping(option string, mainOp OpMode) parser.Enum {
 		return aur
 	case "repo":
 		return repo
+	case "offline":
+		return offline
+	case "trustchanges":
+		return trustChanges
+	case "format":
+		return outputFormat
 	case "removemake":
 		return removeMake
 	case "noremovemake":
@@ -698,6 +803,86 @@ func mapping(option string, mainOp OpMode) parser.Enum {
 		return fish
 	case "tar":
 		return tar
+	case "downgrade":
+		return downgrade
+	case "watch":
+		return watch
+	case "watchinterval":
+		return watchInterval
+	case "watchcmd":
+		return watchCmd
+	case "foreign-health":
+		return foreignHealth
+	case "comments":
+		return comments
+	case "cachestats":
+		return cacheStats
+	case "diffcomments":
+		return diffComments
+	case "autohold":
+		return autoHold
+	case "noautohold":
+		return noAutoHold
+	case "outofdatedays":
+		return outOfDateDays
+	case "aurcachettl":
+		return aurCacheTTL
+	case "aurbackend":
+		return aurBackend
+	case "searchrank":
+		return searchRank
+	case "pkgbuildscan":
+		return pkgbuildScan
+	case "nopkgbuildscan":
+		return noPkgbuildScan
+	case "scanfail":
+		return scanFail
+	case "pgpkeydir":
+		return pgpKeyDir
+	case "reviewledger":
+		return reviewLedger
+	case "reviewer":
+		return reviewer
+	case "signkey":
+		return signKey
+	case "localrepo":
+		return localRepo
+	case "cleankeep":
+		return cleanKeep
+	case "cleanmaxsize":
+		return cleanMaxSize
+	case "cleansourcedays":
+		return cleanSourceDays
+	case "keepmakedeps":
+		return keepMakeDeps
+	case "nokeepmakedeps":
+		return noKeepMakeDeps
+	case "hold":
+		return hold
+	case "unhold":
+		return unhold
+	case "holds":
+		return holds
+	case "until":
+		return holdUntil
+	case "reason":
+		return reason
+	case "vote":
+		return vote
+	case "unvote":
+		return unvote
+	case "flag":
+		return flag
+	case "notify":
+		return notify
+	case "unnotify":
+		return unnotify
+	case "adopt":
+		return adopt
+	case "auruser":
+		return aurUser
+	case "aurpasscmd":
+		return aurPassCmd
 	}
 }
 
@@ -759,8 +944,29 @@ var hasParam = []parser.Enum{
 	answerEdit,         // answer ''
 	answerUpgrade,      // answer <Repo|^Repo|None|...>
 	completionInterval, // int (days)
+	watchInterval,      // int (minutes)
+	watchCmd,           // command
+	outOfDateDays,      // int (days)
+	aurCacheTTL,        // int (minutes)
+	aurBackend,         // <rpc|dump>
+	searchRank,         // <relevance|votes|blocks>
+	outputFormat,       // <json|tsv|template>
+	diffComments,       // int
+	aurUser,            // name
+	aurPassCmd,         // command
+	scanFail,           // <low|medium|high|never>
+	pgpKeyDir,          // dir
+	reviewLedger,       // file
+	reviewer,           // name
+	signKey,            // key
+	localRepo,          // db
+	cleanKeep,          // int
+	cleanMaxSize,       // size
+	cleanSourceDays,    // int (days)
 	sortBy,             // <votes|popularity|id|baseid|name|base|submitted|modified>
 	searchBy,           // <name|name-desc|maintainer|depends|checkdepends|makedepends|optdepends>
+	holdUntil,          // date
+	reason,             // text
 
 	ask,
 }
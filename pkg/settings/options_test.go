@@ -142,6 +142,132 @@ func Test_parseCommandLine(t *testing.T) {
 			Mode:                ModeRepo,
 			Pacman:              &PacmanConf{Targets: &[]string{"racket", "ide"}, ModeConf: &SConf{Search: true}},
 		},
+	}, 16: {
+		args: "-S --downgrade some-pkg",
+		want: &YayConfig{
+			MainOperation: 'S',
+			Downgrade:     true,
+			Targets:       []string{"some-pkg"},
+			Pacman: &PacmanConf{
+				ModeConf: &SConf{},
+				Targets:  &[]string{"some-pkg"},
+			},
+		},
+	}, 17: {
+		args: "--downgrade -S some-pkg",
+		err:  true,
+	}, 18: {
+		args: "-P --watch --watchinterval 30 --watchcmd notify-send",
+		want: &YayConfig{
+			MainOperation:       'P',
+			ModeConf:            &PConf{Watch: true},
+			PersistentYayConfig: PersistentYayConfig{WatchInterval: 30, WatchCmd: "notify-send"},
+		},
+	}, 19: {
+		args: "-Y --hold foo bar=1.0-1 --until 2021-05-01 --reason broken",
+		want: &YayConfig{
+			MainOperation: 'Y',
+			ModeConf:      &YConf{Hold: true, HoldUntil: "2021-05-01", Reason: "broken"},
+			Targets:       []string{"foo", "bar=1.0-1"},
+		},
+	}, 20: {
+		args: "-P --foreign-health --outofdatedays 14 --autohold",
+		want: &YayConfig{
+			MainOperation:       'P',
+			ModeConf:            &PConf{ForeignHealth: true},
+			PersistentYayConfig: PersistentYayConfig{OutOfDateDays: 14, AutoHold: true},
+		},
+	}, 21: {
+		args: "-Qu --offline --aurcachettl 60",
+		want: &YayConfig{
+			MainOperation:       'Q',
+			Offline:             true,
+			PersistentYayConfig: PersistentYayConfig{AURCacheTTL: 60},
+			Pacman:              &PacmanConf{ModeConf: &QConf{Upgrades: Once}},
+		},
+	}, 22: {
+		args: "-Si --format json",
+		want: &YayConfig{
+			MainOperation: 'S',
+			Format:        "json",
+			Pacman:        &PacmanConf{ModeConf: &SConf{Info: Once}},
+		},
+	}, 23: {
+		args: "-P --comments --diffcomments 3 yay",
+		want: &YayConfig{
+			MainOperation:       'P',
+			ModeConf:            &PConf{Comments: true},
+			PersistentYayConfig: PersistentYayConfig{DiffComments: 3},
+			Targets:             []string{"yay"},
+		},
+	}, 24: {
+		args: "-Y --flag --reason 2.0 --auruser jguer --aurpasscmd pass yay",
+		want: &YayConfig{
+			MainOperation:       'Y',
+			ModeConf:            &YConf{Flag: true, Reason: "2.0"},
+			PersistentYayConfig: PersistentYayConfig{AURUser: "jguer", AURPassCmd: "pass"},
+			Targets:             []string{"yay"},
+		},
+	}, 25: {
+		args: "-Syu --pkgbuildscan --scanfail medium --pgpkeydir /etc/keys --trustchanges",
+		want: &YayConfig{
+			MainOperation:       'S',
+			TrustChanges:        true,
+			PersistentYayConfig: PersistentYayConfig{PkgbuildScan: true, ScanFail: "medium", PGPKeyDir: "/etc/keys"},
+			Pacman: &PacmanConf{ModeConf: &SConf{
+				SysUpgrade: Once,
+				Refresh:    Once,
+			}},
+		},
+	}, 26: {
+		args: "-Syu --reviewledger /srv/team/reviews.json --reviewer alice --signkey 0x1234 --localrepo /srv/repo/custom.db.tar.gz",
+		want: &YayConfig{
+			MainOperation: 'S',
+			PersistentYayConfig: PersistentYayConfig{
+				ReviewLedger: "/srv/team/reviews.json",
+				Reviewer:     "alice",
+				SignKey:      "0x1234",
+				LocalRepo:    "/srv/repo/custom.db.tar.gz",
+			},
+			Pacman: &PacmanConf{ModeConf: &SConf{
+				SysUpgrade: Once,
+				Refresh:    Once,
+			}},
+		},
+	}, 27: {
+		args: "-Sc --cleankeep 2 --cleanmaxsize 10G --cleansourcedays 30",
+		want: &YayConfig{
+			MainOperation: 'S',
+			PersistentYayConfig: PersistentYayConfig{
+				CleanKeep:       2,
+				CleanMaxSize:    "10G",
+				CleanSourceDays: 30,
+			},
+			Pacman: &PacmanConf{ModeConf: &SConf{Clean: Once}},
+		},
+	}, 28: {
+		args: "-P --cachestats",
+		want: &YayConfig{
+			MainOperation: 'P',
+			ModeConf:      &PConf{CacheStats: true},
+		},
+	}, 29: {
+		args: "-Yc --keepmakedeps",
+		want: &YayConfig{
+			MainOperation:       'Y',
+			ModeConf:            &YConf{Clean: Once},
+			PersistentYayConfig: PersistentYayConfig{KeepMakeDeps: true},
+		},
+	}, 30: {
+		args: "-Q --downgrade foo",
+		err:  true,
+	}, 31: {
+		args: "-Su --upgradeselector",
+		want: &YayConfig{
+			MainOperation:       'S',
+			PersistentYayConfig: PersistentYayConfig{UpgradeSelector: true},
+			Pacman:              &PacmanConf{ModeConf: &SConf{SysUpgrade: Once}},
+		},
 	}}
 
 	compare := func(t *testing.T, expect *YayConfig, got *YayConfig, targets []string) {
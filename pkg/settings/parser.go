@@ -75,6 +75,7 @@ func ParseCommandLine(args []string) (*YayConfig, error) {
 	yay := &YayConfig{
 		PersistentYayConfig: *conf,
 		CompletionPath:      filepath.Join(getCacheHome(), completionFileName),
+		CacheDir:            getCacheHome(),
 		ConfigPath:          getConfigPath(),
 		Pacman:              new(PacmanConf),
 	}
@@ -545,6 +546,74 @@ func handleConfig(conf *YayConfig, err *error) func(option parser.Enum, value []
 				conf.CompletionInterval = n
 			}
 
+		case watchInterval:
+			n, err := strconv.Atoi(last(value))
+			if err == nil && n >= 0 {
+				conf.WatchInterval = n
+			}
+		case watchCmd:
+			conf.WatchCmd = last(value)
+
+		case pkgbuildScan:
+			conf.PkgbuildScan = true
+		case noPkgbuildScan:
+			conf.PkgbuildScan = false
+		case scanFail:
+			conf.ScanFail = last(value)
+		case pgpKeyDir:
+			conf.PGPKeyDir = last(value)
+		case reviewLedger:
+			conf.ReviewLedger = last(value)
+		case reviewer:
+			conf.Reviewer = last(value)
+		case signKey:
+			conf.SignKey = last(value)
+		case localRepo:
+			conf.LocalRepo = last(value)
+		case cleanKeep:
+			n, err := strconv.Atoi(last(value))
+			if err == nil && n >= 0 {
+				conf.CleanKeep = n
+			}
+		case keepMakeDeps:
+			conf.KeepMakeDeps = true
+		case noKeepMakeDeps:
+			conf.KeepMakeDeps = false
+		case cleanMaxSize:
+			conf.CleanMaxSize = last(value)
+		case cleanSourceDays:
+			n, err := strconv.Atoi(last(value))
+			if err == nil && n >= 0 {
+				conf.CleanSourceDays = n
+			}
+		case autoHold:
+			conf.AutoHold = true
+		case noAutoHold:
+			conf.AutoHold = false
+		case outOfDateDays:
+			n, err := strconv.Atoi(last(value))
+			if err == nil && n >= 0 {
+				conf.OutOfDateDays = n
+			}
+		case aurCacheTTL:
+			n, err := strconv.Atoi(last(value))
+			if err == nil && n >= 0 {
+				conf.AURCacheTTL = n
+			}
+		case aurBackend:
+			conf.AURBackend = last(value)
+		case searchRank:
+			conf.SearchRank = last(value)
+		case aurUser:
+			conf.AURUser = last(value)
+		case aurPassCmd:
+			conf.AURPassCmd = last(value)
+		case diffComments:
+			n, err := strconv.Atoi(last(value))
+			if err == nil && n >= 0 {
+				conf.DiffComments = n
+			}
+
 		case sortBy:
 			conf.SortBy = last(value)
 
@@ -649,6 +718,10 @@ func handleConfig(conf *YayConfig, err *error) func(option parser.Enum, value []
 			conf.UpgradeMenu = true
 		case noUpgradeMenu:
 			conf.UpgradeMenu = false
+		case upgradeSelector:
+			conf.UpgradeSelector = true
+		case noUpgradeSelector:
+			conf.UpgradeSelector = false
 		case cleanMenu:
 			conf.CleanMenu = true
 		case noCleanMenu:
@@ -695,6 +768,14 @@ func handleConfig(conf *YayConfig, err *error) func(option parser.Enum, value []
 			conf.ModeConf.(*PConf).NumberUpgrades = true
 		case fish:
 			conf.ModeConf.(*PConf).Fish = true
+		case watch:
+			conf.ModeConf.(*PConf).Watch = true
+		case foreignHealth:
+			conf.ModeConf.(*PConf).ForeignHealth = true
+		case comments:
+			conf.ModeConf.(*PConf).Comments = true
+		case cacheStats:
+			conf.ModeConf.(*PConf).CacheStats = true
 
 		// -- Yay yay-mode Options --
 
@@ -702,6 +783,28 @@ func handleConfig(conf *YayConfig, err *error) func(option parser.Enum, value []
 			conf.ModeConf.(*YConf).Clean = Trilean(parser.GetCount(value))
 		case genDB:
 			conf.ModeConf.(*YConf).GenDevDB = true
+		case hold:
+			conf.ModeConf.(*YConf).Hold = true
+		case unhold:
+			conf.ModeConf.(*YConf).Unhold = true
+		case holds:
+			conf.ModeConf.(*YConf).Holds = true
+		case holdUntil:
+			conf.ModeConf.(*YConf).HoldUntil = last(value)
+		case reason:
+			conf.ModeConf.(*YConf).Reason = last(value)
+		case vote:
+			conf.ModeConf.(*YConf).Vote = true
+		case unvote:
+			conf.ModeConf.(*YConf).Unvote = true
+		case flag:
+			conf.ModeConf.(*YConf).Flag = true
+		case notify:
+			conf.ModeConf.(*YConf).Notify = true
+		case unnotify:
+			conf.ModeConf.(*YConf).Unnotify = true
+		case adopt:
+			conf.ModeConf.(*YConf).Adopt = true
 
 		// -- Yay GetPkgbuild Options --
 
@@ -711,12 +814,27 @@ func handleConfig(conf *YayConfig, err *error) func(option parser.Enum, value []
 			}
 			conf.ModeConf.(*GConf).Force = true
 
+		// -- Yay Sync Options --
+
+		case downgrade:
+			if _, ok := conf.Pacman.ModeConf.(*SConf); !ok {
+				*err = errors.New("--downgrade is only valid with -S")
+				return false
+			}
+			conf.Downgrade = true
+
 		// -- Other Yay Options --
 
 		case aur:
 			conf.Mode = ModeAUR
 		case repo:
 			conf.Mode = ModeRepo
+		case offline:
+			conf.Offline = true
+		case trustChanges:
+			conf.TrustChanges = true
+		case outputFormat:
+			conf.Format = last(value)
 
 		case save:
 			conf.SaveConfig = true
@@ -0,0 +1,321 @@
+// Package tofu remembers the PGP keys, sources and AUR maintainers a package
+// base was last installed with, trusting them on first use, and reports how
+// later versions differ from them.
+package tofu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/scan"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Skip is the checksum of sources makepkg does not verify.
+const Skip = "SKIP"
+
+// Source is a source of a package base and its checksums, written as
+// <algorithm>:<sum> or SKIP.
+type Source struct {
+	Arch      string   `json:"arch,omitempty"`
+	URL       string   `json:"url"`
+	Checksums []string `json:"checksums"`
+}
+
+// skipped reports whether no checksum of the source is verified.
+func (s *Source) skipped() bool {
+	for _, sum := range s.Checksums {
+		if sum != Skip {
+			return false
+		}
+	}
+	return true
+}
+
+// Record is what a package base was installed with. The AUR maintainer is
+// only known when LastModified is set, the co-maintainers only when
+// CoMaintainers is not nil.
+type Record struct {
+	Version       string   `json:"version"`
+	Keys          []string `json:"keys"`
+	Sources       []Source `json:"sources"`
+	Maintainer    string   `json:"maintainer,omitempty"`
+	LastModified  int64    `json:"lastmodified,omitempty"`
+	CoMaintainers []string `json:"comaintainers"`
+}
+
+// MaintainerChanged reports whether pkg is maintained by someone else than
+// when the record was made.
+func (r Record) MaintainerChanged(pkg *query.Pkg) bool {
+	return r.LastModified != 0 && pkg.LastModified != 0 && r.Maintainer != pkg.Maintainer
+}
+
+// CoMaintainersChanged reports whether co, the current co-maintainers, differ
+// from the recorded ones. Nothing changed if either is unknown.
+func (r Record) CoMaintainersChanged(co []string, known bool) bool {
+	if r.CoMaintainers == nil || !known {
+		return false
+	}
+	return !stringset.Equal(stringset.Make(r.CoMaintainers...), stringset.Make(co...))
+}
+
+// FromSrcinfo builds the record of a .SRCINFO.
+func FromSrcinfo(srcinfo *gosrc.Srcinfo) Record {
+	r := Record{
+		Version: srcinfo.Version(),
+		Keys:    make([]string, 0, len(srcinfo.ValidPGPKeys)),
+		Sources: make([]Source, 0, len(srcinfo.Source)),
+	}
+
+	for _, key := range srcinfo.ValidPGPKeys {
+		r.Keys = append(r.Keys, strings.ToUpper(key))
+	}
+	sort.Strings(r.Keys)
+
+	sums := []struct {
+		name   string
+		values []gosrc.ArchString
+	}{
+		{"md5", srcinfo.MD5Sums},
+		{"sha1", srcinfo.SHA1Sums},
+		{"sha224", srcinfo.SHA224Sums},
+		{"sha256", srcinfo.SHA256Sums},
+		{"sha384", srcinfo.SHA384Sums},
+		{"sha512", srcinfo.SHA512Sums},
+		{"b2", srcinfo.B2Sums},
+	}
+
+	// checksums belong to the source at the same index of the same arch
+	index := make(map[string]int)
+	for _, source := range srcinfo.Source {
+		s := Source{Arch: source.Arch, URL: source.Value, Checksums: make([]string, 0, 1)}
+		i := index[source.Arch]
+		index[source.Arch]++
+
+		for _, sum := range sums {
+			n := 0
+			for _, v := range sum.values {
+				if v.Arch != source.Arch {
+					continue
+				}
+				if n == i {
+					if v.Value == Skip {
+						s.Checksums = append(s.Checksums, Skip)
+					} else {
+						s.Checksums = append(s.Checksums, sum.name+":"+v.Value)
+					}
+					break
+				}
+				n++
+			}
+		}
+
+		r.Sources = append(r.Sources, s)
+	}
+
+	return r
+}
+
+// ChangeKind tells what changed between two records.
+type ChangeKind int
+
+const (
+	NewKey ChangeKind = iota
+	RemovedKey
+	NewDomain
+	NewSkip
+	ChangedChecksum
+)
+
+// Change is a difference between the record a package base was installed
+// with and the one about to be built.
+type Change struct {
+	Kind ChangeKind
+	// Subject is the key, domain or source the change is about.
+	Subject string
+}
+
+// Serious reports whether the change needs to be confirmed.
+func (c Change) Serious() bool {
+	return c.Kind != RemovedKey
+}
+
+// Message describes the change.
+func (c Change) Message() string {
+	switch c.Kind {
+	case NewKey:
+		return text.Tf("new PGP key %s", c.Subject)
+	case RemovedKey:
+		return text.Tf("PGP key %s is no longer used", c.Subject)
+	case NewDomain:
+		return text.Tf("sources are downloaded from the new domain %s", c.Subject)
+	case NewSkip:
+		return text.Tf("checksums of %s are no longer verified", c.Subject)
+	case ChangedChecksum:
+		return text.Tf("checksums of the unchanged source %s changed", c.Subject)
+	}
+	return ""
+}
+
+// sourceName returns the file name makepkg saves a source as.
+func sourceName(url string) string {
+	if i := strings.Index(url, "::"); i != -1 {
+		return url[:i]
+	}
+	if i := strings.IndexAny(url, "#?"); i != -1 {
+		url = url[:i]
+	}
+	return path.Base(strings.TrimSuffix(url, "/"))
+}
+
+func isVCS(url string) bool {
+	if i := strings.Index(url, "::"); i != -1 {
+		url = url[i+2:]
+	}
+	for _, vcs := range []string{"git", "hg", "svn", "bzr", "fossil"} {
+		if strings.HasPrefix(url, vcs+"+") || strings.HasPrefix(url, vcs+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+func sameSums(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare returns the changes from the record old to cur.
+func Compare(old, cur Record) []Change {
+	changes := make([]Change, 0)
+
+	oldKeys := stringset.Make(old.Keys...)
+	curKeys := stringset.Make(cur.Keys...)
+	for _, key := range cur.Keys {
+		if !oldKeys.Get(key) {
+			changes = append(changes, Change{NewKey, key})
+		}
+	}
+	for _, key := range old.Keys {
+		if !curKeys.Get(key) {
+			changes = append(changes, Change{RemovedKey, key})
+		}
+	}
+
+	domains := stringset.Make()
+	byURL := make(map[string]*Source, len(old.Sources))
+	byName := make(map[string]*Source, len(old.Sources))
+	for i := range old.Sources {
+		s := &old.Sources[i]
+		domains.Set(scan.Domain(s.URL))
+		byURL[s.Arch+" "+s.URL] = s
+		byName[s.Arch+" "+sourceName(s.URL)] = s
+	}
+
+	reported := stringset.Make()
+	for i := range cur.Sources {
+		s := &cur.Sources[i]
+
+		if domain := scan.Domain(s.URL); domain != "" && !domains.Get(domain) && !reported.Get(domain) {
+			reported.Set(domain)
+			changes = append(changes, Change{NewDomain, domain})
+		}
+
+		prev, sameURL := byURL[s.Arch+" "+s.URL]
+		if !sameURL {
+			prev = byName[s.Arch+" "+sourceName(s.URL)]
+		}
+
+		switch {
+		case s.skipped() && prev != nil && !prev.skipped():
+			changes = append(changes, Change{NewSkip, s.URL})
+		case s.skipped() && prev == nil && !isVCS(s.URL):
+			changes = append(changes, Change{NewSkip, s.URL})
+		case sameURL && !s.skipped() && !prev.skipped() && !sameSums(s.Checksums, prev.Checksums):
+			changes = append(changes, Change{ChangedChecksum, s.URL})
+		}
+	}
+
+	return changes
+}
+
+// Store is the list of records by package base backed by a json file.
+type Store struct {
+	Records  map[string]Record
+	FilePath string
+}
+
+func NewStore(filePath string) *Store {
+	return &Store{
+		Records:  map[string]Record{},
+		FilePath: filePath,
+	}
+}
+
+// Get returns the record of pkgbase. It is safe to call on a nil Store.
+func (s *Store) Get(pkgbase string) (Record, bool) {
+	if s == nil {
+		return Record{}, false
+	}
+	r, ok := s.Records[pkgbase]
+	return r, ok
+}
+
+// Set adds or replaces the record of pkgbase.
+func (s *Store) Set(pkgbase string, r Record) {
+	s.Records[pkgbase] = r
+}
+
+func (s *Store) Save() error {
+	marshalledinfo, err := json.MarshalIndent(s.Records, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.FilePath), 0o755); err != nil {
+		return err
+	}
+
+	// written to a temporary file first so a crash never loses the records
+	tmp := s.FilePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(marshalledinfo, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.FilePath)
+}
+
+func (s *Store) Load() error {
+	tfile, err := os.Open(s.FilePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf(text.Tf("failed to open trust file '%s': %s", s.FilePath, err))
+	}
+	defer tfile.Close()
+
+	if err = json.NewDecoder(tfile).Decode(&s.Records); err != nil {
+		return fmt.Errorf(text.Tf("failed to read trust file '%s': %s", s.FilePath, err))
+	}
+	// a file containing null leaves no map
+	if s.Records == nil {
+		s.Records = map[string]Record{}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package tofu
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const srcinfo1 = `pkgbase = foo
+	pkgver = 1.0
+	pkgrel = 1
+	arch = x86_64
+	source = foo-1.0.tar.gz::https://example.org/foo-1.0.tar.gz
+	source = foo.patch
+	source = git+https://github.com/foo/bar.git
+	source_x86_64 = https://example.org/blob-x86_64
+	validpgpkeys = abaf11c65a2970b130abe3c479be3e4300411886
+	sha256sums = 1111
+	sha256sums = 2222
+	sha256sums = SKIP
+	sha256sums_x86_64 = 3333
+
+pkgname = foo
+`
+
+const srcinfo2 = `pkgbase = foo
+	pkgver = 1.1
+	pkgrel = 1
+	arch = x86_64
+	source = foo-1.1.tar.gz::https://mirror.example.net/foo-1.1.tar.gz
+	source = foo.patch
+	source = git+https://github.com/foo/bar.git
+	source = https://example.org/extra.tar.gz
+	source_x86_64 = https://example.org/blob-x86_64
+	validpgpkeys = 647F28654894E3BD457199BE38DBBDC86092693E
+	sha256sums = 1111
+	sha256sums = SKIP
+	sha256sums = SKIP
+	sha256sums = SKIP
+	sha256sums_x86_64 = 4444
+
+pkgname = foo
+`
+
+func parse(t *testing.T, s string) *gosrc.Srcinfo {
+	srcinfo, err := gosrc.Parse(s)
+	require.NoError(t, err)
+	return srcinfo
+}
+
+func TestFromSrcinfo(t *testing.T) {
+	r := FromSrcinfo(parse(t, srcinfo1))
+
+	assert.Equal(t, "1.0-1", r.Version)
+	assert.Equal(t, []string{"ABAF11C65A2970B130ABE3C479BE3E4300411886"}, r.Keys)
+	assert.Equal(t, []Source{
+		{URL: "foo-1.0.tar.gz::https://example.org/foo-1.0.tar.gz", Checksums: []string{"sha256:1111"}},
+		{URL: "foo.patch", Checksums: []string{"sha256:2222"}},
+		{URL: "git+https://github.com/foo/bar.git", Checksums: []string{Skip}},
+		{Arch: "x86_64", URL: "https://example.org/blob-x86_64", Checksums: []string{"sha256:3333"}},
+	}, r.Sources)
+}
+
+func TestCompare(t *testing.T) {
+	old := FromSrcinfo(parse(t, srcinfo1))
+	cur := FromSrcinfo(parse(t, srcinfo2))
+
+	assert.Empty(t, Compare(old, old))
+
+	changes := Compare(old, cur)
+	assert.Equal(t, []Change{
+		{NewKey, "647F28654894E3BD457199BE38DBBDC86092693E"},
+		{RemovedKey, "ABAF11C65A2970B130ABE3C479BE3E4300411886"},
+		{NewDomain, "mirror.example.net"},
+		{NewSkip, "foo.patch"},
+		{NewSkip, "https://example.org/extra.tar.gz"},
+		{ChangedChecksum, "https://example.org/blob-x86_64"},
+	}, changes)
+
+	assert.True(t, changes[0].Serious())
+	assert.False(t, changes[1].Serious())
+	assert.Contains(t, changes[2].Message(), "mirror.example.net")
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-tofu")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the config dir may not exist yet
+	path := filepath.Join(dir, "yay", "trust.json")
+
+	s := NewStore(path)
+	s.Set("foo", FromSrcinfo(parse(t, srcinfo1)))
+	require.NoError(t, s.Save())
+
+	l := NewStore(path)
+	require.NoError(t, l.Load())
+	assert.Equal(t, s.Records, l.Records)
+
+	_, ok := l.Get("bar")
+	assert.False(t, ok)
+
+	var none *Store
+	_, ok = none.Get("foo")
+	assert.False(t, ok)
+}
+
+func TestRecord_CoMaintainersChanged(t *testing.T) {
+	r := Record{CoMaintainers: []string{"alice", "bob"}}
+
+	assert.False(t, r.CoMaintainersChanged([]string{"bob", "alice"}, true))
+	assert.True(t, r.CoMaintainersChanged([]string{"alice"}, true))
+	assert.True(t, Record{CoMaintainers: []string{}}.CoMaintainersChanged([]string{"mallory"}, true))
+	// unknown on either side
+	assert.False(t, r.CoMaintainersChanged(nil, false))
+	assert.False(t, Record{}.CoMaintainersChanged([]string{"mallory"}, true))
+}
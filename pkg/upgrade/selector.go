@@ -0,0 +1,204 @@
+package upgrade
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/intrange"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+	"github.com/Jguer/yay/v10/pkg/view"
+)
+
+// selector is the interactive upgrade menu. Every upgrade starts selected,
+// the user narrows the view with filters and toggles entries until the
+// selection is confirmed with an empty line.
+type selector struct {
+	ups      []Upgrade
+	selected []bool
+	visible  func(Upgrade) bool
+	diff     func(Upgrade) error
+	// firstAUR is the index of the first upgrade built from a PKGBUILD,
+	// only those can be diffed.
+	firstAUR int
+}
+
+func newSelector(ups []Upgrade, firstAUR int, diff func(Upgrade) error) *selector {
+	s := &selector{
+		ups:      ups,
+		selected: make([]bool, len(ups)),
+		visible:  func(Upgrade) bool { return true },
+		diff:     diff,
+		firstAUR: firstAUR,
+	}
+	for i := range s.selected {
+		s.selected[i] = true
+	}
+	return s
+}
+
+// number returns the menu number of the upgrade at index i. Like the plain
+// menu the list is numbered from the bottom.
+func (s *selector) number(i int) int {
+	return len(s.ups) - i
+}
+
+func (s *selector) index(n int) (int, bool) {
+	i := len(s.ups) - n
+	return i, i >= 0 && i < len(s.ups)
+}
+
+func (s *selector) print() {
+	longestName, longestVersion := 0, 0
+	for _, up := range s.ups {
+		left, _ := GetVersionDiff(up.LocalVersion, up.RemoteVersion)
+		longestName = intrange.Max(len(StylizedNameWithRepository(up)), longestName)
+		longestVersion = intrange.Max(len(left), longestVersion)
+	}
+
+	namePadding := fmt.Sprintf("%%-%ds  ", longestName)
+	versionPadding := fmt.Sprintf("%%-%ds", longestVersion)
+	numberPadding := fmt.Sprintf("%%%dd ", len(strconv.Itoa(len(s.ups))))
+
+	for i, up := range s.ups {
+		if !s.visible(up) {
+			continue
+		}
+
+		mark := text.Bold(text.Green("[x] "))
+		if !s.selected[i] {
+			mark = "[ ] "
+		}
+
+		left, right := GetVersionDiff(up.LocalVersion, up.RemoteVersion)
+
+		text.Print(text.Magenta(fmt.Sprintf(numberPadding, s.number(i))))
+		text.Print(mark)
+		text.Printf(namePadding, StylizedNameWithRepository(up))
+		text.Printf("%s -> %s\n", fmt.Sprintf(versionPadding, left), right)
+	}
+}
+
+func (s *selector) setVisible(selected bool) {
+	for i, up := range s.ups {
+		if s.visible(up) {
+			s.selected[i] = selected
+		}
+	}
+}
+
+// toggle flips the entries numbered in input. Like in the plain menu ^
+// inverts the selection, ^4 keeps only 4 of the shown entries selected.
+func (s *selector) toggle(input string) {
+	include, exclude, _, _ := view.ParseNumberMenu(input)
+	for i, up := range s.ups {
+		if len(exclude) > 0 {
+			if s.visible(up) {
+				s.selected[i] = exclude.Get(s.number(i))
+			}
+			continue
+		}
+		if include.Get(s.number(i)) {
+			s.selected[i] = !s.selected[i]
+		}
+	}
+}
+
+func (s *selector) showDiff(input string) {
+	n, err := strconv.Atoi(strings.TrimSpace(input))
+	i, ok := s.index(n)
+	if err != nil || !ok {
+		text.Warnln(text.Tf("invalid number: %s", input))
+		return
+	}
+
+	up := s.ups[i]
+	if s.diff == nil || i < s.firstAUR {
+		text.Warnln(text.Tf("%s: no PKGBUILD to diff", text.Cyan(up.Name)))
+		return
+	}
+
+	if err := s.diff(up); err != nil {
+		text.Errorln(err)
+	}
+}
+
+// run reads commands until the selection is confirmed. It reports false if
+// the user aborted.
+func (s *selector) run(reader *bufio.Reader) (bool, error) {
+	for {
+		s.print()
+		text.Infoln(text.T("Toggle packages (eg: 1 2 3, 1-3), ^4: only 4, a: all, n: none, /regex, @repo, d <n>: diff, q: abort"))
+		text.Infoln(text.T("Press enter to upgrade the selected packages"))
+		text.Info()
+
+		buf, overflow, err := reader.ReadLine()
+		if err != nil {
+			return false, err
+		}
+		if overflow {
+			return false, text.ErrT("input too long")
+		}
+
+		input := strings.TrimSpace(string(buf))
+		switch {
+		case input == "":
+			return true, nil
+		case input == "q":
+			return false, nil
+		case input == "a":
+			s.setVisible(true)
+		case input == "n":
+			s.setVisible(false)
+		case input == "/" || input == "@":
+			s.visible = func(Upgrade) bool { return true }
+		case strings.HasPrefix(input, "/"):
+			re, errRe := regexp.Compile(input[1:])
+			if errRe != nil {
+				text.Warnln(text.Tf("invalid regex: %s", errRe))
+				continue
+			}
+			s.visible = func(up Upgrade) bool { return re.MatchString(up.Name) }
+		case strings.HasPrefix(input, "@"):
+			repo := input[1:]
+			s.visible = func(up Upgrade) bool { return up.Repository == repo }
+		case strings.HasPrefix(input, "d "):
+			s.showDiff(input[2:])
+		default:
+			s.toggle(input)
+		}
+		text.Println()
+	}
+}
+
+// selectUpgrades runs the interactive menu over repoUp and aurUp and returns
+// the result in the form of UpgradePkgs.
+func selectUpgrades(repoUp, aurUp []Upgrade, diff func(Upgrade) error) (ignore, aurNames stringset.StringSet, err error) {
+	ignore = stringset.Make()
+	aurNames = stringset.Make()
+
+	allUp := append(append(make([]Upgrade, 0, len(repoUp)+len(aurUp)), repoUp...), aurUp...)
+	s := newSelector(allUp, len(repoUp), diff)
+
+	ok, err := s.run(bufio.NewReader(text.In()))
+	if err != nil {
+		return ignore, aurNames, err
+	}
+	if !ok {
+		return ignore, aurNames, text.ErrT("aborting due to user")
+	}
+
+	for i, up := range allUp {
+		switch {
+		case i < len(repoUp) && !s.selected[i]:
+			ignore.Set(up.Name)
+		case i >= len(repoUp) && s.selected[i]:
+			aurNames.Set(up.Name)
+		}
+	}
+
+	return ignore, aurNames, nil
+}
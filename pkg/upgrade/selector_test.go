@@ -0,0 +1,121 @@
+package upgrade
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+func Test_selectUpgrades(t *testing.T) {
+	repoUp := []Upgrade{
+		{Name: "linux", Repository: "core", LocalVersion: "5.10-1", RemoteVersion: "5.11-1"},
+		{Name: "vim", Repository: "extra", LocalVersion: "8.2-1", RemoteVersion: "8.2-2"},
+	}
+	aurUp := []Upgrade{
+		{Name: "yay", Repository: "aur", LocalVersion: "10.1-1", RemoteVersion: "10.2-1"},
+		{Name: "neovim-git", Repository: "devel", LocalVersion: "r1-1", RemoteVersion: "latest-commit"},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		ignore   []string
+		aurNames []string
+		diffed   []string
+		wantErr  bool
+	}{
+		{
+			name:     "confirm all",
+			input:    "\n",
+			ignore:   []string{},
+			aurNames: []string{"yay", "neovim-git"},
+		},
+		{
+			name:     "toggle",
+			input:    "4 2\n\n",
+			ignore:   []string{"linux"},
+			aurNames: []string{"neovim-git"},
+		},
+		{
+			name:     "keep only",
+			input:    "^1-3\n\n",
+			ignore:   []string{"linux"},
+			aurNames: []string{"yay", "neovim-git"},
+		},
+		{
+			name:     "keep only shown",
+			input:    "@aur\n^1\n\n",
+			ignore:   []string{},
+			aurNames: []string{"neovim-git"},
+		},
+		{
+			name:     "repo filter",
+			input:    "@aur\nn\n@\n\n",
+			ignore:   []string{},
+			aurNames: []string{"neovim-git"},
+		},
+		{
+			name:     "regex filter",
+			input:    "/^(vim|yay)$\nn\n/\na\n/im\nn\n\n",
+			ignore:   []string{"vim"},
+			aurNames: []string{"yay"},
+		},
+		{
+			name:     "diff",
+			input:    "d 1\nd 4\nd 9\n\n",
+			ignore:   []string{},
+			aurNames: []string{"yay", "neovim-git"},
+			diffed:   []string{"neovim-git"},
+		},
+		{
+			name:    "abort",
+			input:   "3\nq\n",
+			wantErr: true,
+		},
+		{
+			name:    "eof",
+			input:   "3\n",
+			wantErr: true,
+		},
+	}
+
+	in := text.In()
+	defer func() { *text.InRef() = in }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*text.InRef() = strings.NewReader(tt.input)
+
+			diffed := []string{}
+			diff := func(up Upgrade) error {
+				diffed = append(diffed, up.Name)
+				return nil
+			}
+
+			var (
+				ignore, aurNames stringset.StringSet
+				err              error
+			)
+			text.CaptureOutput(nil, nil, func() {
+				ignore, aurNames, err = selectUpgrades(repoUp, aurUp, diff)
+			})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.ignore, ignore.ToSlice())
+			assert.ElementsMatch(t, tt.aurNames, aurNames.ToSlice())
+			if tt.diffed != nil {
+				assert.Equal(t, tt.diffed, diffed)
+			}
+		})
+	}
+}
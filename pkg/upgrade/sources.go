@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/hold"
 	"github.com/Jguer/yay/v10/pkg/query"
 	"github.com/Jguer/yay/v10/pkg/text"
 	"github.com/Jguer/yay/v10/pkg/vcs"
@@ -12,7 +13,8 @@ import (
 func UpDevel(
 	remote []db.IPackage,
 	aurdata map[string]*query.Pkg,
-	localCache *vcs.InfoStore) []Upgrade {
+	localCache *vcs.InfoStore,
+	holds *hold.Store) []Upgrade {
 	toUpdate := make([]db.IPackage, 0, len(aurdata))
 	toRemove := make([]string, 0)
 
@@ -51,6 +53,8 @@ func UpDevel(
 	for _, pkg := range toUpdate {
 		if pkg.ShouldIgnore() {
 			printIgnoringPackage(pkg, "latest-commit")
+		} else if h, held := holds.Held(pkg.Name(), "latest-commit"); held {
+			printHeldPackage(pkg.Name(), pkg.Version(), "latest-commit", h)
 		} else {
 			toUpgrade = append(toUpgrade,
 				Upgrade{
@@ -75,9 +79,33 @@ func printIgnoringPackage(pkg db.IPackage, newPkgVersion string) {
 	))
 }
 
+func printHeldPackage(name, oldVersion, newVersion string, h hold.Hold) {
+	left, right := GetVersionDiff(oldVersion, newVersion)
+
+	text.Warnln(text.Tf("%s: holding package upgrade (%s => %s): %s",
+		text.Cyan(name),
+		left, right, h.String(),
+	))
+}
+
+// FilterHeld removes the upgrades prevented by a hold. The names of the held
+// packages are returned so they can be ignored by pacman.
+func FilterHeld(ups []Upgrade, holds *hold.Store) (kept []Upgrade, held []string) {
+	kept = make([]Upgrade, 0, len(ups))
+	for _, up := range ups {
+		if h, ok := holds.Held(up.Name, up.RemoteVersion); ok {
+			printHeldPackage(up.Name, up.LocalVersion, up.RemoteVersion, h)
+			held = append(held, up.Name)
+			continue
+		}
+		kept = append(kept, up)
+	}
+	return kept, held
+}
+
 // UpAUR gathers foreign packages and checks if they have new versions.
 // Output: Upgrade type package list.
-func UpAUR(remote []db.IPackage, aurdata map[string]*query.Pkg, timeUpdate bool) []Upgrade {
+func UpAUR(remote []db.IPackage, aurdata map[string]*query.Pkg, timeUpdate bool, holds *hold.Store) []Upgrade {
 	toUpgrade := make([]Upgrade, 0)
 
 	for _, pkg := range remote {
@@ -90,6 +118,8 @@ func UpAUR(remote []db.IPackage, aurdata map[string]*query.Pkg, timeUpdate bool)
 			(db.VerCmp(pkg.Version(), aurPkg.Version) < 0) {
 			if pkg.ShouldIgnore() {
 				printIgnoringPackage(pkg, aurPkg.Version)
+			} else if h, held := holds.Held(pkg.Name(), aurPkg.Version); held {
+				printHeldPackage(pkg.Name(), pkg.Version(), aurPkg.Version, h)
 			} else {
 				toUpgrade = append(toUpgrade,
 					Upgrade{
@@ -15,6 +15,7 @@ import (
 
 	"github.com/Jguer/yay/v10/pkg/db/mock"
 	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/hold"
 	"github.com/Jguer/yay/v10/pkg/text"
 	"github.com/Jguer/yay/v10/pkg/vcs"
 )
@@ -70,7 +71,7 @@ func Test_upAUR(t *testing.T) {
 
 			buf := &bytes.Buffer{}
 			text.CaptureOutput(buf, nil, func() {
-				got := UpAUR(tt.args.remote, tt.args.aurdata, tt.args.timeUpdate)
+				got := UpAUR(tt.args.remote, tt.args.aurdata, tt.args.timeUpdate, nil)
 				assert.EqualValues(t, tt.want, got)
 			})
 
@@ -79,6 +80,44 @@ func Test_upAUR(t *testing.T) {
 	}
 }
 
+func Test_upAURHeld(t *testing.T) {
+	remote := []alpm.IPackage{
+		&mock.Package{PName: "hello", PVersion: "2.0.0"},
+		&mock.Package{PName: "world", PVersion: "1.0.0"},
+	}
+	aurdata := map[string]*rpc.Pkg{
+		"hello": {Version: "2.1.0", Name: "hello"},
+		"world": {Version: "1.1.0", Name: "world"},
+	}
+
+	holds := hold.NewStore("")
+	holds.Set("hello", hold.Hold{Version: "2.1.0"})
+	holds.Set("world", hold.Hold{Version: "1.0.5"})
+
+	buf := &bytes.Buffer{}
+	text.CaptureOutput(buf, nil, func() {
+		got := UpAUR(remote, aurdata, false, holds)
+		assert.EqualValues(t, []Upgrade{{Name: "world", Repository: "aur", LocalVersion: "1.0.0", RemoteVersion: "1.1.0"}}, got)
+	})
+	assert.Contains(t, buf.String(), "hello")
+}
+
+func TestFilterHeld(t *testing.T) {
+	ups := []Upgrade{
+		{Name: "linux", Repository: "core", LocalVersion: "5.10-1", RemoteVersion: "5.11-1"},
+		{Name: "bash", Repository: "core", LocalVersion: "5.0-1", RemoteVersion: "5.1-1"},
+	}
+
+	holds := hold.NewStore("")
+	holds.Set("linux", hold.Hold{Reason: "nvidia"})
+
+	text.CaptureOutput(nil, nil, func() {
+		kept, held := FilterHeld(ups, holds)
+		assert.Equal(t, []Upgrade{ups[1]}, kept)
+		assert.Equal(t, []string{"linux"}, held)
+	})
+}
+
 type MockRunner struct {
 	Returned []string
 	Index    int
@@ -266,7 +305,7 @@ func Test_upDevel(t *testing.T) {
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
 				tt.args.cached.Runner.(*MockRunner).t = t
-				got := UpDevel(tt.args.remote, tt.args.aurdata, &tt.args.cached)
+				got := UpDevel(tt.args.remote, tt.args.aurdata, &tt.args.cached, nil)
 				assert.ElementsMatch(t, tt.want, got)
 				assert.Equal(t, tt.finalLen, len(tt.args.cached.OriginsByPackage))
 			})
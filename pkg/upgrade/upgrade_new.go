@@ -45,7 +45,8 @@ func isDevelPackage(pkg db.IPackage) bool {
 }
 
 // upgradePkgs handles updating the cache and installing updates.
-func UpgradePkgs(conf *settings.YayConfig, aurUp, repoUp []Upgrade) (ignore, aurNames stringset.StringSet, err error) {
+func UpgradePkgs(conf *settings.YayConfig, aurUp, repoUp []Upgrade,
+	diff func(Upgrade) error) (ignore, aurNames stringset.StringSet, err error) {
 	ignore = stringset.Make()
 	aurNames = stringset.Make()
 
@@ -64,6 +65,11 @@ func UpgradePkgs(conf *settings.YayConfig, aurUp, repoUp []Upgrade) (ignore, aur
 
 	sort.Slice(repoUp, upgradeLess(repoUp))
 	sort.Slice(aurUp, upgradeLess(aurUp))
+
+	if conf.UpgradeSelector && conf.AnswerUpgrade == "" && !conf.Pacman.NoConfirm && text.InIsTerminal() {
+		return selectUpgrades(repoUp, aurUp, diff)
+	}
+
 	allUp := append(repoUp, aurUp...)
 	text.Printf("%s"+text.Bold(" %d ")+"%s\n", text.Bold(text.Cyan("::")), allUpLen, text.Bold(text.T("Packages to upgrade.")))
 	printUpSlice(allUp)
@@ -0,0 +1,42 @@
+package view
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// Page shows content through $PAGER, or less if it is unset, when the output
+// is a terminal. Otherwise content is printed as is.
+func Page(content string) error {
+	_, out, errOut := text.AllPorts()
+
+	f, ok := out.(*os.File)
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+
+	bin, err := exec.LookPath(pager[0])
+	if !ok || !term.IsTerminal(int(f.Fd())) || err != nil {
+		text.Print(content)
+		return nil
+	}
+
+	cmd := exec.Command(bin, pager[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout, cmd.Stderr = out, errOut
+	// like git, quit if one screen is enough and keep the colours
+	if _, set := os.LookupEnv("LESS"); !set {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	if err := cmd.Run(); err != nil {
+		return text.ErrT("pager did not exit successfully")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package yay
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/aurweb"
+	"github.com/Jguer/yay/v10/pkg/multierror"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+const aurPasswordFileName = "aurpassword"
+
+// aurPassword reads the AUR password from the output of --aurpasscmd or
+// from the password file next to the config file, which must only be
+// readable by its owner.
+func aurPassword(rt *Runtime) func() (string, error) {
+	return func() (string, error) {
+		if rt.Config.AURPassCmd != "" {
+			stdout, stderr, err := rt.CmdRunner.Capture(exec.Command("sh", "-c", rt.Config.AURPassCmd), 0)
+			if err != nil {
+				return "", errors.New(text.Tf("aurpasscmd failed: %s", strings.TrimSpace(stderr+" "+err.Error())))
+			}
+			return strings.SplitN(stdout, "\n", 2)[0], nil
+		}
+
+		path := filepath.Join(filepath.Dir(rt.Config.ConfigPath), aurPasswordFileName)
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return "", errors.New(text.Tf("no AUR password: set --aurpasscmd or write it to %s", path))
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode().Perm()&0o077 != 0 {
+			return "", errors.New(text.Tf("%s must only be readable by its owner", path))
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.SplitN(string(data), "\n", 2)[0], nil
+	}
+}
+
+// aurAccountAction votes, flags, adopts or subscribes to the AUR package
+// bases of the targets.
+func aurAccountAction(rt *Runtime, cmdArgs *settings.YConf) error {
+	if len(rt.Config.Targets) == 0 {
+		return text.ErrT("no targets specified")
+	}
+
+	var (
+		act  func(s *aurweb.Session, pkgbase string) error
+		done func(pkgbase string) string
+	)
+	switch {
+	case cmdArgs.Vote:
+		act = (*aurweb.Session).Vote
+		done = func(pkgbase string) string { return text.Tf("Voted for %s", pkgbase) }
+	case cmdArgs.Unvote:
+		act = (*aurweb.Session).Unvote
+		done = func(pkgbase string) string { return text.Tf("Removed the vote for %s", pkgbase) }
+	case cmdArgs.Notify:
+		act = (*aurweb.Session).Notify
+		done = func(pkgbase string) string { return text.Tf("Enabled notifications for %s", pkgbase) }
+	case cmdArgs.Unnotify:
+		act = (*aurweb.Session).Unnotify
+		done = func(pkgbase string) string { return text.Tf("Disabled notifications for %s", pkgbase) }
+	case cmdArgs.Adopt:
+		act = (*aurweb.Session).Adopt
+		done = func(pkgbase string) string { return text.Tf("Adopted %s", pkgbase) }
+	case cmdArgs.Flag:
+		if strings.TrimSpace(cmdArgs.Reason) == "" {
+			return text.ErrT("flagging a package out of date needs a comment, see --reason")
+		}
+		act = func(s *aurweb.Session, pkgbase string) error { return s.Flag(pkgbase, cmdArgs.Reason) }
+		done = func(pkgbase string) string { return text.Tf("Flagged %s out of date", pkgbase) }
+	}
+
+	names := pinTargets(rt.Sources, rt.Config.Targets)
+	warnings := query.NewWarnings()
+	info, err := query.AURInfo(rt.AURInfo, names, warnings, rt.Config.RequestSplitN)
+	if err != nil {
+		return err
+	}
+	warnings.Print()
+
+	var errMulti multierror.MultiError
+	if len(info) != len(names) {
+		errMulti.Add(errMissing)
+	}
+
+	// one login per AUR
+	sessions := make(map[string]*aurweb.Session)
+	seen := stringset.Make()
+	for _, pkg := range info {
+		if seen.Get(pkg.PackageBase) {
+			continue
+		}
+		seen.Set(pkg.PackageBase)
+
+		src := rt.Sources.Origin(pkg.Name)
+		if src.Kind != query.SourceAUR {
+			errMulti.Add(errors.New(text.Tf("%s comes from %s which has no accounts", pkg.Name, src.Name)))
+			continue
+		}
+
+		session, ok := sessions[src.Name]
+		if !ok {
+			cachePath := filepath.Join(rt.Config.CacheDir, "aursession-"+src.Name+".json")
+			session, err = aurweb.NewSession(rt.HttpClient, src.URL, rt.Config.AURUser, aurPassword(rt), cachePath)
+			if err != nil {
+				return err
+			}
+			sessions[src.Name] = session
+		}
+
+		if err := act(session, pkg.PackageBase); err != nil {
+			errMulti.Add(errors.New(text.Tf("%s: %s", pkg.PackageBase, err)))
+			continue
+		}
+		text.Infoln(done(pkg.PackageBase))
+	}
+
+	return errMulti.Return()
+}
@@ -0,0 +1,48 @@
+package yay
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/settings"
+)
+
+func Test_aurPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-aurpassword")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rt := &Runtime{
+		CmdRunner: &exe.OSRunner{},
+		Config:    &settings.YayConfig{ConfigPath: filepath.Join(dir, "config.json")},
+	}
+	password := aurPassword(rt)
+
+	_, err = password()
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, aurPasswordFileName)
+	require.NoError(t, ioutil.WriteFile(path, []byte("secret\n"), 0o644))
+	_, err = password()
+	assert.Error(t, err, "readable by others")
+
+	require.NoError(t, os.Chmod(path, 0o600))
+	got, err := password()
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got)
+
+	rt.Config.AURPassCmd = "printf 'from cmd\nsecond line'"
+	got, err = password()
+	require.NoError(t, err)
+	assert.Equal(t, "from cmd", got)
+
+	rt.Config.AURPassCmd = "exit 1"
+	_, err = password()
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package yay
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// baseUsage is the disk usage of a package base in the build or ABS
+// directory.
+type baseUsage struct {
+	Name     string
+	Git      int64
+	Sources  int64
+	Trees    int64
+	Packages int64
+	// Orphan is set for bases none of whose packages are installed.
+	Orphan bool
+}
+
+func (u *baseUsage) total() int64 {
+	return u.Git + u.Sources + u.Trees + u.Packages
+}
+
+func (u *baseUsage) add(o *baseUsage) {
+	u.Git += o.Git
+	u.Sources += o.Sources
+	u.Trees += o.Trees
+	u.Packages += o.Packages
+}
+
+// measureBase sums the files of the package base in dir. root is the
+// directory makepkg runs in, the base itself or trunk for ABS.
+func measureBase(name, dir, root string) baseUsage {
+	usage := baseUsage{Name: name}
+
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		inRoot, errRoot := filepath.Rel(root, path)
+		top := strings.SplitN(filepath.ToSlash(inRoot), "/", 2)[0]
+
+		switch {
+		case strings.HasPrefix(filepath.ToSlash(rel), ".git/"):
+			usage.Git += info.Size()
+		case errRoot == nil && (top == "src" || top == "pkg") && top != inRoot:
+			usage.Trees += info.Size()
+		case errRoot == nil && top == inRoot && strings.Contains(top, ".pkg.tar"):
+			usage.Packages += info.Size()
+		default:
+			usage.Sources += info.Size()
+		}
+		return nil
+	})
+
+	return usage
+}
+
+// cacheUsage measures the package bases in the build and ABS directories.
+// Bases are marked orphan when no installed package was built from them.
+func cacheUsage(rt *Runtime) []baseUsage {
+	installed := stringset.Make()
+	for _, pkg := range rt.DB.LocalPackages() {
+		if pkg.Base() != "" {
+			installed.Set(pkg.Base())
+		} else {
+			installed.Set(pkg.Name())
+		}
+	}
+
+	usages := make([]baseUsage, 0)
+	for _, cacheDir := range []string{rt.Config.BuildDir, rt.Config.ABSDir} {
+		files, err := ioutil.ReadDir(cacheDir)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			if !file.IsDir() {
+				continue
+			}
+
+			dir := filepath.Join(cacheDir, file.Name())
+			root := dir
+			if _, err := os.Stat(filepath.Join(dir, "trunk")); err == nil && cacheDir == rt.Config.ABSDir {
+				root = filepath.Join(dir, "trunk")
+			}
+
+			usage := measureBase(file.Name(), dir, root)
+			usage.Orphan = file.Name() != sourcesDirName && !installed.Get(file.Name())
+			usages = append(usages, usage)
+		}
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool { return usages[i].total() > usages[j].total() })
+	return usages
+}
+
+// printCacheStats prints the disk usage of every package base in the build
+// and ABS directories, biggest first.
+func printCacheStats(rt *Runtime) error {
+	usages := cacheUsage(rt)
+
+	text.Printf("%-30s %12s %12s %12s %12s %12s\n",
+		text.T("Base"), text.T("Git"), text.T("Sources"), text.T("src/pkg"), text.T("Packages"), text.T("Total"))
+
+	total := baseUsage{}
+	var orphans int64
+	for i := range usages {
+		u := &usages[i]
+		total.add(u)
+
+		name, label := text.Bold(u.Name), u.Name
+		if u.Orphan {
+			orphans += u.total()
+			label += " " + text.T("(not installed)")
+			name = text.Bold(text.Red(u.Name)) + " " + text.T("(not installed)")
+		}
+		name += padding(label)
+
+		text.Printf("%s %12s %12s %12s %12s %12s\n", name,
+			text.Human(u.Git), text.Human(u.Sources), text.Human(u.Trees), text.Human(u.Packages), text.Human(u.total()))
+	}
+
+	text.Println(text.Bold(text.Cyan("===========================================")))
+	text.Printf("%s %12s %12s %12s %12s %12s\n", text.Bold(text.T("Total"))+padding(text.T("Total")),
+		text.Human(total.Git), text.Human(total.Sources), text.Human(total.Trees), text.Human(total.Packages), text.Human(total.total()))
+	text.Infoln(text.Tf("Used by bases no longer installed: %s", text.Cyan(text.Human(orphans))))
+
+	return nil
+}
+
+// padding returns the spaces filling the name column after s.
+func padding(s string) string {
+	if len(s) >= 30 {
+		return ""
+	}
+	return strings.Repeat(" ", 30-len(s))
+}
@@ -0,0 +1,67 @@
+package yay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/db/mock"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+func TestCacheUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-cachestats")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(path string, size int) {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, ioutil.WriteFile(path, make([]byte, size), 0o644))
+	}
+	write("build/foo/.git/objects/pack/x.pack", 1000)
+	write("build/foo/PKGBUILD", 10)
+	write("build/foo/foo-1.0.tar.gz", 300)
+	write("build/foo/src/foo-1.0/main.c", 200)
+	write("build/foo/pkg/foo/usr/bin/foo", 100)
+	write("build/foo/foo-1.0-1-x86_64.pkg.tar.zst", 50)
+	write("build/foo/foo-1.0-1-x86_64.pkg.tar.zst.sig", 5)
+	write("build/old/.git/HEAD", 20)
+	write("abs/bar/.git/HEAD", 30)
+	write("abs/bar/repos/core-x86_64/PKGBUILD", 10)
+	write("abs/bar/trunk/PKGBUILD", 10)
+	write("abs/bar/trunk/src/bar.c", 40)
+	write("abs/bar/trunk/bar-2-1-x86_64.pkg.tar.zst", 70)
+
+	rt := &Runtime{
+		DB: &mock.DBMock{Local: []db.IPackage{
+			&mock.Package{PName: "foo-bin", PBase: "foo"},
+			&mock.Package{PName: "bar"},
+		}},
+		Config: &settings.YayConfig{PersistentYayConfig: settings.PersistentYayConfig{
+			BuildDir: filepath.Join(dir, "build"),
+			ABSDir:   filepath.Join(dir, "abs"),
+		}},
+	}
+
+	assert.Equal(t, []baseUsage{
+		{Name: "foo", Git: 1000, Sources: 310, Trees: 300, Packages: 55},
+		{Name: "bar", Git: 30, Sources: 20, Trees: 40, Packages: 70},
+		{Name: "old", Git: 20, Orphan: true},
+	}, cacheUsage(rt))
+
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, buf, func() {
+		err = printCacheStats(rt)
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "(not installed)")
+	assert.Contains(t, buf.String(), text.Human(1665+160+20))
+}
@@ -1,13 +1,20 @@
 package yay
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
 
 	"github.com/Jguer/yay/v10/pkg/db"
 	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/multierror"
 	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/retention"
 	"github.com/Jguer/yay/v10/pkg/settings"
 	"github.com/Jguer/yay/v10/pkg/stringset"
 	"github.com/Jguer/yay/v10/pkg/text"
@@ -15,12 +22,98 @@ import (
 
 // CleanDependencies removes all dangling dependencies in system
 func cleanDependencies(rt *Runtime, cmdArgs *settings.PacmanConf, removeOptional bool) error {
-	hanging := query.HangingPackages(removeOptional, rt.DB)
-	if len(hanging) != 0 {
-		return cleanRemove(rt, cmdArgs, hanging)
+	var keep []string
+	if rt.Config.KeepMakeDeps {
+		var err error
+		if keep, err = aurBuildDepends(rt); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	unneeded := query.UnneededPackages(removeOptional, keep, rt.DB)
+	if len(unneeded) == 0 {
+		return nil
+	}
+
+	printUnneeded(unneeded)
+
+	hanging := make([]string, 0, len(unneeded))
+	for _, pkg := range unneeded {
+		hanging = append(hanging, pkg.Name)
+	}
+	return cleanRemove(rt, cmdArgs, hanging)
+}
+
+// aurBuildDepends returns the make and check dependencies of the installed
+// AUR packages, read from their .SRCINFO in the build directory or else
+// from the AUR. It fails if the AUR could not be queried for some of them,
+// their make dependencies would be removed otherwise.
+func aurBuildDepends(rt *Runtime) ([]string, error) {
+	remotePackages, _ := query.GetRemotePackages(rt.DB)
+
+	deps := make([]string, 0)
+	bases := stringset.Make()
+	missing := make([]string, 0)
+	for _, pkg := range remotePackages {
+		base := pkg.Base()
+		if base == "" {
+			base = pkg.Name()
+		}
+		if bases.Get(base) {
+			continue
+		}
+		bases.Set(base)
+
+		srcinfo, err := gosrc.ParseFile(filepath.Join(rt.Config.BuildDir, base, ".SRCINFO"))
+		if err != nil {
+			missing = append(missing, pkg.Name())
+			continue
+		}
+		for _, list := range [2][]gosrc.ArchString{srcinfo.MakeDepends, srcinfo.CheckDepends} {
+			for _, dep := range list {
+				deps = append(deps, dep.Value)
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return deps, nil
+	}
+
+	warnings := &query.AURWarnings{}
+	info, err := query.AURInfo(rt.AURInfo, missing, warnings, rt.Config.RequestSplitN)
+	if err != nil {
+		failed := warnings.Failed
+		if len(failed) == 0 {
+			failed = missing
+		}
+		return nil, errors.New(text.Tf("unable to get the make dependencies of %s: %s",
+			strings.Join(failed, ", "), err))
+	}
+	for _, pkg := range info {
+		deps = append(deps, pkg.MakeDepends...)
+		deps = append(deps, pkg.CheckDepends...)
+	}
+
+	return deps, nil
+}
+
+// printUnneeded lists the packages -Yc removes and why they are not needed.
+func printUnneeded(unneeded []query.Unneeded) {
+	text.OperationInfoln(text.T("Packages no longer needed:"))
+
+	for _, pkg := range unneeded {
+		reason := text.T("installed as a dependency, required by nothing")
+		if len(pkg.RequiredBy) > 0 {
+			reason = text.Tf("only required by unneeded packages: %s", strings.Join(pkg.RequiredBy, ", "))
+		}
+		if len(pkg.OptionalFor) > 0 {
+			reason += "; " + text.Tf("optional for: %s", strings.Join(pkg.OptionalFor, ", "))
+		}
+
+		text.Printf("    %s %s\n", text.Bold(pkg.Name), reason)
+	}
+	text.Println()
 }
 
 // CleanRemove sends a full removal command to pacman with the pkgName slice
@@ -70,7 +163,7 @@ func syncClean(rt *Runtime) error {
 	text.Println(text.T("\nBuild directory:"), rt.Config.BuildDir)
 
 	if text.ContinueTask(question, true, rt.Config.Pacman.NoConfirm) {
-		if err := cleanAUR(&rt.Config.PersistentYayConfig, keepInstalled, keepCurrent, removeAll > 0, rt.DB, rt.AUR); err != nil {
+		if err := cleanAUR(&rt.Config.PersistentYayConfig, keepInstalled, keepCurrent, removeAll > 0, rt.DB, rt.AURInfo); err != nil {
 			return err
 		}
 	}
@@ -79,6 +172,14 @@ func syncClean(rt *Runtime) error {
 		return nil
 	}
 
+	policy, err := retentionPolicy(&rt.Config.PersistentYayConfig)
+	if err != nil {
+		return err
+	}
+	if !policy.Empty() {
+		return cleanRetention(rt, policy)
+	}
+
 	if text.ContinueTask(text.T("Do you want to remove ALL untracked AUR files?"), true, rt.Config.Pacman.NoConfirm) {
 		return cleanUntracked(rt)
 	}
@@ -90,7 +191,7 @@ func cleanAUR(
 	conf *settings.PersistentYayConfig,
 	keepInstalled, keepCurrent, removeAll bool,
 	dbExecutor db.Executor,
-	aur *query.AUR) error {
+	aur query.AURInfoProvider) error {
 
 	text.Println(text.T("removing AUR packages from cache..."))
 
@@ -106,7 +207,7 @@ func cleanAUR(
 
 	cachedPackages := make([]string, 0, len(files))
 	for _, file := range files {
-		if !file.IsDir() {
+		if !file.IsDir() || file.Name() == sourcesDirName {
 			continue
 		}
 
@@ -137,7 +238,7 @@ func cleanAUR(
 	}
 
 	for _, file := range files {
-		if !file.IsDir() {
+		if !file.IsDir() || file.Name() == sourcesDirName {
 			continue
 		}
 
@@ -184,6 +285,149 @@ func cleanUntracked(rt *Runtime) error {
 	return nil
 }
 
+// retentionPolicy returns the -Sc retention policy of the config.
+func retentionPolicy(conf *settings.PersistentYayConfig) (retention.Policy, error) {
+	policy := retention.Policy{
+		Versions:   conf.CleanKeep,
+		SourceDays: conf.CleanSourceDays,
+	}
+
+	if conf.CleanMaxSize != "" {
+		size, err := retention.ParseSize(conf.CleanMaxSize)
+		if err != nil {
+			return policy, err
+		}
+		policy.MaxSize = size
+	}
+
+	return policy, nil
+}
+
+func pathSize(path string) (int64, time.Time) {
+	var size int64
+	var modTime time.Time
+	_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		size += info.Size()
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		return nil
+	})
+	return size, modTime
+}
+
+// scanBuildDir lists the built packages and the files git clean would
+// remove of each package base in the build directory.
+func scanBuildDir(rt *Runtime) ([]retention.Base, error) {
+	files, err := ioutil.ReadDir(rt.Config.BuildDir)
+	if err != nil {
+		return nil, err
+	}
+
+	bases := make([]retention.Base, 0, len(files))
+	for _, file := range files {
+		dir := filepath.Join(rt.Config.BuildDir, file.Name())
+		if !file.IsDir() || file.Name() == sourcesDirName || !isGitRepository(dir) {
+			continue
+		}
+
+		stdout, stderr, err := rt.CmdRunner.Capture(rt.GitBuilder.Build(dir, "clean", "-n", "-d", "-x", "-ff"), 0)
+		if err != nil {
+			return nil, errors.New(text.Tf("error listing untracked files of %s: %s", file.Name(), stderr))
+		}
+
+		base := retention.Base{Name: file.Name()}
+		sigs := make(map[string]retention.File)
+		for _, line := range strings.Split(stdout, "\n") {
+			name := strings.TrimSuffix(strings.TrimPrefix(line, "Would remove "), "/")
+			if name == line || name == "" {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			size, modTime := pathSize(path)
+			f := retention.File{Path: path, Size: size, Time: modTime}
+
+			if _, ok := retention.PackageVersion(name); ok && strings.HasSuffix(name, ".sig") {
+				sigs[strings.TrimSuffix(path, ".sig")] = f
+			} else if ok {
+				base.Packages = append(base.Packages, f)
+			} else {
+				base.Sources = append(base.Sources, f)
+			}
+		}
+
+		// signatures go with their package
+		for i := range base.Packages {
+			if sig, ok := sigs[base.Packages[i].Path]; ok {
+				base.Packages[i].Size += sig.Size
+				delete(sigs, base.Packages[i].Path)
+			}
+		}
+		for _, sig := range sigs {
+			base.Sources = append(base.Sources, sig)
+		}
+
+		bases = append(bases, base)
+	}
+
+	return bases, nil
+}
+
+// cleanRetention removes what the retention policy does not keep, after
+// showing the space reclaimed per package base.
+func cleanRetention(rt *Runtime, policy retention.Policy) error {
+	bases, err := scanBuildDir(rt)
+	if err != nil {
+		return err
+	}
+
+	plan := retention.Plan(bases, policy, time.Now())
+	if len(plan) == 0 {
+		text.Println(text.T("nothing to remove from the build directory"))
+		return nil
+	}
+
+	var total int64
+	text.Println()
+	for i := range plan {
+		r := &plan[i]
+		total += r.Size()
+
+		what := make([]string, 0, 2)
+		if len(r.Packages) > 0 {
+			what = append(what, text.Tf("built packages: %d", len(r.Packages)))
+		}
+		if len(r.Sources) > 0 {
+			what = append(what, text.T("sources"))
+		}
+		text.Printf("%-30s %-30s %10s\n", text.Cyan(r.Base), strings.Join(what, ", "), text.Human(r.Size()))
+	}
+	text.Printf("%s %s\n\n", text.Bold(text.T("Total reclaimed:")), text.Human(total))
+
+	if !text.ContinueTask(text.T("Do you want to remove these files?"), true, rt.Config.Pacman.NoConfirm) {
+		return nil
+	}
+
+	var errMulti multierror.MultiError
+	for i := range plan {
+		for _, pkg := range plan[i].Packages {
+			errMulti.Add(os.Remove(pkg.Path))
+			if err := os.Remove(pkg.Path + ".sig"); err != nil && !os.IsNotExist(err) {
+				errMulti.Add(err)
+			}
+		}
+		for _, source := range plan[i].Sources {
+			errMulti.Add(os.RemoveAll(source.Path))
+		}
+	}
+
+	return errMulti.Return()
+}
+
 func isGitRepository(dir string) bool {
 	_, err := os.Stat(filepath.Join(dir, ".git"))
 	return !os.IsNotExist(err)
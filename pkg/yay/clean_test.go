@@ -0,0 +1,133 @@
+package yay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/db/mock"
+	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/retention"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+func TestCleanRetention(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	buildDir, err := ioutil.TempDir("", "yay-clean")
+	require.NoError(t, err)
+	defer os.RemoveAll(buildDir)
+
+	dir := filepath.Join(buildDir, "foo")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0o755))
+	out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(name string, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(name), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	write("PKGBUILD", old)
+	write("foo-1.0-1-any.pkg.tar.zst", old)
+	write("foo-1.0-1-any.pkg.tar.zst.sig", old)
+	write("foo-1.1-1-any.pkg.tar.zst", time.Now())
+	write("foo-1.1.tar.gz", time.Now())
+	write("src/foo.c", time.Now())
+	out, err = exec.Command("git", "-C", dir, "add", "PKGBUILD").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	rt := &Runtime{
+		CmdRunner:  &exe.OSRunner{},
+		GitBuilder: &exe.GitBuilder{GitBin: "git"},
+		Config: &settings.YayConfig{
+			PersistentYayConfig: settings.PersistentYayConfig{BuildDir: buildDir},
+			Pacman:              &settings.PacmanConf{NoConfirm: true},
+		},
+	}
+
+	bases, err := scanBuildDir(rt)
+	require.NoError(t, err)
+	require.Len(t, bases, 1)
+	assert.Len(t, bases[0].Packages, 2)
+	assert.Len(t, bases[0].Sources, 2)
+
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, buf, func() {
+		err = cleanRetention(rt, retention.Policy{Versions: 1})
+	})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "built packages: 1")
+
+	for name, exists := range map[string]bool{
+		"PKGBUILD":                      true,
+		"foo-1.0-1-any.pkg.tar.zst":     false,
+		"foo-1.0-1-any.pkg.tar.zst.sig": false,
+		"foo-1.1-1-any.pkg.tar.zst":     true,
+		"foo-1.1.tar.gz":                true,
+		"src":                           true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.Equal(t, exists, err == nil, name)
+	}
+}
+
+type makeDepsAUR struct{}
+
+func (makeDepsAUR) Info(names []string) ([]query.Pkg, error) {
+	return []query.Pkg{{Name: "bar", PackageBase: "bar", MakeDepends: []string{"go"}, CheckDepends: []string{"bats"}}}, nil
+}
+
+func TestAURBuildDepends(t *testing.T) {
+	buildDir, err := ioutil.TempDir("", "yay-clean")
+	require.NoError(t, err)
+	defer os.RemoveAll(buildDir)
+
+	srcinfo := "pkgbase = foo-git\n\tpkgver = 1\n\tpkgrel = 1\n\tarch = x86_64\n" +
+		"\tmakedepends = cmake\n\tmakedepends_x86_64 = nasm\n\tcheckdepends = gtest>=1.10\n\npkgname = foo-git\n"
+	require.NoError(t, os.MkdirAll(filepath.Join(buildDir, "foo-git"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(buildDir, "foo-git", ".SRCINFO"), []byte(srcinfo), 0o644))
+
+	rt := &Runtime{
+		DB: &mock.DBMock{Local: []db.IPackage{
+			&mock.Package{PName: "foo-git", PBase: "foo-git", PReason: db.PkgReasonExplicit},
+			&mock.Package{PName: "bar", PReason: db.PkgReasonExplicit},
+		}},
+		AURInfo: makeDepsAUR{},
+		Config: &settings.YayConfig{PersistentYayConfig: settings.PersistentYayConfig{
+			BuildDir:      buildDir,
+			RequestSplitN: 150,
+		}},
+	}
+
+	deps, err := aurBuildDepends(rt)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cmake", "nasm", "gtest>=1.10", "go", "bats"}, deps)
+
+	// make dependencies that could not be looked up must not be removed
+	rt.AURInfo = upListAUR{broken: "bar"}
+	_, err = aurBuildDepends(rt)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "unable to get the make dependencies of bar: "), err.Error())
+
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, buf, func() {
+		printUnneeded([]query.Unneeded{{Name: "cmake"}, {Name: "jsoncpp", RequiredBy: []string{"cmake"}}})
+	})
+	assert.Contains(t, buf.String(), "required by nothing")
+	assert.Contains(t, buf.String(), "only required by unneeded packages: cmake")
+}
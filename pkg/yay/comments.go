@@ -0,0 +1,81 @@
+package yay
+
+import (
+	"errors"
+
+	"github.com/Jguer/yay/v10/pkg/comments"
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/multierror"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// printComments prints the pinned and latest comments of AUR packages.
+func printComments(rt *Runtime, targets []string) error {
+	if len(targets) == 0 {
+		return text.ErrT("no targets specified")
+	}
+
+	names := pinTargets(rt.Sources, targets)
+	warnings := query.NewWarnings()
+	info, err := query.AURInfo(rt.AURInfo, names, warnings, rt.Config.RequestSplitN)
+	if err != nil {
+		return err
+	}
+	warnings.Print()
+
+	var errMulti multierror.MultiError
+	seen := stringset.Make()
+	for _, pkg := range info {
+		if seen.Get(pkg.PackageBase) {
+			continue
+		}
+		seen.Set(pkg.PackageBase)
+
+		if src := rt.Sources.Origin(pkg.Name); src.Kind != query.SourceAUR {
+			errMulti.Add(errors.New(text.Tf("%s comes from %s which has no comments", pkg.Name, src.Name)))
+			continue
+		}
+
+		errMulti.Add(printBaseComments(rt, pkg.Name, pkg.PackageBase, -1))
+	}
+
+	if len(info) != len(names) {
+		errMulti.Add(errMissing)
+	}
+
+	return errMulti.Return()
+}
+
+// printBaseComments prints the pinned and up to latest other comments of a
+// package base. name is any package of the base.
+func printBaseComments(rt *Runtime, name, pkgbase string, latest int) error {
+	cs, err := comments.Fetch(rt.HttpClient, rt.Sources.Origin(name).URL, pkgbase)
+	if err != nil {
+		return err
+	}
+
+	text.OperationInfoln(text.Tf("Comments on %s", text.Cyan(pkgbase)))
+	if len(cs) == 0 {
+		text.Println(text.T("No comments"))
+		return nil
+	}
+	comments.Print(cs, latest)
+
+	return nil
+}
+
+// showDiffComments prints the latest comments of the AUR bases in the diff
+// menu. Failing to fetch them does not stop the install.
+func showDiffComments(rt *Runtime, bases []dep.Base) {
+	for _, base := range bases {
+		if rt.Sources.Origin(base[0].Name).Kind != query.SourceAUR {
+			continue
+		}
+
+		if err := printBaseComments(rt, base[0].Name, base.Pkgbase(), rt.Config.DiffComments); err != nil {
+			text.Warnln(text.Tf("could not fetch comments of %s: %s", base.Pkgbase(), err))
+		}
+	}
+}
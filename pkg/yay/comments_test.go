@@ -0,0 +1,55 @@
+package yay
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+const commentsPage = `<div class="comments package-comments">
+	<div class="comments-header"><h3><span class="text">Latest Comments</span></h3></div>
+	<h4 id="comment-1" class="comment-header">
+		<a href="/account/someone">someone</a> commented on <a href="#comment-1" class="date">2021-03-01 08:00 (UTC)</a>
+	</h4>
+	<div id="comment-1-content" class="article-content">
+		<div><p>Builds fine</p></div>
+	</div>
+</div>`
+
+func Test_printComments(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://aur.archlinux.org").Get("/pkgbase/yay").Times(1).Reply(200).BodyString(commentsPage)
+
+	sources := query.NewSources(&query.Source{
+		Name: "aur", Kind: query.SourceAUR, URL: "https://aur.archlinux.org",
+		Backend: &searchBackend{pkgs: []query.Pkg{
+			{Name: "yay", PackageBase: "yay"},
+			{Name: "yay-docs", PackageBase: "yay"},
+		}},
+	})
+	rt := &Runtime{
+		Sources:    sources,
+		AURInfo:    sources,
+		HttpClient: http.DefaultClient,
+		Config:     &settings.YayConfig{PersistentYayConfig: *settings.Defaults()},
+	}
+
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, nil, func() {
+		assert.NoError(t, printComments(rt, []string{"yay", "aur/yay-docs"}))
+	})
+	assert.Contains(t, buf.String(), "Builds fine")
+	assert.True(t, gock.IsDone())
+
+	text.CaptureOutput(nil, nil, func() {
+		assert.Error(t, printComments(rt, []string{"missing"}))
+		assert.Error(t, printComments(rt, nil))
+	})
+}
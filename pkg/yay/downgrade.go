@@ -0,0 +1,336 @@
+package yay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+	"github.com/Jguer/yay/v10/pkg/view"
+)
+
+// aurVersion is a version of a package base as found in the git history of
+// its AUR repository.
+type aurVersion struct {
+	Hash    string
+	Date    int
+	Srcinfo *gosrc.Srcinfo
+}
+
+// aurHistory lists every version of a package base found in the history of
+// its upstream branch, newest first. Commits that did not change the version
+// are folded into the newest commit carrying that version.
+func aurHistory(br buildRun, path, name string) ([]aurVersion, error) {
+	dir := filepath.Join(path, name)
+
+	stdout, stderr, err := br.Run.Capture(
+		br.Build.Build(dir, "log", "--format=%H %ct", "HEAD@{upstream}", "--", ".SRCINFO"), 0)
+	if err != nil {
+		return nil, fmt.Errorf(text.Tf("error reading history of %s: %s", name, stderr))
+	}
+
+	versions := make([]aurVersion, 0)
+	seen := stringset.Make()
+
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		content, _, errShow := br.Run.Capture(
+			br.Build.Build(dir, "show", fields[0]+":.SRCINFO"), 0)
+		if errShow != nil {
+			continue
+		}
+
+		srcinfo, errParse := gosrc.Parse(content)
+		if errParse != nil {
+			text.Warnln(text.Tf("failed to parse %s at %s -- skipping: %s", name, fields[0], errParse))
+			continue
+		}
+
+		if seen.Get(srcinfo.Version()) {
+			continue
+		}
+		seen.Set(srcinfo.Version())
+
+		date, _ := strconv.Atoi(fields[1])
+		versions = append(versions, aurVersion{Hash: fields[0], Date: date, Srcinfo: srcinfo})
+	}
+
+	return versions, nil
+}
+
+// downgradeMenu presents the versions of base and returns the one picked by
+// the user. The newest version is numbered 1 and printed last.
+func downgradeMenu(base dep.Base, versions []aurVersion, installed string, noConfirm bool) (aurVersion, error) {
+	text.Infoln(text.Tf("Versions of %s:", text.Cyan(base.Pkgbase())))
+
+	toPrint := ""
+	for n := len(versions) - 1; n >= 0; n-- {
+		v := versions[n]
+		toPrint += fmt.Sprintf(text.Magenta("%3d")+" %-40s %s", n+1,
+			text.Bold(v.Srcinfo.Version()), text.FormatTime(v.Date))
+
+		if v.Srcinfo.Version() == installed {
+			toPrint += text.Bold(text.Green(text.T(" (Installed)")))
+		}
+
+		toPrint += "\n"
+	}
+	text.Print(toPrint)
+
+	text.Infoln(text.T("Version to install (eg: 2)"))
+	input, err := view.GetInput("", noConfirm)
+	if err != nil {
+		return aurVersion{}, err
+	}
+
+	include, _, _, _ := view.ParseNumberMenu(input)
+	for n := range versions {
+		if include.Get(n + 1) {
+			return versions[n], nil
+		}
+	}
+
+	return aurVersion{}, errors.New(text.Tf("no version of %s selected", base.Pkgbase()))
+}
+
+// baseFromSrcinfo rebuilds a base out of a historical srcinfo so the build
+// pipeline sees the dependencies of that version instead of the current one.
+// Only the split packages present in base are kept, their AUR metadata is
+// taken from base.
+func baseFromSrcinfo(base dep.Base, srcinfo *gosrc.Srcinfo, arch string) (dep.Base, error) {
+	archValues := func(as []gosrc.ArchString) []string {
+		values := make([]string, 0, len(as))
+		for _, a := range as {
+			if a.Arch == "" || a.Arch == arch {
+				values = append(values, a.Value)
+			}
+		}
+		return values
+	}
+
+	old := make(dep.Base, 0, len(base))
+	for _, pkg := range base {
+		split, err := srcinfo.SplitPackage(pkg.Name)
+		if err != nil {
+			return nil, errors.New(text.Tf("%s is not part of %s", pkg.Name, base.Pkgbase()+"-"+srcinfo.Version()))
+		}
+
+		// the AUR metadata such as the maintainer is kept, trust records
+		// depend on it
+		oldPkg := *pkg
+		oldPkg.Name = split.Pkgname
+		oldPkg.PackageBase = srcinfo.Pkgbase
+		oldPkg.Version = srcinfo.Version()
+		oldPkg.Description = split.Pkgdesc
+		oldPkg.URL = split.URL
+		oldPkg.Depends = archValues(split.Depends)
+		oldPkg.MakeDepends = archValues(srcinfo.MakeDepends)
+		oldPkg.CheckDepends = archValues(srcinfo.CheckDepends)
+		oldPkg.OptDepends = archValues(split.OptDepends)
+		oldPkg.Conflicts = archValues(split.Conflicts)
+		oldPkg.Provides = archValues(split.Provides)
+		oldPkg.Replaces = archValues(split.Replaces)
+		oldPkg.Groups = split.Groups
+		oldPkg.License = split.License
+		old = append(old, &oldPkg)
+	}
+
+	return old, nil
+}
+
+// downgradeDeps returns the dependencies of the chosen versions that are
+// not installed and not provided by the downgraded bases themselves.
+func downgradeDeps(rt *Runtime, bases []dep.Base) []string {
+	downgraded := stringset.Make()
+	for _, base := range bases {
+		for _, pkg := range base {
+			downgraded.Set(pkg.Name)
+		}
+	}
+
+	missing := stringset.Make()
+	for _, base := range bases {
+		for _, pkg := range base {
+			for _, deps := range [3][]string{pkg.Depends, pkg.MakeDepends, pkg.CheckDepends} {
+				for _, d := range deps {
+					if !downgraded.Get(dep.ToTarget(d, rt.Sources).Name) && !rt.DB.LocalSatisfierExists(d) {
+						missing.Set(d)
+					}
+				}
+			}
+		}
+	}
+
+	return missing.ToSlice()
+}
+
+// installRepoDeps installs the repository dependencies of the downgraded
+// packages as dependencies.
+func installRepoDeps(rt *Runtime, pacmanConf *settings.PacmanConf, pkgs []db.IPackage) error {
+	if len(pkgs) == 0 {
+		return nil
+	}
+
+	arguments := pacmanConf.DeepCopy()
+	arguments.ModeConf = &settings.SConf{Upgrade: settings.Upgrade{AsDeps: true, Needed: true}}
+	*arguments.Targets = make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		*arguments.Targets = append(*arguments.Targets, pkg.DB().Name()+"/"+pkg.Name())
+	}
+
+	if err := rt.CmdRunner.Show(PassToPacman(rt.Config, arguments)); err != nil {
+		return errors.New(text.T("error installing repo packages"))
+	}
+
+	return nil
+}
+
+// syncDowngrade builds and installs an older version of AUR packages chosen
+// from the git history of their package base. The chosen commits and the
+// dependencies they need go through the same menus and checks as any other
+// install.
+func syncDowngrade(rt *Runtime, pacmanConf *settings.PacmanConf, sconf *settings.SConf) error {
+	if os.Geteuid() == 0 {
+		return text.ErrT("refusing to install AUR packages as root, aborting")
+	}
+
+	names := make([]string, 0, len(rt.Config.Targets))
+	for _, target := range rt.Config.Targets {
+		dbName, name := text.SplitDBFromName(target)
+		if dbName != "" && !rt.Sources.IsSourceName(dbName) {
+			return errors.New(text.Tf("can only downgrade AUR packages: %s", target))
+		}
+		if dbName != "" {
+			rt.Sources.Pin(name, dbName)
+		}
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return text.ErrT("no targets specified")
+	}
+
+	info, err := query.AURInfoPrint(rt.AUR, names, rt.Config.RequestSplitN)
+	if err != nil {
+		return err
+	}
+	if len(info) == 0 {
+		return text.ErrT("no packages match search")
+	}
+
+	arch, err := rt.DB.AlpmArch()
+	if err != nil {
+		return err
+	}
+
+	br := buildRun{rt.GitBuilder, rt.CmdRunner}
+	bases := dep.GetBases(info)
+	for _, base := range bases {
+		// the checkout of a git source is shared by all its packages
+		if src := rt.Sources.Origin(base.Pkgbase()); src.Kind == query.SourceGit {
+			return errors.New(text.Tf("can not downgrade %s from git source %s", base.String(), src.Name))
+		}
+	}
+
+	_, err = downloadPkgbuilds(br, bases, stringset.Make(), rt.Config.BuildDir, rt.Sources)
+	if err != nil {
+		return err
+	}
+
+	oldBases := make([]dep.Base, 0, len(bases))
+	revs := make(map[string]string, len(bases))
+
+	for _, base := range bases {
+		versions, errHistory := aurHistory(br, rt.Config.BuildDir, base.Pkgbase())
+		if errHistory != nil {
+			return errHistory
+		}
+		if len(versions) == 0 {
+			return errors.New(text.Tf("no history found for %s", base.Pkgbase()))
+		}
+
+		installed := ""
+		if pkg := rt.DB.LocalPackage(base[0].Name); pkg != nil {
+			installed = pkg.Version()
+		}
+
+		chosen, errMenu := downgradeMenu(base, versions, installed, pacmanConf.NoConfirm)
+		if errMenu != nil {
+			return errMenu
+		}
+
+		old, errBase := baseFromSrcinfo(base, chosen.Srcinfo, arch)
+		if errBase != nil {
+			return errBase
+		}
+
+		oldBases = append(oldBases, old)
+		revs[base.Pkgbase()] = chosen.Hash
+	}
+
+	warnings := query.NewWarnings()
+	dp, err := dep.GetPool(
+		downgradeDeps(rt, oldBases), warnings, rt.DB, rt.AUR, settings.ModeAny, false,
+		pacmanConf.NoConfirm, rt.Config.Provides, rt.Config.ReBuild,
+		rt.Config.RequestSplitN,
+	)
+	if err != nil {
+		return err
+	}
+	warnings.Print()
+
+	// the dependencies are installed as such, only the targets are explicit
+	dp.Explicit = stringset.Make(names...)
+	do := dep.GetOrder(dp)
+	do.Aur = append(do.Aur, oldBases...)
+
+	do.Print()
+	text.Println()
+
+	_, remoteNames, err := query.GetPackageNamesBySource(rt.DB)
+	if err != nil {
+		return err
+	}
+
+	srcinfos, incompatible, err := reviewPkgbuilds(
+		rt, pacmanConf, do.Aur, stringset.Make(names...), stringset.Make(remoteNames...), revs)
+	if err != nil {
+		return err
+	}
+
+	err = installRepoDeps(rt, pacmanConf, do.Repo)
+	if err != nil {
+		return err
+	}
+
+	err = downloadPkgbuildsSources(rt.CmdRunner, rt.MakepkgBuilder, do.Aur, incompatible, rt.Config.BuildDir)
+	if err != nil {
+		return err
+	}
+
+	err = buildInstallPkgbuilds(rt, pacmanConf, &sconf.Upgrade, dp, do, srcinfos, incompatible, nil)
+	if err != nil {
+		return err
+	}
+
+	if errTrust := recordTrust(rt, do.Aur, srcinfos); errTrust != nil {
+		text.Errorln(errTrust)
+	}
+
+	text.Warnln(text.T("downgraded packages will be upgraded again on the next sysupgrade unless ignored"))
+	return nil
+}
@@ -0,0 +1,88 @@
+package yay
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/db/mock"
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+type historyRunner struct{ files map[string]string }
+
+func (h *historyRunner) Capture(c *exec.Cmd, _ int64) (string, string, error) {
+	args := c.Args[len(c.Args)-1]
+	if c.Args[3] == "log" {
+		return "ccc 300\nbbb 200\naaa 100", "", nil
+	}
+	return h.files[strings.TrimSuffix(args, ":.SRCINFO")], "", nil
+}
+
+func TestAURHistory(t *testing.T) {
+	srcinfo := func(ver string) string {
+		return "pkgbase = foo\n\tpkgver = " + ver + "\n\tpkgrel = 1\n\tarch = any\n\npkgname = foo\n"
+	}
+	run := &historyRunner{files: map[string]string{
+		"ccc": srcinfo("2.0"),
+		"bbb": srcinfo("1.0"),
+		"aaa": srcinfo("1.0"),
+	}}
+
+	var versions []aurVersion
+	var err error
+	text.CaptureOutput(new(bytes.Buffer), nil, func() {
+		versions, err = aurHistory(buildRun{&exe.GitBuilder{GitBin: "git"}, run}, "/tmp", "foo")
+	})
+	require.NoError(t, err)
+
+	require.Len(t, versions, 2)
+	assert.Equal(t, "2.0-1", versions[0].Srcinfo.Version())
+	assert.Equal(t, "ccc", versions[0].Hash)
+	assert.Equal(t, "1.0-1", versions[1].Srcinfo.Version())
+	assert.Equal(t, "bbb", versions[1].Hash)
+	assert.Equal(t, 200, versions[1].Date)
+}
+
+func TestDowngradeDeps(t *testing.T) {
+	rt := &Runtime{DB: &mock.DBMock{}}
+	bases := []dep.Base{{
+		&query.Pkg{Name: "foo", PackageBase: "foo", Depends: []string{"foo-libs=1.0", "bar>=2"}},
+		&query.Pkg{Name: "foo-libs", PackageBase: "foo", MakeDepends: []string{"baz"}},
+	}}
+
+	deps := downgradeDeps(rt, bases)
+	assert.ElementsMatch(t, []string{"bar>=2", "baz"}, deps)
+}
+
+func TestBaseFromSrcinfo(t *testing.T) {
+	srcinfo, err := gosrc.Parse("pkgbase = foo\n\tpkgver = 1.0\n\tpkgrel = 1\n\tarch = any\n" +
+		"\tmakedepends = cmake\n\npkgname = foo\n\tdepends = bar\n")
+	require.NoError(t, err)
+
+	base := dep.Base{&query.Pkg{
+		Name: "foo", PackageBase: "foo", Version: "2.0-1", Depends: []string{"bar", "baz"},
+		Maintainer: "alice", LastModified: 200, FirstSubmitted: 100, NumVotes: 7,
+	}}
+
+	old, err := baseFromSrcinfo(base, srcinfo, "x86_64")
+	require.NoError(t, err)
+	require.Len(t, old, 1)
+	assert.Equal(t, "1.0-1", old[0].Version)
+	assert.Equal(t, []string{"bar"}, old[0].Depends)
+	assert.Equal(t, []string{"cmake"}, old[0].MakeDepends)
+	assert.Equal(t, "alice", old[0].Maintainer)
+	assert.Equal(t, 200, old[0].LastModified)
+	assert.Equal(t, 7, old[0].NumVotes)
+
+	// the base itself is left alone
+	assert.Equal(t, "2.0-1", base[0].Version)
+}
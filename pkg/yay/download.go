@@ -14,45 +14,85 @@ import (
 	"github.com/Jguer/yay/v10/pkg/dep"
 	"github.com/Jguer/yay/v10/pkg/multierror"
 	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/review"
 	"github.com/Jguer/yay/v10/pkg/stringset"
 	"github.com/Jguer/yay/v10/pkg/text"
 )
 
+// gitDiffRefName is the ref reviews were tracked with before each package
+// base got its own. It is only read from clones of a single package base.
 const gitDiffRefName = "AUR_SEEN"
 
+// gitSeenRef returns the ref holding the last reviewed commit of a package
+// base. Package bases of a git source share a repository, so the ref is
+// named after the base.
+func gitSeenRef(name string) string {
+	return "refs/yay/seen/" + name
+}
+
 type buildRun struct {
 	Build CmdBuilder
 	Run   Capturer
 }
 
-// Update the YAY_DIFF_REVIEW ref to HEAD. We use this ref to determine which diff were
-// reviewed by the user
-func gitUpdateSeenRef(br buildRun, path, name string) error {
+// Update the seen ref of a package base to hash. We use this ref to determine
+// which diff were reviewed by the user
+func gitUpdateSeenRef(br buildRun, path, name, hash string) error {
 	_, stderr, err := br.Run.Capture(
 		br.Build.Build(
-			filepath.Join(path, name), "update-ref", gitDiffRefName, "HEAD"), 0)
+			filepath.Join(path, name), "update-ref", gitSeenRef(name), hash), 0)
 	if err != nil {
 		return fmt.Errorf("%s %s", stderr, err)
 	}
 	return nil
 }
 
-// Return wether or not we have reviewed a diff yet. It checks for the existence of
-// YAY_DIFF_REVIEW in the git ref-list
-func gitHasLastSeenRef(br buildRun, path, name string) bool {
-	_, _, err := br.Run.Capture(
-		br.Build.Build(
-			filepath.Join(path, name), "rev-parse", "--quiet", "--verify", gitDiffRefName), 0)
-	return err == nil
+// Returns the ref recording the last review of a package base and wether
+// there is one. Clones of a single package base fall back to AUR_SEEN.
+func gitLastSeenRef(br buildRun, path, name string) (string, bool) {
+	refs := []string{gitSeenRef(name)}
+	if !isSourceLink(path, name) {
+		refs = append(refs, gitDiffRefName)
+	}
+
+	for _, ref := range refs {
+		_, _, err := br.Run.Capture(
+			br.Build.Build(
+				filepath.Join(path, name), "rev-parse", "--quiet", "--verify", ref), 0)
+		if err == nil {
+			return ref, true
+		}
+	}
+	return "", false
+}
+
+// Returns the newest commit approved in the review ledger that exists in the
+// clone. If there is none it will return empty tree.
+func getReviewedHash(br buildRun, ledger *review.Ledger, path, name string) string {
+	approval, ok := ledger.Latest(name, func(commit string) bool {
+		_, _, err := br.Run.Capture(
+			br.Build.Build(
+				filepath.Join(path, name), "cat-file", "-e", commit+"^{commit}"), 0)
+		return err == nil
+	})
+	if !ok {
+		return gitEmptyTree
+	}
+	return approval.Commit
 }
 
-// Returns the last reviewed hash. If YAY_DIFF_REVIEW exists it will return this hash.
-// If it does not it will return empty tree as no diff have been reviewed yet.
-func getLastSeenHash(br buildRun, path, name string) (string, error) {
-	if gitHasLastSeenRef(br, path, name) {
+// Returns the last reviewed hash. Approvals in the review ledger come first,
+// then the seen ref. If neither exists it will return empty tree as no diff
+// have been reviewed yet.
+func getLastSeenHash(br buildRun, ledger *review.Ledger, path, name string) (string, error) {
+	if reviewed := getReviewedHash(br, ledger, path, name); reviewed != gitEmptyTree {
+		return reviewed, nil
+	}
+
+	if ref, ok := gitLastSeenRef(br, path, name); ok {
 		stdout, stderr, err := br.Run.Capture(
 			br.Build.Build(
-				filepath.Join(path, name), "rev-parse", gitDiffRefName), 0)
+				filepath.Join(path, name), "rev-parse", ref), 0)
 		if err != nil {
 			return "", fmt.Errorf("%s %s", stderr, err)
 		}
@@ -63,24 +103,25 @@ func getLastSeenHash(br buildRun, path, name string) (string, error) {
 	return gitEmptyTree, nil
 }
 
-// Check whether or not a diff exists between the last reviewed diff and
-// HEAD@{upstream}
-func gitHasDiff(br buildRun, path, name string) (bool, error) {
-	if gitHasLastSeenRef(br, path, name) {
-		stdout, stderr, err := br.Run.Capture(
-			br.Build.Build(filepath.Join(path, name), "rev-parse", gitDiffRefName, "HEAD@{upstream}"), 0)
-		if err != nil {
-			return false, fmt.Errorf("%s%s", stderr, err)
-		}
+// buildRev returns the commit of a package base that is reviewed and built.
+// It is HEAD@{upstream} unless revs pins the base to an older commit.
+func buildRev(revs map[string]string, name string) string {
+	if rev, ok := revs[name]; ok {
+		return rev
+	}
+	return "HEAD@{upstream}"
+}
 
-		lines := strings.Split(stdout, "\n")
-		lastseen := lines[0]
-		upstream := lines[1]
-		return lastseen != upstream, nil
+// Returns the hash of rev, the commit a review approves.
+func getRevHash(br buildRun, path, name, rev string) (string, error) {
+	stdout, stderr, err := br.Run.Capture(
+		br.Build.Build(filepath.Join(path, name), "rev-parse", rev), 0)
+	if err != nil {
+		return "", fmt.Errorf("%s%s", stderr, err)
 	}
-	// If YAY_DIFF_REVIEW does not exists, we have never reviewed a diff for this package
-	// and should display it.
-	return true, nil
+
+	lines := strings.Split(stdout, "\n")
+	return lines[0], nil
 }
 
 // TODO: yay-next passes args through the header, use that to unify ABS and AUR
@@ -135,6 +176,12 @@ func gitDownload(br buildRun, url, path, name string) (bool, error) {
 }
 
 func gitMerge(br buildRun, path, name string) error {
+	// the checkout of a git source is fast forwarded when it is synced, only
+	// the files of this package base are restored
+	if isSourceLink(path, name) {
+		return gitRestore(br, path, name, "HEAD")
+	}
+
 	_, stderr, err := br.Run.Capture(
 		br.Build.Build(
 			filepath.Join(path, name), "reset", "--hard", "HEAD"), 0)
@@ -152,6 +199,37 @@ func gitMerge(br buildRun, path, name string) error {
 	return nil
 }
 
+// gitReset moves the local branch of a package base to rev, which leaves its
+// upstream untouched so the next regular install fast forwards it again.
+func gitReset(br buildRun, path, name, rev string) error {
+	if isSourceLink(path, name) {
+		return gitRestore(br, path, name, rev)
+	}
+
+	_, stderr, err := br.Run.Capture(
+		br.Build.Build(
+			filepath.Join(path, name), "reset", "--hard", rev), 0)
+	if err != nil {
+		return fmt.Errorf(text.Tf("error resetting %s: %s", name, stderr))
+	}
+
+	return nil
+}
+
+// gitRestore checks out the directory of a package base at rev without
+// moving the branch, other package bases sharing the repository are left
+// untouched.
+func gitRestore(br buildRun, path, name, rev string) error {
+	_, stderr, err := br.Run.Capture(
+		br.Build.Build(
+			filepath.Join(path, name), "restore", "--source="+rev, "--staged", "--worktree", "--", "."), 0)
+	if err != nil {
+		return fmt.Errorf(text.Tf("error resetting %s: %s", name, stderr))
+	}
+
+	return nil
+}
+
 func getPkgbuilds(pkgs []string, rt *Runtime, force bool) error {
 	missing := false
 	wd, err := os.Getwd()
@@ -159,11 +237,14 @@ func getPkgbuilds(pkgs []string, rt *Runtime, force bool) error {
 		return err
 	}
 
-	pkgs = query.RemoveInvalidTargets(pkgs, rt.Config.Mode)
-	aur, repo := packageSlices(pkgs, rt.DB, rt.Config.Mode)
+	pkgs = query.RemoveInvalidTargets(pkgs, rt.Config.Mode, rt.Sources)
+	aur, repo := packageSlices(pkgs, rt.DB, rt.Config.Mode, rt.Sources)
 
 	for n := range aur {
-		_, pkg := text.SplitDBFromName(aur[n])
+		dbName, pkg := text.SplitDBFromName(aur[n])
+		if dbName != "" {
+			rt.Sources.Pin(pkg, dbName)
+		}
 		aur[n] = pkg
 	}
 
@@ -206,7 +287,7 @@ func getPkgbuilds(pkgs []string, rt *Runtime, force bool) error {
 			}
 		}
 
-		if _, err = downloadPkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, bases, stringset.Make(), wd, rt.Config.AURURL); err != nil {
+		if _, err = downloadPkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, bases, stringset.Make(), wd, rt.Sources); err != nil {
 			return err
 		}
 
@@ -0,0 +1,101 @@
+package yay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/review"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+func TestGetLastSeenHash_Ledger(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "yay-review")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	git := func(dir string, args ...string) string {
+		args = append([]string{"-C", dir, "-c", "user.name=yay", "-c", "user.email=yay@example.org"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(pkgbuild string) string {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "aur", "PKGBUILD"), []byte(pkgbuild), 0o644))
+		git(filepath.Join(dir, "aur"), "add", "-A")
+		git(filepath.Join(dir, "aur"), "commit", "-qm", "update")
+		return git(filepath.Join(dir, "aur"), "rev-parse", "HEAD")
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "aur"), 0o755))
+	git(filepath.Join(dir, "aur"), "init", "-q")
+	first := commit("pkgver=1\n")
+	second := commit("pkgver=2\n")
+
+	// a fresh clone, as after cleaning the build dir
+	buildDir := filepath.Join(dir, "build")
+	require.NoError(t, os.MkdirAll(buildDir, 0o755))
+	git(buildDir, "clone", "-q", filepath.Join(dir, "aur"), "foo")
+
+	br := buildRun{&exe.GitBuilder{GitBin: "git"}, &exe.OSRunner{}}
+	ledger := review.NewLedger(filepath.Join(dir, "reviews.json"))
+
+	seen, err := getLastSeenHash(br, ledger, buildDir, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, gitEmptyTree, seen)
+
+	ledger.Approve("foo", first, "alice", time.Unix(100, 0))
+	ledger.Approve("foo", "0123456789abcdef0123456789abcdef01234567", "bob", time.Unix(200, 0))
+
+	// commits missing from the clone are skipped
+	seen, err = getLastSeenHash(br, ledger, buildDir, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, first, seen)
+	assert.Equal(t, first, getReviewedHash(br, ledger, buildDir, "foo"))
+
+	conf := &settings.PersistentYayConfig{BuildDir: buildDir}
+	bases := []dep.Base{{&query.Pkg{Name: "foo", PackageBase: "foo"}}}
+
+	// the diff starts at the approval even though the clone is new
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, buf, func() {
+		err = showPkgbuildDiffs(br.Build, &exe.OSRunner{}, conf, ledger, bases, stringset.Make("foo"), nil)
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), filepath.Join(buildDir, "foo", "PKGBUILD"))
+	assert.Contains(t, buf.String(), "pkgver=2")
+
+	require.NoError(t, updatePkgbuildSeenRef(br, ledger, "carol", bases, buildDir, nil))
+	approval, ok := ledger.Latest("foo", func(string) bool { return true })
+	require.True(t, ok)
+	assert.Equal(t, second, approval.Commit)
+	assert.Equal(t, "carol", approval.Reviewer)
+
+	saved := review.NewLedger(ledger.FilePath)
+	require.NoError(t, saved.Load())
+	assert.Len(t, saved.Approvals["foo"], 3)
+
+	buf.Reset()
+	text.CaptureOutput(buf, buf, func() {
+		err = showPkgbuildDiffs(br.Build, &exe.OSRunner{}, conf, saved, bases, stringset.Make("foo"), nil)
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "No changes -- skipping")
+}
@@ -35,7 +35,15 @@ func HandlePrint(cmdArgs *settings.PConf, yayVersion string, rt *Runtime) (err e
 			rt.DB, rt.HttpClient, rt.Config.AURURL, rt.Config.CompletionPath,
 			rt.Config.CompletionInterval, cmdArgs.Complete > 1)
 	case cmdArgs.LocalStats:
-		err = localStatistics(rt.DB, rt.AUR, yayVersion, rt.Config.RequestSplitN)
+		err = localStatistics(rt.DB, rt.AURInfo, yayVersion, rt.Config.RequestSplitN)
+	case cmdArgs.Watch:
+		err = watchUpgrades(rt)
+	case cmdArgs.ForeignHealth:
+		err = printForeignHealth(rt)
+	case cmdArgs.Comments:
+		err = printComments(rt, rt.Config.Targets)
+	case cmdArgs.CacheStats:
+		err = printCacheStats(rt)
 	}
 	return err
 }
@@ -47,6 +55,19 @@ func HandleYay(cmdArgs *settings.YConf, rt *Runtime) error {
 	if cmdArgs.Clean != 0 {
 		return cleanDependencies(rt, rt.Config.Pacman, cmdArgs.Clean > 1)
 	}
+	if cmdArgs.Holds {
+		printHolds(rt.Holds)
+		return nil
+	}
+	if cmdArgs.Hold {
+		return holdPackages(rt, rt.Config.Targets, cmdArgs.HoldUntil, cmdArgs.Reason)
+	}
+	if cmdArgs.Unhold {
+		return unholdPackages(rt, rt.Config.Targets)
+	}
+	if cmdArgs.Vote || cmdArgs.Unvote || cmdArgs.Flag || cmdArgs.Notify || cmdArgs.Unnotify || cmdArgs.Adopt {
+		return aurAccountAction(rt, cmdArgs)
+	}
 	if len(rt.Config.Targets) > 0 {
 		return handleYogurt(rt.Config.Pacman, rt)
 	}
@@ -74,6 +95,12 @@ func HandleSync(cmdArgs *settings.SConf, rt *Runtime) error {
 		sudoLoopBackground(rt.CmdRunner, rt.Config)
 	}
 
+	if rt.Sources != nil && cmdArgs.Refresh != 0 {
+		if err := updateSources(rt.Sources, cmdArgs.Refresh > 1); err != nil {
+			return err
+		}
+	}
+
 	targets := rt.Config.Targets
 
 	if cmdArgs.Search {
@@ -99,6 +126,9 @@ func HandleSync(cmdArgs *settings.SConf, rt *Runtime) error {
 	if cmdArgs.Info != 0 {
 		return syncInfo(rt.Config.Pacman, targets, rt)
 	}
+	if rt.Config.Downgrade {
+		return syncDowngrade(rt, rt.Config.Pacman, cmdArgs)
+	}
 	if cmdArgs.SysUpgrade != 0 {
 		return install(rt, rt.Config.Pacman, cmdArgs, false)
 	}
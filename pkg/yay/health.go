@@ -0,0 +1,184 @@
+package yay
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/hold"
+	"github.com/Jguer/yay/v10/pkg/intrange"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// lowVotes is the vote count below which an AUR package is considered to
+// have too few users to notice breakage.
+const lowVotes = 10
+
+// Risk weights of the health report. A package removed from the AUR can be
+// re-submitted by anyone, an orphan no longer gets fixes.
+const (
+	riskMissing       = 100
+	riskOrphan        = 50
+	riskOutOfDateLong = 30
+	riskOutOfDate     = 10
+	riskLowVotes      = 10
+)
+
+type healthReport struct {
+	Name     string
+	Risk     int
+	Reasons  []string
+	Provider string
+}
+
+// autoHoldPackages holds orphaned packages and packages removed from the AUR
+// and releases auto holds of packages that have a maintainer again.
+func autoHoldPackages(holds *hold.Store, warnings *query.AURWarnings, aurdata map[string]*query.Pkg) error {
+	changed := false
+
+	set := func(names []string, reason string) {
+		for _, name := range names {
+			if holds.SetAuto(name, reason) {
+				text.Warnln(text.Tf("Holding %s: %s", text.Cyan(name), reason))
+				changed = true
+			}
+		}
+	}
+
+	set(warnings.Missing, text.T("removed from the AUR"))
+	set(warnings.Orphans, text.T("orphaned"))
+
+	for name, pkg := range aurdata {
+		if pkg.Maintainer != "" && holds.ReleaseAuto(name) {
+			text.OperationInfoln(text.Tf("Releasing hold of %s: maintained again", text.Cyan(name)))
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return holds.Save()
+}
+
+// foreignHealth rates the foreign packages names by the risk of keeping them
+// installed. provider returns a repo package providing a name, if any. Only
+// packages with a risk or a provider are reported, riskiest first.
+func foreignHealth(names []string, info []*query.Pkg, now time.Time, outOfDateDays int,
+	provider func(string) string) []healthReport {
+	aurdata := make(map[string]*query.Pkg, len(info))
+	for _, pkg := range info {
+		aurdata[pkg.Name] = pkg
+	}
+
+	reports := make([]healthReport, 0)
+	for _, name := range names {
+		r := healthReport{Name: name, Provider: provider(name)}
+
+		pkg, ok := aurdata[name]
+		if !ok {
+			r.Risk += riskMissing
+			r.Reasons = append(r.Reasons, text.T("not in the AUR"))
+		} else {
+			if pkg.Maintainer == "" {
+				r.Risk += riskOrphan
+				r.Reasons = append(r.Reasons, text.T("orphaned"))
+			}
+
+			if pkg.OutOfDate != 0 {
+				days := int(now.Sub(time.Unix(int64(pkg.OutOfDate), 0)).Hours() / 24)
+				if days >= outOfDateDays {
+					r.Risk += riskOutOfDateLong
+				} else {
+					r.Risk += riskOutOfDate
+				}
+				r.Reasons = append(r.Reasons, text.Tf("out of date for %d days", days))
+			}
+
+			if pkg.NumVotes < lowVotes {
+				r.Risk += riskLowVotes
+				r.Reasons = append(r.Reasons, text.Tf("%d votes", pkg.NumVotes))
+			}
+		}
+
+		if r.Risk > 0 || r.Provider != "" {
+			reports = append(reports, r)
+		}
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		if reports[i].Risk != reports[j].Risk {
+			return reports[i].Risk > reports[j].Risk
+		}
+		return reports[i].Name < reports[j].Name
+	})
+
+	return reports
+}
+
+// printForeignHealth prints the health report of all foreign packages.
+func printForeignHealth(rt *Runtime) error {
+	_, remoteNames := query.GetRemotePackages(rt.DB)
+
+	info, err := query.AURInfo(rt.AURInfo, remoteNames, query.NewWarnings(), rt.Config.RequestSplitN)
+	if err != nil {
+		return err
+	}
+
+	provider := func(name string) string {
+		pkg := rt.DB.SyncSatisfier(name)
+		if pkg == nil {
+			return ""
+		}
+		return pkg.DB().Name() + "/" + pkg.Name()
+	}
+
+	reports := foreignHealth(remoteNames, info, time.Now(), rt.Config.OutOfDateDays, provider)
+
+	// version holds are checked against the version an upgrade would
+	// install, packages gone from the AUR only have the installed one
+	versions := make(map[string]string, len(remoteNames))
+	for _, name := range remoteNames {
+		if pkg := rt.DB.LocalPackage(name); pkg != nil {
+			versions[name] = pkg.Version()
+		}
+	}
+	for _, pkg := range info {
+		versions[pkg.Name] = pkg.Version
+	}
+
+	longestName := 0
+	for _, r := range reports {
+		longestName = intrange.Max(len(r.Name), longestName)
+	}
+	namePadding := fmt.Sprintf("%%-%ds  ", longestName)
+
+	for _, r := range reports {
+		risk := fmt.Sprintf("%4d ", r.Risk)
+		switch {
+		case r.Risk >= riskOrphan:
+			risk = text.Bold(text.Red(risk))
+		case r.Risk > 0:
+			risk = text.Bold(risk)
+		default:
+			risk = text.Bold(text.Green(risk))
+		}
+
+		line := risk + text.Cyan(fmt.Sprintf(namePadding, r.Name)) + strings.Join(r.Reasons, ", ")
+		if _, held := rt.Holds.Held(r.Name, versions[r.Name]); held {
+			line += text.Bold(text.T(" (Held)"))
+		}
+		text.Println(line)
+
+		if r.Provider != "" {
+			text.Println(strings.Repeat(" ", 5+longestName+2) +
+				text.Tf("-> provided by %s", text.Green(r.Provider)))
+		}
+	}
+
+	text.Infoln(text.Tf("%d of %d foreign packages need attention", len(reports), len(remoteNames)))
+
+	return nil
+}
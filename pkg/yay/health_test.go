@@ -0,0 +1,84 @@
+package yay
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/hold"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+func Test_foreignHealth(t *testing.T) {
+	now := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) int { return int(now.AddDate(0, 0, -d).Unix()) }
+
+	info := []*query.Pkg{
+		{Name: "healthy", Maintainer: "bob", NumVotes: 200},
+		{Name: "orphan", NumVotes: 3},
+		{Name: "stale", Maintainer: "bob", NumVotes: 50, OutOfDate: daysAgo(45)},
+		{Name: "fresh-flag", Maintainer: "bob", NumVotes: 50, OutOfDate: daysAgo(2)},
+		{Name: "in-repo", Maintainer: "bob", NumVotes: 50},
+	}
+	names := []string{"healthy", "orphan", "stale", "fresh-flag", "in-repo", "gone"}
+
+	provider := func(name string) string {
+		if name == "in-repo" {
+			return "extra/in-repo"
+		}
+		return ""
+	}
+
+	got := foreignHealth(names, info, now, 30, provider)
+
+	want := []healthReport{
+		{Name: "gone", Risk: riskMissing, Reasons: []string{"not in the AUR"}},
+		{Name: "orphan", Risk: riskOrphan + riskLowVotes, Reasons: []string{"orphaned", "3 votes"}},
+		{Name: "stale", Risk: riskOutOfDateLong, Reasons: []string{"out of date for 45 days"}},
+		{Name: "fresh-flag", Risk: riskOutOfDate, Reasons: []string{"out of date for 2 days"}},
+		{Name: "in-repo", Provider: "extra/in-repo"},
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func Test_autoHoldPackages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-health")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	holds := hold.NewStore(filepath.Join(dir, "holds.json"))
+	holds.Set("manual", hold.Hold{Reason: "broken"})
+	holds.Holds["adopted"] = hold.Hold{Reason: "orphaned", Auto: true}
+
+	warnings := query.NewWarnings()
+	warnings.Missing = []string{"gone"}
+	warnings.Orphans = []string{"orphan", "manual"}
+
+	aurdata := map[string]*query.Pkg{
+		"orphan":  {Name: "orphan"},
+		"manual":  {Name: "manual"},
+		"adopted": {Name: "adopted", Maintainer: "bob"},
+	}
+
+	text.CaptureOutput(nil, nil, func() {
+		err = autoHoldPackages(holds, warnings, aurdata)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]hold.Hold{
+		"manual": {Reason: "broken"},
+		"gone":   {Reason: "removed from the AUR", Auto: true},
+		"orphan": {Reason: "orphaned", Auto: true},
+	}, holds.Holds)
+
+	saved := hold.NewStore(holds.FilePath)
+	require.NoError(t, saved.Load())
+	assert.Equal(t, holds.Holds, saved.Holds)
+}
@@ -0,0 +1,85 @@
+package yay
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/hold"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// holdPackages adds holds for targets. A target of the form pkg=version
+// only skips that version, until holds the packages up to the given date.
+func holdPackages(rt *Runtime, targets []string, until, reason string) error {
+	if len(targets) == 0 {
+		return text.ErrT("no targets specified")
+	}
+
+	var untilTime time.Time
+	if until != "" {
+		var err error
+		untilTime, err = time.ParseInLocation("2006-01-02", until, time.Local)
+		if err != nil {
+			return errors.New(text.Tf("invalid date %q, expected YYYY-MM-DD", until))
+		}
+	}
+
+	for _, target := range targets {
+		name, version := target, ""
+		if split := strings.SplitN(target, "=", 2); len(split) == 2 {
+			name, version = split[0], split[1]
+		}
+
+		if version != "" && until != "" {
+			return errors.New(text.Tf("%s: a version hold can not have a date", name))
+		}
+
+		if rt.DB.LocalPackage(name) == nil {
+			text.Warnln(text.Tf("%s is not installed", text.Cyan(name)))
+		}
+
+		h := hold.Hold{Version: version, Reason: reason}
+		if !untilTime.IsZero() {
+			h.Until = untilTime.Unix()
+		}
+
+		rt.Holds.Set(name, h)
+		text.OperationInfoln(text.Tf("Holding %s: %s", text.Cyan(name), h.String()))
+	}
+
+	return rt.Holds.Save()
+}
+
+func unholdPackages(rt *Runtime, targets []string) error {
+	if len(targets) == 0 {
+		return text.ErrT("no targets specified")
+	}
+
+	for _, target := range targets {
+		if !rt.Holds.Remove(target) {
+			text.Warnln(text.Tf("%s is not held", text.Cyan(target)))
+		}
+	}
+
+	return rt.Holds.Save()
+}
+
+func printHolds(holds *hold.Store) {
+	names := make([]string, 0, len(holds.Holds))
+	for name := range holds.Holds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	for _, name := range names {
+		h := holds.Holds[name]
+		line := text.Bold(name) + " " + h.String()
+		if h.Expired(now) {
+			line += text.Bold(text.Red(text.T(" (Expired)")))
+		}
+		text.Println(line)
+	}
+}
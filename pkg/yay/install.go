@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	alpm "github.com/Jguer/go-alpm/v2"
 	gosrc "github.com/Morganamilo/go-srcinfo"
@@ -15,9 +16,12 @@ import (
 	"github.com/Jguer/yay/v10/pkg/completion"
 	"github.com/Jguer/yay/v10/pkg/db"
 	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/diff"
 	"github.com/Jguer/yay/v10/pkg/multierror"
 	"github.com/Jguer/yay/v10/pkg/pgp"
 	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/review"
+	"github.com/Jguer/yay/v10/pkg/scan"
 	"github.com/Jguer/yay/v10/pkg/settings"
 	"github.com/Jguer/yay/v10/pkg/stringset"
 	"github.com/Jguer/yay/v10/pkg/text"
@@ -126,18 +130,24 @@ func install(rt *Runtime, pacmanConf *settings.PacmanConf, sconf *settings.SConf
 
 	// if we are doing -u also request all packages needing update
 	if sconf.SysUpgrade != 0 {
-		aurUp, repoUp, err = upList(warnings, rt, sconf.SysUpgrade > 1)
+		var changes []maintainerChange
+		aurUp, repoUp, changes, err = upList(warnings, rt, sconf.SysUpgrade > 1, true)
 		if err != nil {
 			return err
 		}
 
+		printMaintainerChanges(changes, rt.Config.TrustChanges)
+
 		warnings.Print()
 
-		ignore, aurUp, errUp := upgrade.UpgradePkgs(rt.Config, aurUp, repoUp)
+		ignore, aurUp, errUp := upgrade.UpgradePkgs(rt.Config, aurUp, repoUp, upgradeDiff(rt))
 		if errUp != nil {
 			return errUp
 		}
 
+		// keeps held repo packages out of pacman's sysupgrade
+		ignore.Extend(warnings.Ignore.ToSlice()...)
+
 		for _, up := range repoUp {
 			if !ignore.Get(up.Name) {
 				*requestTargets = append(*requestTargets, up.Name)
@@ -244,38 +254,121 @@ func install(rt *Runtime, pacmanConf *settings.PacmanConf, sconf *settings.SConf
 		}
 	}
 
+	srcinfos, incompatible, err = reviewPkgbuilds(rt, pacmanConf, do.Aur, targets, remoteNamesCache, nil)
+	if err != nil {
+		return err
+	}
+
+	if !rt.Config.CombinedUpgrade {
+		argumentsSConf.SysUpgrade = 0
+	}
+
+	if len(*arguments.Targets) > 0 || argumentsSConf.SysUpgrade != 0 {
+		if errShow := rt.CmdRunner.Show(PassToPacman(rt.Config, arguments)); errShow != nil {
+			return errors.New(text.T("error installing repo packages"))
+		}
+
+		deps := make([]string, 0)
+		exp := make([]string, 0)
+
+		for _, pkg := range do.Repo {
+			if !dp.Explicit.Get(pkg.Name()) && !localNamesCache.Get(pkg.Name()) && !remoteNamesCache.Get(pkg.Name()) {
+				deps = append(deps, pkg.Name())
+				continue
+			}
+
+			if sconf.AsDeps && dp.Explicit.Get(pkg.Name()) {
+				deps = append(deps, pkg.Name())
+			} else if sconf.AsExplicit && dp.Explicit.Get(pkg.Name()) {
+				exp = append(exp, pkg.Name())
+			}
+		}
+
+		if errDeps := asdeps(pacmanConf, rt, deps); errDeps != nil {
+			return errDeps
+		}
+		if errExp := asexp(pacmanConf, rt, exp); errExp != nil {
+			return errExp
+		}
+	}
+
+	go func() {
+		_ = completion.Update(
+			rt.DB, rt.HttpClient, rt.Config.AURURL, rt.Config.CompletionPath,
+			rt.Config.CompletionInterval, false)
+	}()
+
+	err = downloadPkgbuildsSources(rt.CmdRunner, rt.MakepkgBuilder, do.Aur, incompatible, rt.Config.BuildDir)
+	if err != nil {
+		return err
+	}
+
+	var upgr *settings.Upgrade
+	switch t := pacmanConf.ModeConf.(type) {
+	case *settings.UConf:
+		upgr = &t.Upgrade
+	case *settings.SConf:
+		upgr = &t.Upgrade
+	}
+	err = buildInstallPkgbuilds(rt, pacmanConf, upgr, dp, do, srcinfos, incompatible, conflicts)
+	if err != nil {
+		return err
+	}
+
+	if errTrust := recordTrust(rt, do.Aur, srcinfos); errTrust != nil {
+		text.Errorln(errTrust)
+	}
+
+	return nil
+}
+
+// reviewPkgbuilds downloads the PKGBUILDs of bases and takes them through the
+// clean, diff and edit menus, the PKGBUILD scan and the trust checks. Bases
+// in revs are checked out at that commit instead of their upstream head.
+func reviewPkgbuilds(rt *Runtime, pacmanConf *settings.PacmanConf, bases []dep.Base,
+	targets, installed stringset.StringSet, revs map[string]string,
+) (srcinfos map[string]*gosrc.Srcinfo, incompatible stringset.StringSet, err error) {
 	if rt.Config.CleanMenu {
-		if anyExistInCache(do.Aur, rt.Config.BuildDir) {
-			askClean := pkgbuildNumberMenu(do.Aur, remoteNamesCache, rt.Config.BuildDir)
-			toClean, errClean := cleanNumberMenu(do.Aur, remoteNamesCache, askClean, rt.Config.AnswerClean, rt.Config.BuildDir, pacmanConf.NoConfirm)
+		if anyExistInCache(bases, rt.Config.BuildDir) {
+			askClean := pkgbuildNumberMenu(bases, installed, rt.Config.BuildDir)
+			toClean, errClean := cleanNumberMenu(bases, installed, askClean, rt.Config.AnswerClean, rt.Config.BuildDir, pacmanConf.NoConfirm)
 			if errClean != nil {
-				return errClean
+				return nil, incompatible, errClean
 			}
 
 			cleanBuilds(rt.Config.BuildDir, toClean)
 		}
 	}
 
-	toSkip := pkgbuildsToSkip(do.Aur, targets, rt.Config.ReDownload, rt.Config.BuildDir)
-	cloned, err := downloadPkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, do.Aur, toSkip, rt.Config.BuildDir, rt.Config.AURURL)
+	toSkip := pkgbuildsToSkip(bases, targets, rt.Config.ReDownload, rt.Config.BuildDir)
+	cloned, err := downloadPkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, bases, toSkip, rt.Config.BuildDir, rt.Sources)
 	if err != nil {
-		return err
+		return nil, incompatible, err
 	}
 
 	var toDiff []dep.Base
 	var toEdit []dep.Base
+	var scanned map[string][]scan.Finding
 
 	if rt.Config.DiffMenu {
-		pkgbuildNumberMenu(do.Aur, remoteNamesCache, rt.Config.BuildDir)
-		toDiff, err = diffNumberMenu(do.Aur, remoteNamesCache, rt.Config.AnswerDiff, rt.Config.AnswerEdit, pacmanConf.NoConfirm)
+		pkgbuildNumberMenu(bases, installed, rt.Config.BuildDir)
+		toDiff, err = diffNumberMenu(bases, installed, rt.Config.AnswerDiff, rt.Config.AnswerEdit, pacmanConf.NoConfirm)
 		if err != nil {
-			return err
+			return nil, incompatible, err
 		}
 
 		if len(toDiff) > 0 {
-			err = showPkgbuildDiffs(rt.GitBuilder, rt.CmdRunner, &rt.Config.PersistentYayConfig, toDiff, cloned)
+			err = showPkgbuildDiffs(rt.GitBuilder, rt.CmdRunner, &rt.Config.PersistentYayConfig, rt.Reviews, toDiff, cloned, revs)
 			if err != nil {
-				return err
+				return nil, incompatible, err
+			}
+
+			if rt.Config.PkgbuildScan {
+				scanned = showDiffScan(rt, toDiff, revs)
+			}
+
+			if rt.Config.DiffComments > 0 {
+				showDiffComments(rt, toDiff)
 			}
 		}
 	}
@@ -285,9 +378,9 @@ func install(rt *Runtime, pacmanConf *settings.PacmanConf, sconf *settings.SConf
 		rt.DB.SetNoConfirm(false)
 		text.Println()
 		if !text.ContinueTask(text.T("Proceed with install?"), true, false) {
-			return text.ErrT("aborting due to user")
+			return nil, incompatible, text.ErrT("aborting due to user")
 		}
-		err = updatePkgbuildSeenRef(buildRun{rt.GitBuilder, rt.CmdRunner}, toDiff, rt.Config.BuildDir)
+		err = updatePkgbuildSeenRef(buildRun{rt.GitBuilder, rt.CmdRunner}, rt.Reviews, reviewer(rt), toDiff, rt.Config.BuildDir, revs)
 		if err != nil {
 			text.Errorln(err.Error())
 		}
@@ -295,27 +388,34 @@ func install(rt *Runtime, pacmanConf *settings.PacmanConf, sconf *settings.SConf
 		rt.DB.SetNoConfirm(oldValue)
 	}
 
-	err = mergePkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, do.Aur, rt.Config.BuildDir)
+	err = mergePkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, bases, rt.Config.BuildDir, revs)
 	if err != nil {
-		return err
+		return nil, incompatible, err
 	}
 
-	srcinfos, err = parseSrcinfoFiles(do.Aur, true, rt.Config.BuildDir)
+	if rt.Config.PkgbuildScan {
+		err = checkPkgbuildScan(rt, bases, scanned, pacmanConf.NoConfirm)
+		if err != nil {
+			return nil, incompatible, err
+		}
+	}
+
+	srcinfos, err = parseSrcinfoFiles(bases, true, rt.Config.BuildDir)
 	if err != nil {
-		return err
+		return nil, incompatible, err
 	}
 
 	if rt.Config.EditMenu {
-		pkgbuildNumberMenu(do.Aur, remoteNamesCache, rt.Config.BuildDir)
-		toEdit, err = editNumberMenu(do.Aur, remoteNamesCache, rt.Config.AnswerDiff, rt.Config.AnswerEdit, pacmanConf.NoConfirm)
+		pkgbuildNumberMenu(bases, installed, rt.Config.BuildDir)
+		toEdit, err = editNumberMenu(bases, installed, rt.Config.AnswerDiff, rt.Config.AnswerEdit, pacmanConf.NoConfirm)
 		if err != nil {
-			return err
+			return nil, incompatible, err
 		}
 
 		if len(toEdit) > 0 {
 			err = editPkgbuilds(toEdit, srcinfos, rt.Config)
 			if err != nil {
-				return err
+				return nil, incompatible, err
 			}
 		}
 	}
@@ -323,79 +423,29 @@ func install(rt *Runtime, pacmanConf *settings.PacmanConf, sconf *settings.SConf
 	if len(toEdit) > 0 {
 		text.Println()
 		if !text.ContinueTask(text.T("Proceed with install?"), true, false) {
-			return errors.New(text.T("aborting due to user"))
+			return nil, incompatible, errors.New(text.T("aborting due to user"))
 		}
 	}
 
-	incompatible, err = getIncompatible(do.Aur, srcinfos, rt.DB, pacmanConf.NoConfirm)
+	err = checkTrust(rt, bases, srcinfos)
 	if err != nil {
-		return err
+		return nil, incompatible, err
 	}
 
-	if rt.Config.PGPFetch {
-		err = pgp.CheckPgpKeys(do.Aur, srcinfos, rt.Config.GpgBin, rt.Config.GpgFlags, pacmanConf.NoConfirm)
-		if err != nil {
-			return err
-		}
-	}
-
-	if !rt.Config.CombinedUpgrade {
-		argumentsSConf.SysUpgrade = 0
-	}
-
-	if len(*arguments.Targets) > 0 || argumentsSConf.SysUpgrade != 0 {
-		if errShow := rt.CmdRunner.Show(PassToPacman(rt.Config, arguments)); errShow != nil {
-			return errors.New(text.T("error installing repo packages"))
-		}
-
-		deps := make([]string, 0)
-		exp := make([]string, 0)
-
-		for _, pkg := range do.Repo {
-			if !dp.Explicit.Get(pkg.Name()) && !localNamesCache.Get(pkg.Name()) && !remoteNamesCache.Get(pkg.Name()) {
-				deps = append(deps, pkg.Name())
-				continue
-			}
-
-			if sconf.AsDeps && dp.Explicit.Get(pkg.Name()) {
-				deps = append(deps, pkg.Name())
-			} else if sconf.AsExplicit && dp.Explicit.Get(pkg.Name()) {
-				exp = append(exp, pkg.Name())
-			}
-		}
-
-		if errDeps := asdeps(pacmanConf, rt, deps); errDeps != nil {
-			return errDeps
-		}
-		if errExp := asexp(pacmanConf, rt, exp); errExp != nil {
-			return errExp
-		}
-	}
-
-	go func() {
-		_ = completion.Update(
-			rt.DB, rt.HttpClient, rt.Config.AURURL, rt.Config.CompletionPath,
-			rt.Config.CompletionInterval, false)
-	}()
-
-	err = downloadPkgbuildsSources(rt.CmdRunner, rt.MakepkgBuilder, do.Aur, incompatible, rt.Config.BuildDir)
+	incompatible, err = getIncompatible(bases, srcinfos, rt.DB, pacmanConf.NoConfirm)
 	if err != nil {
-		return err
+		return nil, incompatible, err
 	}
 
-	var upgr *settings.Upgrade
-	switch t := pacmanConf.ModeConf.(type) {
-	case *settings.UConf:
-		upgr = &t.Upgrade
-	case *settings.SConf:
-		upgr = &t.Upgrade
-	}
-	err = buildInstallPkgbuilds(rt, pacmanConf, upgr, dp, do, srcinfos, incompatible, conflicts)
-	if err != nil {
-		return err
+	if rt.Config.PGPFetch {
+		err = pgp.CheckPgpKeys(bases, srcinfos, rt.Config.GpgBin, rt.Config.GpgFlags,
+			rt.Config.PGPKeyDir, rt.Config.BuildDir, pacmanConf.NoConfirm)
+		if err != nil {
+			return nil, incompatible, err
+		}
 	}
 
-	return nil
+	return srcinfos, incompatible, nil
 }
 
 func removeMake(do *dep.Order, rt *Runtime) error {
@@ -416,10 +466,10 @@ func removeMake(do *dep.Order, rt *Runtime) error {
 	return err
 }
 
-func inRepos(dbExecutor db.Executor, pkg string) bool {
-	target := dep.ToTarget(pkg)
+func inRepos(dbExecutor db.Executor, sources *query.Sources, pkg string) bool {
+	target := dep.ToTarget(pkg, sources)
 
-	if target.DB == "aur" {
+	if target.Source != "" {
 		return false
 	} else if target.DB != "" {
 		return true
@@ -447,7 +497,7 @@ func earlyPacmanCall(rt *Runtime, pacmanConf *settings.PacmanConf, sconf *settin
 	} else {
 		// separate aur and repo targets
 		for _, target := range *targets {
-			if inRepos(rt.DB, target) {
+			if inRepos(rt.DB, rt.Sources, target) {
 				*arguments.Targets = append(*arguments.Targets, target)
 			} else {
 				*pacmanConf.Targets = append(*pacmanConf.Targets, target)
@@ -735,55 +785,99 @@ func editDiffNumberMenu(bases []dep.Base, installed stringset.StringSet, diff bo
 	return toEdit, nil
 }
 
-func updatePkgbuildSeenRef(br buildRun, bases []dep.Base, buildDir string) error {
+// updatePkgbuildSeenRef marks the diffs of bases as reviewed, both in the
+// clones and in the review ledger.
+func updatePkgbuildSeenRef(br buildRun, ledger *review.Ledger, reviewer string, bases []dep.Base, buildDir string, revs map[string]string) error {
 	var errMulti multierror.MultiError
+	now := time.Now()
 	for _, base := range bases {
 		pkg := base.Pkgbase()
-		err := gitUpdateSeenRef(br, buildDir, pkg)
+		reviewed, err := getRevHash(br, buildDir, pkg, buildRev(revs, pkg))
 		if err != nil {
 			errMulti.Add(err)
+			continue
+		}
+
+		if err := gitUpdateSeenRef(br, buildDir, pkg, reviewed); err != nil {
+			errMulti.Add(err)
 		}
+		if ledger != nil {
+			ledger.Approve(pkg, reviewed, reviewer, now)
+		}
+	}
+
+	if ledger != nil {
+		errMulti.Add(ledger.Save())
 	}
 	return errMulti.Return()
 }
 
-func showPkgbuildDiffs(gitBuilder CmdBuilder, run Runner, conf *settings.PersistentYayConfig, bases []dep.Base, cloned stringset.StringSet) error {
+// upgradeDiff returns a function fetching the PKGBUILD of an AUR upgrade and
+// showing its changes, used by the interactive upgrade menu.
+func upgradeDiff(rt *Runtime) func(upgrade.Upgrade) error {
+	return func(up upgrade.Upgrade) error {
+		pkgbase := up.Name
+		if pkg := rt.DB.LocalPackage(up.Name); pkg != nil && pkg.Base() != "" {
+			pkgbase = pkg.Base()
+		}
+
+		bases := []dep.Base{{&query.Pkg{Name: up.Name, PackageBase: pkgbase}}}
+
+		cloned, err := downloadPkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner},
+			bases, stringset.Make(), rt.Config.BuildDir, rt.Sources)
+		if err != nil {
+			return err
+		}
+
+		return showPkgbuildDiffs(rt.GitBuilder, rt.CmdRunner, &rt.Config.PersistentYayConfig, rt.Reviews, bases, cloned, nil)
+	}
+}
+
+func showPkgbuildDiffs(gitBuilder CmdBuilder, run Runner, conf *settings.PersistentYayConfig, ledger *review.Ledger, bases []dep.Base, cloned stringset.StringSet, revs map[string]string) error {
 	var errMulti multierror.MultiError
+	var diffs strings.Builder
+	br := buildRun{gitBuilder, run}
 	for _, base := range bases {
 		pkg := base.Pkgbase()
 		dir := filepath.Join(conf.BuildDir, pkg)
-		start, err := getLastSeenHash(buildRun{gitBuilder, run}, conf.BuildDir, pkg)
+		start, err := getLastSeenHash(br, ledger, conf.BuildDir, pkg)
 		if err != nil {
 			errMulti.Add(err)
 			continue
 		}
 
+		// a fresh clone has not been reviewed here, but maybe by the team
 		if cloned.Get(pkg) {
-			start = gitEmptyTree
-		} else {
-			hasDiff, err := gitHasDiff(buildRun{gitBuilder, run}, conf.BuildDir, pkg)
+			start = getReviewedHash(br, ledger, conf.BuildDir, pkg)
+		}
+
+		rev := buildRev(revs, pkg)
+		if start != gitEmptyTree {
+			hash, err := getRevHash(br, conf.BuildDir, pkg, rev)
 			if err != nil {
 				errMulti.Add(err)
 				continue
 			}
 
-			if !hasDiff {
+			if start == hash {
 				text.Warnln(text.Tf("%s: No changes -- skipping", text.Cyan(base.String())))
 				continue
 			}
 		}
 
-		args := []string{
-			"diff",
-			start + "..HEAD@{upstream}", "--src-prefix",
-			dir + "/", "--dst-prefix", dir + "/", "--", ".", ":(exclude).SRCINFO",
-		}
-		if text.UseColor {
-			args = append(args, "--color=always")
-		} else {
-			args = append(args, "--color=never")
+		stdout, stderr, err := run.Capture(gitBuilder.Build(dir, "diff", "--no-color", "--no-ext-diff",
+			"--src-prefix=a/", "--dst-prefix=b/", start+".."+rev,
+			"--", ".", ":(exclude).SRCINFO"), 0)
+		if err != nil {
+			errMulti.Add(fmt.Errorf("%s %s", stderr, err))
+			continue
 		}
-		_ = run.Show(gitBuilder.Build(dir, args...))
+
+		diff.Render(&diffs, dir+"/", diff.Parse(stdout))
+	}
+
+	if diffs.Len() > 0 {
+		errMulti.Add(view.Page(diffs.String()))
 	}
 
 	return errMulti.Return()
@@ -866,9 +960,14 @@ func pkgbuildsToSkip(bases []dep.Base, targets stringset.StringSet, reDownload,
 	return toSkip
 }
 
-func mergePkgbuilds(br buildRun, bases []dep.Base, buildDir string) error {
+func mergePkgbuilds(br buildRun, bases []dep.Base, buildDir string, revs map[string]string) error {
 	for _, base := range bases {
-		err := gitMerge(br, buildDir, base.Pkgbase())
+		var err error
+		if rev, ok := revs[base.Pkgbase()]; ok {
+			err = gitReset(br, buildDir, base.Pkgbase(), rev)
+		} else {
+			err = gitMerge(br, buildDir, base.Pkgbase())
+		}
 		if err != nil {
 			return err
 		}
@@ -877,7 +976,7 @@ func mergePkgbuilds(br buildRun, bases []dep.Base, buildDir string) error {
 	return nil
 }
 
-func downloadPkgbuilds(br buildRun, bases []dep.Base, toSkip stringset.StringSet, buildDir, aurURL string) (stringset.StringSet, error) {
+func downloadPkgbuilds(br buildRun, bases []dep.Base, toSkip stringset.StringSet, buildDir string, sources *query.Sources) (stringset.StringSet, error) {
 	cloned := stringset.Make()
 	downloaded := 0
 	var wg sync.WaitGroup
@@ -898,7 +997,13 @@ func downloadPkgbuilds(br buildRun, bases []dep.Base, toSkip stringset.StringSet
 			return
 		}
 
-		clone, err := gitDownload(br, aurURL+"/"+pkg+".git", buildDir, pkg)
+		var clone bool
+		var err error
+		if src := sources.Origin(pkg); src.Kind == query.SourceGit {
+			clone, err = linkSourcePkgbuild(src, buildDir, pkg)
+		} else {
+			clone, err = gitDownload(br, src.CloneURL(pkg), buildDir, pkg)
+		}
 		if err != nil {
 			errs.Add(err)
 			return
@@ -1147,6 +1252,7 @@ func buildInstallPkgbuilds(
 			}
 		}
 
+		pkgfiles := make([]string, 0, len(base))
 		doAddTarget := func(name string, optional bool) error {
 			pkgdest, ok := pkgdests[name]
 			if !ok {
@@ -1169,6 +1275,7 @@ func buildInstallPkgbuilds(
 			}
 
 			*arguments.Targets = append(*arguments.Targets, pkgdest)
+			pkgfiles = append(pkgfiles, pkgdest)
 			if pacmanUpgrade.AsDeps {
 				deps = append(deps, name)
 			} else if pacmanUpgrade.AsExplicit {
@@ -1190,6 +1297,18 @@ func buildInstallPkgbuilds(
 			}
 		}
 
+		if rt.Config.SignKey != "" {
+			if errSign := signPackages(rt, pkgfiles); errSign != nil {
+				return errSign
+			}
+		}
+
+		if rt.Config.LocalRepo != "" {
+			if errRepo := addToLocalRepo(rt, pkgfiles); errRepo != nil {
+				return errRepo
+			}
+		}
+
 		var mux sync.Mutex
 		var wg sync.WaitGroup
 		for _, pkg := range base {
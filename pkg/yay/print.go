@@ -16,47 +16,32 @@ import (
 // NumberMenu presents a CLI for selecting packages to install.
 func displayNumberMenu(pkgS []string, rt *Runtime) error {
 	var (
-		aurErr, repoErr error
-		aq              aurQuery
-		pq              repoQuery
-		lenaq, lenpq    int
+		aurErr error
+		aq     aurQuery
+		pq     repoQuery
 	)
 
-	pkgS = query.RemoveInvalidTargets(pkgS, rt.Config.Mode)
+	pkgS = query.RemoveInvalidTargets(pkgS, rt.Config.Mode, rt.Sources)
+
+	aurQ, repoQ, err := parseSearch(pkgS, rt.Config.SearchBy)
+	if err != nil {
+		return err
+	}
 
 	if rt.Config.Mode == settings.ModeAUR || rt.Config.Mode == settings.ModeAny {
-		aq, aurErr = narrowSearch(rt.AUR, pkgS, true, rt.Config.SearchBy, rt.Config.SortBy)
-		lenaq = len(aq)
+		aq, aurErr = narrowSearch(rt.AUR, aurQ, rt.sourceName, true, rt.Config.SortBy)
 	}
 	if rt.Config.Mode == settings.ModeRepo || rt.Config.Mode == settings.ModeAny {
-		pq = queryRepo(pkgS, rt.DB, rt.Config.SortMode)
-		lenpq = len(pq)
-		if repoErr != nil {
-			return repoErr
-		}
+		pq = queryRepo(repoQ, rt.DB)
 	}
 
-	if lenpq == 0 && lenaq == 0 {
+	if len(pq) == 0 && len(aq) == 0 {
 		return text.ErrT("no packages match search")
 	}
 
-	switch rt.Config.SortMode {
-	case settings.TopDown:
-		if rt.Config.Mode == settings.ModeRepo || rt.Config.Mode == settings.ModeAny {
-			printSearchRepo(pq, rt.DB, rt.Config.SearchMode, rt.Config.SortMode)
-		}
-		if rt.Config.Mode == settings.ModeAUR || rt.Config.Mode == settings.ModeAny {
-			printSearchAUR(aq, rt.DB, lenpq+1, rt.Config.SearchMode, rt.Config.SortMode)
-		}
-	case settings.BottomUp:
-		if rt.Config.Mode == settings.ModeAUR || rt.Config.Mode == settings.ModeAny {
-			printSearchAUR(aq, rt.DB, lenpq+1, rt.Config.SearchMode, rt.Config.SortMode)
-		}
-		if rt.Config.Mode == settings.ModeRepo || rt.Config.Mode == settings.ModeAny {
-			printSearchRepo(pq, rt.DB, rt.Config.SearchMode, rt.Config.SortMode)
-		}
-	default:
-		return text.ErrT("invalid sort mode. Fix with yay -Y --bottomup --save")
+	results := rankSearch(pq, aq, repoQ.Words(), rt.DB, rt.Config.SearchRank)
+	if err := printSearch(results, rt.DB, rt.sourceName, rt.Config.SearchMode, rt.Config.SortMode); err != nil {
+		return err
 	}
 
 	if aurErr != nil {
@@ -82,36 +67,15 @@ func displayNumberMenu(pkgS []string, rt *Runtime) error {
 
 	isInclude := len(exclude) == 0 && otherExclude.Len() == 0
 
-	for i, pkg := range pq {
-		var target int
-		switch rt.Config.SortMode {
-		case settings.TopDown:
-			target = i + 1
-		case settings.BottomUp:
-			target = len(pq) - i
-		default:
-			return text.ErrT("invalid sort mode. Fix with yay -Y --bottomup --save")
-		}
-
+	for i := range results {
+		target := i + 1
 		if (isInclude && include.Get(target)) || (!isInclude && !exclude.Get(target)) {
-			*arguments.Targets = append(*arguments.Targets, pkg.DB().Name()+"/"+pkg.Name())
-		}
-	}
-
-	for i := range aq {
-		var target int
-
-		switch rt.Config.SortMode {
-		case settings.TopDown:
-			target = i + 1 + len(pq)
-		case settings.BottomUp:
-			target = len(aq) - i + len(pq)
-		default:
-			return text.ErrT("invalid sort mode. Fix with yay -Y --bottomup --save")
-		}
-
-		if (isInclude && include.Get(target)) || (!isInclude && !exclude.Get(target)) {
-			*arguments.Targets = append(*arguments.Targets, "aur/"+aq[i].Name)
+			res := &results[i]
+			if res.repo != nil {
+				*arguments.Targets = append(*arguments.Targets, res.repo.DB().Name()+"/"+res.repo.Name())
+			} else {
+				*arguments.Targets = append(*arguments.Targets, rt.sourceName(res.aur.Name)+"/"+res.aur.Name)
+			}
 		}
 	}
 
@@ -172,14 +136,16 @@ func syncList(rt *Runtime, quiet bool) error {
 }
 
 // PrintInfo prints package info like pacman -Si.
-func printInfo(a *query.Pkg, aurURL string, extendedInfo bool) {
-	text.PrintInfoValue(text.T("Repository"), "aur")
+func printInfo(a *query.Pkg, src *query.Source, extendedInfo bool) {
+	text.PrintInfoValue(text.T("Repository"), src.Name)
 	text.PrintInfoValue(text.T("Name"), a.Name)
 	text.PrintInfoValue(text.T("Keywords"), a.Keywords...)
 	text.PrintInfoValue(text.T("Version"), a.Version)
 	text.PrintInfoValue(text.T("Description"), a.Description)
 	text.PrintInfoValue(text.T("URL"), a.URL)
-	text.PrintInfoValue(text.T("AUR URL"), aurURL+"/packages/"+a.Name)
+	if src.Kind == query.SourceAUR {
+		text.PrintInfoValue(text.T("AUR URL"), src.URL+"/packages/"+a.Name)
+	}
 	text.PrintInfoValue(text.T("Groups"), a.Groups...)
 	text.PrintInfoValue(text.T("Licenses"), a.License...)
 	text.PrintInfoValue(text.T("Provides"), a.Provides...)
@@ -204,7 +170,9 @@ func printInfo(a *query.Pkg, aurURL string, extendedInfo bool) {
 		text.PrintInfoValue("ID", fmt.Sprintf("%d", a.ID))
 		text.PrintInfoValue(text.T("Package Base ID"), fmt.Sprintf("%d", a.PackageBaseID))
 		text.PrintInfoValue(text.T("Package Base"), a.PackageBase)
-		text.PrintInfoValue(text.T("Snapshot URL"), aurURL+a.URLPath)
+		if a.URLPath != "" {
+			text.PrintInfoValue(text.T("Snapshot URL"), src.URL+a.URLPath)
+		}
 	}
 
 	text.Println()
@@ -225,7 +193,7 @@ func biggestPackages(dbExecutor db.Executor) {
 }
 
 // localStatistics prints installed packages statistics.
-func localStatistics(dbExecutor db.Executor, aur *query.AUR, yayVersion string, requestSplitN int) error {
+func localStatistics(dbExecutor db.Executor, aur query.AURInfoProvider, yayVersion string, requestSplitN int) error {
 	info := query.Statistics(dbExecutor)
 
 	_, remoteNames, err := query.GetPackageNamesBySource(dbExecutor)
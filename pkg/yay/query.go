@@ -6,11 +6,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	rpc "github.com/mikkeloscar/aur"
 
 	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/output"
 	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/search"
 	"github.com/Jguer/yay/v10/pkg/settings"
 	"github.com/Jguer/yay/v10/pkg/stringset"
 	"github.com/Jguer/yay/v10/pkg/text"
@@ -27,94 +30,89 @@ type aurQuery = []query.Pkg
 // Query holds the results of a repository search.
 type repoQuery = []db.IPackage
 
-// PrintSearch handles printing search results in a given format
-func printSearchAUR(q aurQuery, dbExecutor db.Executor, start int, searchMode settings.SearchMode, sortOrder enum) {
-	for i := range q {
-		var toprint string
-		if searchMode == settings.NumberMenu {
-			switch sortOrder {
-			case settings.TopDown:
-				toprint += text.Magenta(strconv.Itoa(start+i) + " ")
-			case settings.BottomUp:
-				toprint += text.Magenta(strconv.Itoa(len(q)+start-i-1) + " ")
-			default:
-				text.Warnln(text.T("invalid sort mode. Fix with yay -Y --bottomup --save"))
-			}
-		} else if searchMode == settings.Minimal {
-			text.Println(q[i].Name)
-			continue
-		}
+// printSearch prints ranked search results. The best result is numbered 1
+// and printed first, or last when sorting bottom up.
+func printSearch(results []searchResult, dbExecutor db.Executor, origin func(string) string,
+	searchMode settings.SearchMode, sortMode enum) error {
+	if sortMode != settings.TopDown && sortMode != settings.BottomUp {
+		return text.ErrT("invalid sort mode. Fix with yay -Y --bottomup --save")
+	}
 
-		toprint += text.Bold(text.ColorHash("aur")) + "/" + text.Bold(q[i].Name) +
-			" " + text.Cyan(q[i].Version) +
-			text.Bold(" (+"+strconv.Itoa(q[i].NumVotes)) +
-			" " + text.Bold(strconv.FormatFloat(q[i].Popularity, 'f', 2, 64)+") ")
+	for n := range results {
+		i := n
+		if sortMode == settings.BottomUp {
+			i = len(results) - 1 - n
+		}
+		res := &results[i]
 
-		if q[i].Maintainer == "" {
-			toprint += text.Bold(text.Red(text.T("(Orphaned)"))) + " "
+		if searchMode == settings.Minimal {
+			text.Println(res.name())
+			continue
 		}
 
-		if q[i].OutOfDate != 0 {
-			toprint += text.Bold(text.Red(text.Tf("(Out-of-date: %s)", text.FormatTime(q[i].OutOfDate)))) + " "
+		var toprint string
+		if searchMode == settings.NumberMenu {
+			toprint += text.Magenta(strconv.Itoa(i+1) + " ")
 		}
 
-		if pkg := dbExecutor.LocalPackage(q[i].Name); pkg != nil {
-			if pkg.Version() != q[i].Version {
-				toprint += text.Bold(text.Green(text.Tf("(Installed: %s)", pkg.Version())))
-			} else {
-				toprint += text.Bold(text.Green(text.T("(Installed)")))
-			}
+		if res.repo != nil {
+			toprint += formatSearchRepo(res.repo, dbExecutor)
+		} else {
+			toprint += formatSearchAUR(res.aur, dbExecutor, origin)
 		}
-		toprint += "\n    " + q[i].Description
 		text.Println(toprint)
 	}
+
+	return nil
 }
 
-// PrintSearch receives a RepoSearch type and outputs pretty text.
-func printSearchRepo(s repoQuery, dbExecutor db.Executor, searchMode settings.SearchMode, sortMode enum) {
-	for i, res := range s {
-		var toprint string
-		if searchMode == settings.NumberMenu {
-			switch sortMode {
-			case settings.TopDown:
-				toprint += text.Magenta(strconv.Itoa(i+1) + " ")
-			case settings.BottomUp:
-				toprint += text.Magenta(strconv.Itoa(len(s)-i) + " ")
-			default:
-				text.Warnln(text.T("invalid sort mode. Fix with yay -Y --bottomup --save"))
-			}
-		} else if searchMode == settings.Minimal {
-			text.Println(res.Name())
-			continue
-		}
+// formatSearchAUR formats an AUR search result without its number.
+func formatSearchAUR(pkg *query.Pkg, dbExecutor db.Executor, origin func(string) string) string {
+	toprint := text.Bold(text.ColorHash(origin(pkg.Name))) + "/" + text.Bold(pkg.Name) +
+		" " + text.Cyan(pkg.Version) +
+		text.Bold(" (+"+strconv.Itoa(pkg.NumVotes)) +
+		" " + text.Bold(strconv.FormatFloat(pkg.Popularity, 'f', 2, 64)+") ")
 
-		toprint += text.Bold(text.ColorHash(res.DB().Name())) + "/" + text.Bold(res.Name()) +
-			" " + text.Cyan(res.Version()) +
-			text.Bold(" ("+text.Human(res.Size())+
-				" "+text.Human(res.ISize())+") ")
+	if pkg.Maintainer == "" {
+		toprint += text.Bold(text.Red(text.T("(Orphaned)"))) + " "
+	}
 
-		packageGroups := dbExecutor.PackageGroups(res)
-		if len(packageGroups) != 0 {
-			toprint += fmt.Sprint(packageGroups, " ")
-		}
+	if pkg.OutOfDate != 0 {
+		toprint += text.Bold(text.Red(text.Tf("(Out-of-date: %s)", text.FormatTime(pkg.OutOfDate)))) + " "
+	}
 
-		if pkg := dbExecutor.LocalPackage(res.Name()); pkg != nil {
-			if pkg.Version() != res.Version() {
-				toprint += text.Bold(text.Green(text.Tf("(Installed: %s)", pkg.Version())))
-			} else {
-				toprint += text.Bold(text.Green(text.T("(Installed)")))
-			}
+	if local := dbExecutor.LocalPackage(pkg.Name); local != nil {
+		if local.Version() != pkg.Version {
+			toprint += text.Bold(text.Green(text.Tf("(Installed: %s)", local.Version())))
+		} else {
+			toprint += text.Bold(text.Green(text.T("(Installed)")))
 		}
-
-		toprint += "\n    " + res.Description()
-		text.Println(toprint)
 	}
+
+	return toprint + "\n    " + pkg.Description
 }
 
-func reverse(s repoQuery) {
-	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
-		s[i], s[j] = s[j], s[i]
+// formatSearchRepo formats a repo search result without its number.
+func formatSearchRepo(res db.IPackage, dbExecutor db.Executor) string {
+	toprint := text.Bold(text.ColorHash(res.DB().Name())) + "/" + text.Bold(res.Name()) +
+		" " + text.Cyan(res.Version()) +
+		text.Bold(" ("+text.Human(res.Size())+
+			" "+text.Human(res.ISize())+") ")
+
+	packageGroups := dbExecutor.PackageGroups(res)
+	if len(packageGroups) != 0 {
+		toprint += fmt.Sprint(packageGroups, " ")
+	}
+
+	if pkg := dbExecutor.LocalPackage(res.Name()); pkg != nil {
+		if pkg.Version() != res.Version() {
+			toprint += text.Bold(text.Green(text.Tf("(Installed: %s)", pkg.Version())))
+		} else {
+			toprint += text.Bold(text.Green(text.T("(Installed)")))
+		}
 	}
+
+	return toprint + "\n    " + res.Description()
 }
 
 func sortAURQuery(q aurQuery, sortBy string, sortMode enum) func(int, int) bool {
@@ -167,56 +165,115 @@ func getSearchBy(value string) rpc.By {
 	}
 }
 
-// NarrowSearch searches AUR and narrows based on subarguments
-func narrowSearch(aur *query.AUR, pkgS []string, sortS bool, searchBy string, sortBy string) (aurQuery, error) {
-	var r []query.Pkg
-	var err error
-	var usedIndex int
+// depNames strips version constraints and optdepends descriptions.
+func depNames(deps []string) []string {
+	names := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if i := strings.IndexAny(dep, "<>=:"); i != -1 {
+			dep = dep[:i]
+		}
+		names = append(names, dep)
+	}
+	return names
+}
 
-	by := getSearchBy(searchBy)
+func aurSearchPackage(pkg *query.Pkg, repo string) *search.Package {
+	var updated time.Time
+	if pkg.LastModified != 0 {
+		updated = time.Unix(int64(pkg.LastModified), 0)
+	}
 
-	if len(pkgS) == 0 {
-		return nil, nil
+	return &search.Package{
+		Name:        pkg.Name,
+		Description: pkg.Description,
+		Maintainer:  pkg.Maintainer,
+		Repo:        repo,
+		Lists: map[string][]string{
+			search.Depends:      depNames(pkg.Depends),
+			search.MakeDepends:  depNames(pkg.MakeDepends),
+			search.OptDepends:   depNames(pkg.OptDepends),
+			search.CheckDepends: depNames(pkg.CheckDepends),
+			search.Provides:     depNames(pkg.Provides),
+			search.Keyword:      pkg.Keywords,
+			search.Group:        pkg.Groups,
+			search.License:      pkg.License,
+		},
+		Rated:      true,
+		Votes:      pkg.NumVotes,
+		Popularity: pkg.Popularity,
+		Updated:    updated,
 	}
+}
 
-	for i, word := range pkgS {
-		r, err = aur.SearchBy(word, by)
-		if err == nil {
-			usedIndex = i
-			break
+// repoSearchPackage gathers the fields of a repo package used by q.
+func repoSearchPackage(pkg db.IPackage, dbExecutor db.Executor, q *search.Query) *search.Package {
+	names := func(deps []db.Depend) []string {
+		s := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			s = append(s, dep.Name)
 		}
+		return s
 	}
 
-	if err != nil {
-		return nil, err
+	lists := make(map[string][]string)
+	if q.Uses(search.Depends) {
+		lists[search.Depends] = names(dbExecutor.PackageDepends(pkg))
+	}
+	if q.Uses(search.OptDepends) {
+		lists[search.OptDepends] = names(dbExecutor.PackageOptionalDepends(pkg))
+	}
+	if q.Uses(search.Provides) {
+		lists[search.Provides] = names(dbExecutor.PackageProvides(pkg))
+	}
+	if q.Uses(search.Group) {
+		lists[search.Group] = dbExecutor.PackageGroups(pkg)
 	}
 
-	if len(pkgS) == 1 {
-		if sortS {
-			sort.Slice(aurQuery(r), sortAURQuery(r, sortBy, settings.TopDown))
-		}
-		return r, err
+	return &search.Package{
+		Name:        pkg.Name(),
+		Description: pkg.Description(),
+		Repo:        pkg.DB().Name(),
+		Lists:       lists,
+		Updated:     pkg.BuildDate(),
 	}
+}
 
-	var aq aurQuery
-	var n int
+// parseSearch parses a search query for the AUR, where bare words are
+// matched against searchBy, and for the repos, where they are matched
+// against name and description like pacman does.
+func parseSearch(pkgS []string, searchBy string) (aurQ, repoQ *search.Query, err error) {
+	aurQ, err = search.Parse(pkgS, searchBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	repoQ, err = search.Parse(pkgS, search.NameDesc)
+	return aurQ, repoQ, err
+}
 
-	for i := range r {
-		match := true
-		for j, pkgN := range pkgS {
-			if usedIndex == j {
-				continue
-			}
+// NarrowSearch searches the AUR for the seeds of a query and narrows the
+// results down to the matching packages.
+func narrowSearch(aur query.AURBackend, q *search.Query, origin func(string) string, sortS bool, sortBy string) (aurQuery, error) {
+	alternatives := q.Seeds()
+	if len(alternatives) == 0 {
+		return nil, text.ErrT("the query needs a name, description, maintainer or dependency to search the AUR")
+	}
 
-			if !(strings.Contains(r[i].Name, pkgN) || strings.Contains(strings.ToLower(r[i].Description), pkgN)) {
-				match = false
-				break
-			}
+	var found []query.Pkg
+	var err error
+	for _, seeds := range alternatives {
+		found, err = searchSeeds(aur, seeds)
+		if err == nil {
+			break
 		}
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		if match {
-			n++
-			aq = append(aq, r[i])
+	aq := make(aurQuery, 0, len(found))
+	for i := range found {
+		if q.Match(aurSearchPackage(&found[i], origin(found[i].Name))) {
+			aq = append(aq, found[i])
 		}
 	}
 
@@ -224,70 +281,147 @@ func narrowSearch(aur *query.AUR, pkgS []string, sortS bool, searchBy string, so
 		sort.Slice(aq, sortAURQuery(aq, sortBy, settings.TopDown))
 	}
 
-	return aq, err
+	return aq, nil
+}
+
+// searchSeeds returns the union of the search results of all seeds.
+func searchSeeds(aur query.AURBackend, seeds []search.Seed) ([]query.Pkg, error) {
+	seen := stringset.Make()
+	found := make([]query.Pkg, 0)
+
+	for _, seed := range seeds {
+		r, err := aur.SearchBy(seed.Value, getSearchBy(seed.Field))
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range r {
+			if !seen.Get(r[i].Name) {
+				seen.Set(r[i].Name)
+				found = append(found, r[i])
+			}
+		}
+	}
+
+	return found, nil
 }
 
 // SyncSearch presents a query to the local repos and to the AUR.
 func syncSearch(pkgS []string, rt *Runtime) (err error) {
-	pkgS = query.RemoveInvalidTargets(pkgS, rt.Config.Mode)
+	pkgS = query.RemoveInvalidTargets(pkgS, rt.Config.Mode, rt.Sources)
 	var aurErr error
 	var aq aurQuery
 	var pq repoQuery
 
-	switch rt.Config.Mode {
-	case settings.ModeAUR:
-		aq, aurErr = narrowSearch(rt.AUR, pkgS, true, rt.Config.SearchBy, rt.Config.SortBy)
-	case settings.ModeRepo:
-		pq = queryRepo(pkgS, rt.DB, rt.Config.SortMode)
-	case settings.ModeAny:
-		aq, aurErr = narrowSearch(rt.AUR, pkgS, true, rt.Config.SearchBy, rt.Config.SortBy)
-		pq = queryRepo(pkgS, rt.DB, rt.Config.SortMode)
+	formatter, err := newFormatter(rt)
+	if err != nil {
+		return err
+	}
+	divert := func(f func()) { f() }
+	if formatter != nil {
+		divert = toStderr
 	}
 
-	switch rt.Config.SortMode {
-	case settings.TopDown:
-		if rt.Config.Mode == settings.ModeRepo || rt.Config.Mode == settings.ModeAny {
-			printSearchRepo(pq, rt.DB, rt.Config.SearchMode, rt.Config.SortMode)
-		}
-		if rt.Config.Mode == settings.ModeAUR || rt.Config.Mode == settings.ModeAny {
-			printSearchAUR(aq, rt.DB, 1, rt.Config.SearchMode, rt.Config.SortMode)
-		}
-	case settings.BottomUp:
-		if rt.Config.Mode == settings.ModeAUR || rt.Config.Mode == settings.ModeAny {
-			printSearchAUR(aq, rt.DB, 1, rt.Config.SearchMode, rt.Config.SortMode)
+	aurQ, repoQ, err := parseSearch(pkgS, rt.Config.SearchBy)
+	if err != nil {
+		return err
+	}
+
+	divert(func() {
+		switch rt.Config.Mode {
+		case settings.ModeAUR:
+			aq, aurErr = narrowSearch(rt.AUR, aurQ, rt.sourceName, true, rt.Config.SortBy)
+		case settings.ModeRepo:
+			pq = queryRepo(repoQ, rt.DB)
+		case settings.ModeAny:
+			aq, aurErr = narrowSearch(rt.AUR, aurQ, rt.sourceName, true, rt.Config.SortBy)
+			pq = queryRepo(repoQ, rt.DB)
 		}
-		if rt.Config.Mode == settings.ModeRepo || rt.Config.Mode == settings.ModeAny {
-			printSearchRepo(pq, rt.DB, rt.Config.SearchMode, rt.Config.SortMode)
+	})
+
+	results := rankSearch(pq, aq, repoQ.Words(), rt.DB, rt.Config.SearchRank)
+	if formatter != nil {
+		pkgs := make([]output.Package, 0, len(results))
+		for i := range results {
+			if res := &results[i]; res.repo != nil {
+				pkgs = append(pkgs, output.FromRepo(res.repo, rt.DB))
+			} else {
+				pkgs = append(pkgs, output.FromAUR(res.aur, rt.sourceName(res.aur.Name), rt.DB))
+			}
 		}
-	default:
-		return errors.New(text.T("invalid sort mode. Fix with yay -Y --bottomup --save"))
+		err = writeFormatted(formatter, pkgs)
+	} else {
+		err = printSearch(results, rt.DB, rt.sourceName, rt.Config.SearchMode, rt.Config.SortMode)
+	}
+	if err != nil {
+		return err
 	}
 
 	if aurErr != nil {
-		text.Errorln(text.Tf("error during AUR search: %s", aurErr))
-		text.Warnln(text.T("Showing repo packages only"))
+		divert(func() {
+			text.Errorln(text.Tf("error during AUR search: %s", aurErr))
+			text.Warnln(text.T("Showing repo packages only"))
+		})
 	}
 
 	return nil
 }
 
+// newFormatter returns the formatter of --format, nil without the option.
+func newFormatter(rt *Runtime) (*output.Formatter, error) {
+	if rt.Config.Format == "" {
+		return nil, nil
+	}
+	return output.New(rt.Config.Format)
+}
+
+func writeFormatted(formatter *output.Formatter, pkgs []output.Package) error {
+	_, out, _ := text.AllPorts()
+	return formatter.Write(out, pkgs)
+}
+
+// toStderr runs f with its output sent to stderr so that it does not mix
+// with formatted output.
+func toStderr(f func()) {
+	_, _, errOut := text.AllPorts()
+	text.CaptureOutput(errOut, errOut, f)
+}
+
+// pinTargets pins source prefixed targets to their source and returns the
+// targets without prefix.
+func pinTargets(sources *query.Sources, targets []string) []string {
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		dbName, name := text.SplitDBFromName(target)
+		if dbName != "" {
+			sources.Pin(name, dbName)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
 // SyncInfo serves as a pacman -Si for repo packages and AUR packages.
 func syncInfo(cmdArgs *settings.PacmanConf, pkgS []string, rt *Runtime) error {
 	var info []*query.Pkg
 	var err error
 	missing := false
-	pkgS = query.RemoveInvalidTargets(pkgS, rt.Config.Mode)
-	aurS, repoS := packageSlices(pkgS, rt.DB, rt.Config.Mode)
+	pkgS = query.RemoveInvalidTargets(pkgS, rt.Config.Mode, rt.Sources)
 
-	if len(aurS) != 0 {
-		noDB := make([]string, 0, len(aurS))
+	formatter, err := newFormatter(rt)
+	if err != nil {
+		return err
+	}
+	if formatter != nil {
+		return syncInfoFormatted(formatter, pkgS, rt)
+	}
 
-		for _, pkg := range aurS {
-			_, name := text.SplitDBFromName(pkg)
-			noDB = append(noDB, name)
-		}
+	aurS, repoS := packageSlices(pkgS, rt.DB, rt.Config.Mode, rt.Sources)
 
-		info, err = query.AURInfoPrint(rt.AUR, noDB, rt.Config.RequestSplitN)
+	if len(aurS) != 0 {
+		noDB := pinTargets(rt.Sources, aurS)
+
+		info, err = query.AURInfoPrint(rt.AURInfo, noDB, rt.Config.RequestSplitN)
 		if err != nil {
 			missing = true
 			text.EPrintln(err)
@@ -312,7 +446,7 @@ func syncInfo(cmdArgs *settings.PacmanConf, pkgS []string, rt *Runtime) error {
 
 	if len(info) != 0 {
 		for _, pkg := range info {
-			printInfo(pkg, rt.Config.AURURL, cmdArgs.ModeConf.(*settings.QConf).Info > 1)
+			printInfo(pkg, rt.Sources.Origin(pkg.Name), cmdArgs.ModeConf.(*settings.SConf).Info > 1)
 		}
 	}
 
@@ -323,23 +457,86 @@ func syncInfo(cmdArgs *settings.PacmanConf, pkgS []string, rt *Runtime) error {
 	return err
 }
 
-// Search handles repo searches. Creates a RepoSearch struct.
-func queryRepo(pkgInputN []string, dbExecutor db.Executor, sortOrder enum) repoQuery {
-	s := repoQuery(dbExecutor.SyncPackages(pkgInputN...))
+// syncInfoFormatted is syncInfo for --format. Repo packages are looked up
+// instead of being shown by pacman, without targets all of them are written.
+func syncInfoFormatted(formatter *output.Formatter, pkgS []string, rt *Runtime) error {
+	pkgs := make([]output.Package, 0, len(pkgS))
+	missing := false
 
-	if sortOrder == settings.BottomUp {
-		reverse(s)
+	if len(pkgS) == 0 {
+		for _, pkg := range rt.DB.SyncPackages() {
+			pkgs = append(pkgs, output.FromRepo(pkg, rt.DB))
+		}
 	}
+
+	aurS, repoS := packageSlices(pkgS, rt.DB, rt.Config.Mode, rt.Sources)
+
+	for _, target := range repoS {
+		var pkg db.IPackage
+		if dbName, name := text.SplitDBFromName(target); dbName != "" {
+			pkg = rt.DB.SatisfierFromDB(name, dbName)
+		} else {
+			pkg = rt.DB.SyncPackage(name)
+		}
+
+		if pkg == nil {
+			text.Errorln(text.Tf("package '%s' was not found", target))
+			missing = true
+			continue
+		}
+		pkgs = append(pkgs, output.FromRepo(pkg, rt.DB))
+	}
+
+	if len(aurS) != 0 {
+		var (
+			info []*query.Pkg
+			err  error
+		)
+		toStderr(func() {
+			info, err = query.AURInfoPrint(rt.AURInfo, pinTargets(rt.Sources, aurS), rt.Config.RequestSplitN)
+		})
+		if err != nil {
+			text.EPrintln(err)
+		}
+		if err != nil || len(info) != len(aurS) {
+			missing = true
+		}
+
+		for _, pkg := range info {
+			pkgs = append(pkgs, output.FromAUR(pkg, rt.sourceName(pkg.Name), rt.DB))
+		}
+	}
+
+	if err := writeFormatted(formatter, pkgs); err != nil {
+		return err
+	}
+
+	if missing {
+		return errMissing
+	}
+	return nil
+}
+
+// Search handles repo searches. Creates a RepoSearch struct.
+func queryRepo(q *search.Query, dbExecutor db.Executor) repoQuery {
+	s := make(repoQuery, 0)
+	for _, pkg := range dbExecutor.SyncPackages() {
+		if q.Match(repoSearchPackage(pkg, dbExecutor, q)) {
+			s = append(s, pkg)
+		}
+	}
+
 	return s
 }
 
 // PackageSlices separates an input slice into aur and repo slices
-func packageSlices(toCheck []string, dbExecutor db.Executor, mode settings.TargetMode) (aur, repo []string) {
+func packageSlices(toCheck []string, dbExecutor db.Executor, mode settings.TargetMode,
+	sources *query.Sources) (aur, repo []string) {
 	for _, _pkg := range toCheck {
 		dbName, name := text.SplitDBFromName(_pkg)
 		found := false
 
-		if dbName == "aur" || mode == settings.ModeAUR {
+		if sources.IsSourceName(dbName) || mode == settings.ModeAUR {
 			aur = append(aur, _pkg)
 			continue
 		} else if dbName != "" || mode == settings.ModeRepo {
@@ -367,18 +564,21 @@ func printNumberOfUpdates(rt *Runtime, enableDowngrade bool) error {
 	warnings := query.NewWarnings()
 
 	var (
-		aurUp  []upgrade.Upgrade
-		repoUp []upgrade.Upgrade
-		err    error
+		aurUp   []upgrade.Upgrade
+		repoUp  []upgrade.Upgrade
+		changes []maintainerChange
+		err     error
 	)
 
 	text.CaptureOutput(nil, nil, func() {
-		aurUp, repoUp, err = upList(warnings, rt, enableDowngrade)
+		aurUp, repoUp, changes, err = upList(warnings, rt, enableDowngrade, false)
 	})
 
 	if err != nil {
 		return err
 	}
+	warnings.PrintFailed()
+	printPausedUpgrades(changes, rt.Config.TrustChanges)
 	text.Println(len(aurUp) + len(repoUp))
 
 	return nil
@@ -394,6 +594,7 @@ func printUpdateList(cmdArgs *settings.PacmanConf, rt *Runtime, enableDowngrade
 		remoteNames []string
 		aurUp       []upgrade.Upgrade
 		repoUp      []upgrade.Upgrade
+		changes     []maintainerChange
 	)
 	text.CaptureOutput(nil, nil, func() {
 		localNames, remoteNames, err = query.GetPackageNamesBySource(rt.DB)
@@ -401,21 +602,31 @@ func printUpdateList(cmdArgs *settings.PacmanConf, rt *Runtime, enableDowngrade
 			return
 		}
 
-		aurUp, repoUp, err = upList(warnings, rt, enableDowngrade)
+		aurUp, repoUp, changes, err = upList(warnings, rt, enableDowngrade, false)
 	})
 
 	if err != nil {
 		return err
 	}
+	warnings.PrintFailed()
+	printPausedUpgrades(changes, rt.Config.TrustChanges)
 
 	noTargets := targets.Len() == 0
 
 	qconf := cmdArgs.ModeConf.(*settings.QConf)
 
+	formatter, err := newFormatter(rt)
+	if err != nil {
+		return err
+	}
+	pkgs := make([]output.Package, 0, len(repoUp)+len(aurUp))
+
 	if !qconf.Foreign {
 		for _, pkg := range repoUp {
 			if noTargets || targets.Get(pkg.Name) {
-				if qconf.Quiet {
+				if formatter != nil {
+					pkgs = append(pkgs, output.FromUpgrade(&pkg))
+				} else if qconf.Quiet {
 					text.Printf("%s\n", pkg.Name)
 				} else {
 					text.Printf("%s %s -> %s\n", text.Bold(pkg.Name), text.Green(pkg.LocalVersion), text.Green(pkg.RemoteVersion))
@@ -428,7 +639,9 @@ func printUpdateList(cmdArgs *settings.PacmanConf, rt *Runtime, enableDowngrade
 	if !qconf.Native {
 		for _, pkg := range aurUp {
 			if noTargets || targets.Get(pkg.Name) {
-				if qconf.Quiet {
+				if formatter != nil {
+					pkgs = append(pkgs, output.FromUpgrade(&pkg))
+				} else if qconf.Quiet {
 					text.Printf("%s\n", pkg.Name)
 				} else {
 					text.Printf("%s %s -> %s\n", text.Bold(pkg.Name), text.Green(pkg.LocalVersion), text.Green(pkg.RemoteVersion))
@@ -438,6 +651,12 @@ func printUpdateList(cmdArgs *settings.PacmanConf, rt *Runtime, enableDowngrade
 		}
 	}
 
+	if formatter != nil {
+		if err := writeFormatted(formatter, pkgs); err != nil {
+			return err
+		}
+	}
+
 	missing := false
 
 outer:
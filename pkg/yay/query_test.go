@@ -0,0 +1,124 @@
+package yay
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	rpc "github.com/mikkeloscar/aur"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/db/mock"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/search"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+type searchBackend struct {
+	pkgs     []query.Pkg
+	searches []string
+}
+
+func (b *searchBackend) Info(names []string) ([]query.Pkg, error) {
+	found := []query.Pkg{}
+	for _, pkg := range b.pkgs {
+		for _, name := range names {
+			if pkg.Name == name {
+				found = append(found, pkg)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (b *searchBackend) Search(q string) ([]query.Pkg, error) { return b.SearchBy(q, rpc.NameDesc) }
+
+func (b *searchBackend) SearchBy(q string, by rpc.By) ([]query.Pkg, error) {
+	b.searches = append(b.searches, q+" by "+by.String())
+	if len(q) < 2 {
+		return nil, errors.New("Query arg too small.")
+	}
+
+	found := []query.Pkg{}
+	for _, pkg := range b.pkgs {
+		switch by {
+		case rpc.Maintainer:
+			if pkg.Maintainer == q {
+				found = append(found, pkg)
+			}
+		default:
+			if strings.Contains(pkg.Name, q) || strings.Contains(strings.ToLower(pkg.Description), q) {
+				found = append(found, pkg)
+			}
+		}
+	}
+	return found, nil
+}
+
+func Test_narrowSearch(t *testing.T) {
+	aur := &searchBackend{pkgs: []query.Pkg{
+		{Name: "yay", Description: "AUR helper", Maintainer: "jguer", NumVotes: 1500},
+		{Name: "yay-bin", Description: "AUR helper (binary)", Maintainer: "jguer", NumVotes: 90},
+		{Name: "paru", Description: "AUR helper", Maintainer: "morganamilo", NumVotes: 800},
+	}}
+	origin := func(string) string { return "aur" }
+
+	tests := []struct {
+		query    string
+		searchBy string
+		want     []string
+		searches []string
+	}{
+		{"helper votes:>100", search.NameDesc, []string{"yay", "paru"}, []string{"helper by name-desc"}},
+		{"y helper NOT bin", search.NameDesc, []string{"yay"}, []string{"y by name-desc", "helper by name-desc"}},
+		{"jguer OR morganamilo", search.Maintainer, []string{"yay", "yay-bin", "paru"},
+			[]string{"jguer by maintainer", "morganamilo by maintainer"}},
+	}
+
+	for _, tt := range tests {
+		aur.searches = nil
+		q, err := search.Parse(strings.Fields(tt.query), tt.searchBy)
+		require.NoError(t, err)
+
+		aq, err := narrowSearch(aur, q, origin, false, "votes")
+		require.NoError(t, err)
+
+		names := []string{}
+		for _, pkg := range aq {
+			names = append(names, pkg.Name)
+		}
+		assert.Equal(t, tt.want, names, tt.query)
+		assert.Equal(t, tt.searches, aur.searches, tt.query)
+	}
+
+	q, err := search.Parse([]string{"votes:>100"}, search.NameDesc)
+	require.NoError(t, err)
+	_, err = narrowSearch(aur, q, origin, false, "votes")
+	assert.Error(t, err)
+}
+
+func Test_syncSearchFormat(t *testing.T) {
+	rt := &Runtime{
+		DB: &mock.DBMock{Sync: []db.IPackage{
+			&mock.Package{PName: "pacman", PVersion: "5.2.2-2", PDescription: "A library-based package manager", PDB: &mock.DB{DBName: "core"}},
+		}},
+		AUR: &searchBackend{pkgs: []query.Pkg{
+			{Name: "yay", Version: "10.2-1", Description: "Pacman wrapper\tand AUR helper", NumVotes: 1500},
+		}},
+		Config: &settings.YayConfig{Format: "tsv", PersistentYayConfig: *settings.Defaults()},
+	}
+
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, nil, func() {
+		require.NoError(t, syncSearch([]string{"pacman"}, rt))
+	})
+	assert.Equal(t, "core\tpacman\t5.2.2-2\t\tA library-based package manager\n"+
+		"aur\tyay\t10.2-1\t\tPacman wrapper and AUR helper\n", buf.String())
+
+	rt.Config.Format = "{{.Name"
+	assert.Error(t, syncSearch([]string{"pacman"}, rt))
+}
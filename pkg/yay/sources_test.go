@@ -0,0 +1,174 @@
+package yay
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+)
+
+func Test_newSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-sources")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &settings.YayConfig{CacheDir: dir}
+	conf.AURURL = "https://aur.archlinux.org"
+	conf.BuildDir = dir
+	conf.Sources = []settings.PkgbuildSource{
+		{Name: "work", Type: "git", URL: "https://git.example.com/pkgbuilds.git"},
+		{Name: "aur"},
+		{Name: "mirror", Type: "aur", URL: "https://aur.example.com/"},
+	}
+
+	sources, err := newSources(conf, []string{"core", "extra"}, buildRun{})
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(sources.List))
+	for _, src := range sources.List {
+		got = append(got, src.Name+":"+src.Kind+":"+src.URL)
+	}
+	assert.Equal(t, []string{
+		"work:git:https://git.example.com/pkgbuilds.git",
+		"aur:aur:https://aur.archlinux.org",
+		"mirror:aur:https://aur.example.com",
+	}, got)
+	assert.Equal(t, filepath.Join(dir, sourcesDirName, "work"), sources.List[0].Dir)
+	// the info cache is opt-in
+	assert.Nil(t, sources.List[1].Cache)
+	assert.Nil(t, sources.List[2].Cache)
+
+	conf.AURCacheTTL = 10
+	sources, err = newSources(conf, []string{"core", "extra"}, buildRun{})
+	require.NoError(t, err)
+	assert.NotNil(t, sources.List[1].Cache)
+	assert.NotNil(t, sources.List[2].Cache)
+
+	conf.Sources = conf.Sources[:1]
+	sources, err = newSources(conf, []string{"core", "extra"}, buildRun{})
+	require.NoError(t, err)
+	assert.Equal(t, "aur", sources.List[1].Name)
+
+	for _, bad := range [][]settings.PkgbuildSource{
+		{{Name: "", Type: "git"}},
+		{{Name: "a/b", Type: "git"}},
+		{{Name: "work", Type: "svn"}},
+		{{Name: "work", Type: "git"}, {Name: "work", Type: "aur"}},
+		{{Name: "core", Type: "git"}},
+		{{Name: "devel", Type: "aur"}},
+	} {
+		conf.Sources = bad
+		_, err = newSources(conf, []string{"core", "extra"}, buildRun{})
+		assert.Error(t, err, "%v", bad)
+	}
+}
+
+func Test_linkSourcePkgbuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-sources")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildDir := filepath.Join(dir, "build")
+	src := &query.Source{Name: "work", Kind: query.SourceGit, Dir: filepath.Join(buildDir, sourcesDirName, "work")}
+	require.NoError(t, os.MkdirAll(filepath.Join(src.Dir, "tool"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src.Dir, "tool", "PKGBUILD"), []byte("pkgname=tool\n"), 0o644))
+
+	linked, err := linkSourcePkgbuild(src, buildDir, "tool")
+	require.NoError(t, err)
+	assert.True(t, linked)
+
+	pkgbuild, err := ioutil.ReadFile(filepath.Join(buildDir, "tool", "PKGBUILD"))
+	require.NoError(t, err)
+	assert.Equal(t, "pkgname=tool\n", string(pkgbuild))
+
+	linked, err = linkSourcePkgbuild(src, buildDir, "tool")
+	require.NoError(t, err)
+	assert.False(t, linked)
+
+	// a clone from the AUR is not replaced silently
+	require.NoError(t, os.MkdirAll(filepath.Join(buildDir, "yay"), 0o755))
+	_, err = linkSourcePkgbuild(src, buildDir, "yay")
+	assert.Error(t, err)
+}
+
+func TestSourceLinkSeenRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "yay-sources")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	git := func(dir string, args ...string) string {
+		args = append([]string{"-C", dir, "-c", "user.name=yay", "-c", "user.email=yay@example.org"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	upstream := filepath.Join(dir, "upstream")
+	commit := func(pkgver string) string {
+		for _, name := range []string{"tool", "lib"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(upstream, name), 0o755))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(upstream, name, "PKGBUILD"),
+				[]byte("pkgname="+name+"\npkgver="+pkgver+"\n"), 0o644))
+		}
+		git(upstream, "add", "-A")
+		git(upstream, "commit", "-qm", "update")
+		return git(upstream, "rev-parse", "HEAD")
+	}
+
+	require.NoError(t, os.MkdirAll(upstream, 0o755))
+	git(upstream, "init", "-q")
+	first := commit("1")
+
+	buildDir := filepath.Join(dir, "build")
+	src := &query.Source{Name: "work", Kind: query.SourceGit, Dir: filepath.Join(buildDir, sourcesDirName, "work")}
+	br := buildRun{&exe.GitBuilder{GitBin: "git"}, &exe.OSRunner{}}
+	require.NoError(t, gitSyncSource(br, upstream, src.Dir))
+	for _, name := range []string{"tool", "lib"} {
+		_, err = linkSourcePkgbuild(src, buildDir, name)
+		require.NoError(t, err)
+	}
+	assert.True(t, isSourceLink(buildDir, "tool"))
+
+	bases := []dep.Base{
+		{&query.Pkg{Name: "tool", PackageBase: "tool"}},
+		{&query.Pkg{Name: "lib", PackageBase: "lib"}},
+	}
+	require.NoError(t, updatePkgbuildSeenRef(br, nil, "", bases, buildDir, nil))
+
+	second := commit("2")
+	require.NoError(t, gitSyncSource(br, upstream, src.Dir))
+
+	// reviewing one base leaves the others unseen
+	require.NoError(t, updatePkgbuildSeenRef(br, nil, "", bases[:1], buildDir, nil))
+	seen, err := getLastSeenHash(br, nil, buildDir, "tool")
+	require.NoError(t, err)
+	assert.Equal(t, second, seen)
+	seen, err = getLastSeenHash(br, nil, buildDir, "lib")
+	require.NoError(t, err)
+	assert.Equal(t, first, seen)
+
+	// restoring one base keeps the changes to the others
+	for _, name := range []string{"tool", "lib"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(buildDir, name, "PKGBUILD"), []byte("edited\n"), 0o644))
+	}
+	require.NoError(t, gitMerge(br, buildDir, "lib"))
+	lib, err := ioutil.ReadFile(filepath.Join(buildDir, "lib", "PKGBUILD"))
+	require.NoError(t, err)
+	assert.Equal(t, "pkgname=lib\npkgver=2\n", string(lib))
+	tool, err := ioutil.ReadFile(filepath.Join(buildDir, "tool", "PKGBUILD"))
+	require.NoError(t, err)
+	assert.Equal(t, "edited\n", string(tool))
+}
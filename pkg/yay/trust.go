@@ -0,0 +1,200 @@
+package yay
+
+import (
+	"strings"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/stringset"
+	"github.com/Jguer/yay/v10/pkg/text"
+	"github.com/Jguer/yay/v10/pkg/tofu"
+	"github.com/Jguer/yay/v10/pkg/upgrade"
+)
+
+// checkTrust compares the PGP keys and sources of the bases with the ones
+// they were last installed with. Changes that could smuggle in other code
+// have to be confirmed, even with --noconfirm, unless --trustchanges is set.
+func checkTrust(rt *Runtime, bases []dep.Base, srcinfos map[string]*gosrc.Srcinfo) error {
+	confirm := false
+	for _, base := range bases {
+		pkgbase := base.Pkgbase()
+		old, known := rt.Trust.Get(pkgbase)
+		srcinfo, ok := srcinfos[pkgbase]
+		if !known || !ok {
+			continue
+		}
+
+		changes := tofu.Compare(old, tofu.FromSrcinfo(srcinfo))
+		if len(changes) == 0 {
+			continue
+		}
+
+		text.Warnln(text.Tf("%s: keys or sources changed since %s was installed", text.Cyan(pkgbase), old.Version))
+		for _, c := range changes {
+			msg := c.Message()
+			if c.Serious() {
+				msg = text.Bold(text.Red(msg))
+				confirm = true
+			}
+			text.Println("    " + msg)
+		}
+	}
+
+	if !confirm || rt.Config.TrustChanges {
+		return nil
+	}
+
+	text.Println()
+	// asked even with --noconfirm
+	if !text.ContinueTask(text.T("Trust the changed keys and sources?"), false, false) {
+		return text.ErrT("aborting due to changed keys or sources, see --trustchanges")
+	}
+	return nil
+}
+
+// coMaintainers returns the co-maintainers of an AUR package base, which
+// only the metadata dump reports.
+func coMaintainers(rt *Runtime, pkgbase string) ([]string, bool) {
+	finder, ok := rt.AUR.(query.CoMaintainerFinder)
+	if !ok {
+		return nil, false
+	}
+	return finder.CoMaintainers(pkgbase)
+}
+
+// recordTrust remembers the PGP keys, sources and maintainers the bases
+// were installed with.
+func recordTrust(rt *Runtime, bases []dep.Base, srcinfos map[string]*gosrc.Srcinfo) error {
+	if rt.Trust == nil {
+		return nil
+	}
+
+	for _, base := range bases {
+		srcinfo, ok := srcinfos[base.Pkgbase()]
+		if !ok {
+			continue
+		}
+
+		r := tofu.FromSrcinfo(srcinfo)
+		r.Maintainer = base[0].Maintainer
+		r.LastModified = int64(base[0].LastModified)
+		if co, ok := coMaintainers(rt, base.Pkgbase()); ok {
+			r.CoMaintainers = append([]string{}, co...)
+		}
+		rt.Trust.Set(base.Pkgbase(), r)
+	}
+	return rt.Trust.Save()
+}
+
+// maintainerChange is an AUR package whose package base changed maintainer
+// or co-maintainers since it was installed.
+type maintainerChange struct {
+	pkg *query.Pkg
+	was tofu.Record
+	// co are the current co-maintainers if they changed.
+	co []string
+}
+
+// pauseMaintainerChanges leaves out the AUR upgrades of package bases that
+// changed maintainer or co-maintainers since they were installed, unless
+// --trustchanges is set. The changes are returned once per package base and printed by the
+// caller, update checks capture the output of the search. Installing a
+// package records its new maintainers.
+func pauseMaintainerChanges(rt *Runtime, ups []upgrade.Upgrade,
+	aurdata map[string]*query.Pkg) (kept []upgrade.Upgrade, changes []maintainerChange) {
+	kept = ups[:0]
+	warned := stringset.Make()
+
+	for _, up := range ups {
+		pkg, ok := aurdata[up.Name]
+		if !ok {
+			kept = append(kept, up)
+			continue
+		}
+
+		r, known := rt.Trust.Get(pkg.PackageBase)
+		if !known {
+			kept = append(kept, up)
+			continue
+		}
+
+		co, coKnown := coMaintainers(rt, pkg.PackageBase)
+		coChanged := r.CoMaintainersChanged(co, coKnown)
+		if !r.MaintainerChanged(pkg) && !coChanged {
+			kept = append(kept, up)
+			continue
+		}
+
+		if !warned.Get(pkg.PackageBase) {
+			warned.Set(pkg.PackageBase)
+			c := maintainerChange{pkg: pkg, was: r}
+			if coChanged {
+				c.co = append([]string{}, co...)
+			}
+			changes = append(changes, c)
+		}
+		if rt.Config.TrustChanges {
+			kept = append(kept, up)
+		}
+	}
+
+	return kept, changes
+}
+
+// printMaintainerChanges warns about each change in detail.
+func printMaintainerChanges(changes []maintainerChange, trusted bool) {
+	for _, c := range changes {
+		warnMaintainerChange(c, trusted)
+	}
+}
+
+// printPausedUpgrades lists the package bases whose upgrade was paused on
+// stderr, so they do not silently go missing from update lists.
+func printPausedUpgrades(changes []maintainerChange, trusted bool) {
+	if trusted || len(changes) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(changes))
+	for _, c := range changes {
+		names = append(names, text.Cyan(c.pkg.PackageBase))
+	}
+	text.EPrintln(text.SprintWarn(text.T("Paused upgrades of AUR Packages with new maintainers:"), " "+strings.Join(names, "  ")))
+}
+
+func warnMaintainerChange(c maintainerChange, trusted bool) {
+	pkg, r := c.pkg, c.was
+	maintainer := func(name string) string {
+		if name == "" {
+			return text.T("orphan")
+		}
+		return name
+	}
+	coMaintainers := func(names []string) string {
+		if len(names) == 0 {
+			return text.T("none")
+		}
+		return strings.Join(names, " ")
+	}
+
+	if r.MaintainerChanged(pkg) {
+		text.Warnln(text.Bold(text.Red(text.Tf("%s changed maintainer since it was installed", pkg.PackageBase))))
+		text.Printf("    %s  %s (%s)\n", text.T("was:"), maintainer(r.Maintainer),
+			text.Tf("last submitted %s", text.FormatTime(int(r.LastModified))))
+		text.Printf("    %s  %s (%s)\n", text.T("now:"), maintainer(pkg.Maintainer),
+			text.Tf("last submitted %s", text.FormatTime(pkg.LastModified)))
+		text.Printf("    %s\n", text.Tf("first submitted %s", text.FormatTime(pkg.FirstSubmitted)))
+	}
+	if c.co != nil {
+		text.Warnln(text.Bold(text.Red(text.Tf("%s changed co-maintainers since it was installed", pkg.PackageBase))))
+		text.Printf("    %s  %s\n", text.T("was:"), coMaintainers(r.CoMaintainers))
+		text.Printf("    %s  %s\n", text.T("now:"), coMaintainers(c.co))
+	}
+
+	if !trusted {
+		text.Warnln(text.Tf("The upgrade of %s is paused, install it with -S or use --trustchanges to accept the new maintainers",
+			text.Cyan(pkg.PackageBase)))
+	}
+}
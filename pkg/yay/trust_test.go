@@ -0,0 +1,180 @@
+package yay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gosrc "github.com/Morganamilo/go-srcinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/dep"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+	"github.com/Jguer/yay/v10/pkg/tofu"
+	"github.com/Jguer/yay/v10/pkg/upgrade"
+)
+
+func TestCheckTrust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-trust")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srcinfo := func(key string) *gosrc.Srcinfo {
+		s, err := gosrc.Parse("pkgbase = foo\n\tpkgver = 1\n\tpkgrel = 1\n\tarch = any\n" +
+			"\tsource = https://example.org/foo.tar.gz\n\tvalidpgpkeys = " + key + "\n\tsha256sums = 1111\n\npkgname = foo\n")
+		require.NoError(t, err)
+		return s
+	}
+
+	rt := &Runtime{
+		Trust:  tofu.NewStore(filepath.Join(dir, trustFileName)),
+		Config: &settings.YayConfig{},
+	}
+	bases := []dep.Base{{&query.Pkg{Name: "foo", PackageBase: "foo"}}}
+
+	// the first install is trusted
+	first := map[string]*gosrc.Srcinfo{"foo": srcinfo("ABAF11C65A2970B130ABE3C479BE3E4300411886")}
+	require.NoError(t, checkTrust(rt, bases, first))
+	require.NoError(t, recordTrust(rt, bases, first))
+	assert.FileExists(t, filepath.Join(dir, trustFileName))
+
+	changed := map[string]*gosrc.Srcinfo{"foo": srcinfo("647F28654894E3BD457199BE38DBBDC86092693E")}
+
+	in := text.In()
+	defer func() { *text.InRef() = in }()
+
+	buf := new(bytes.Buffer)
+	for _, answer := range []string{"n\n", ""} {
+		*text.InRef() = strings.NewReader(answer)
+		text.CaptureOutput(buf, buf, func() {
+			err = checkTrust(rt, bases, changed)
+		})
+		assert.Error(t, err)
+	}
+	assert.Contains(t, buf.String(), "new PGP key 647F28654894E3BD457199BE38DBBDC86092693E")
+
+	*text.InRef() = strings.NewReader("y\n")
+	text.CaptureOutput(buf, buf, func() {
+		err = checkTrust(rt, bases, changed)
+	})
+	assert.NoError(t, err)
+
+	rt.Config.TrustChanges = true
+	*text.InRef() = strings.NewReader("")
+	text.CaptureOutput(buf, buf, func() {
+		err = checkTrust(rt, bases, changed)
+	})
+	assert.NoError(t, err)
+}
+
+func TestPauseMaintainerChanges(t *testing.T) {
+	rt := &Runtime{
+		Trust:  tofu.NewStore(""),
+		Config: &settings.YayConfig{},
+	}
+	rt.Trust.Set("foo", tofu.Record{Maintainer: "jguer", LastModified: 100})
+	rt.Trust.Set("bar", tofu.Record{Maintainer: "jguer", LastModified: 100})
+	// recorded before maintainers were
+	rt.Trust.Set("baz", tofu.Record{})
+
+	aurdata := map[string]*query.Pkg{
+		"foo":     {Name: "foo", PackageBase: "foo", Maintainer: "mallory", LastModified: 200},
+		"foo-lib": {Name: "foo-lib", PackageBase: "foo", Maintainer: "mallory", LastModified: 200},
+		"bar":     {Name: "bar", PackageBase: "bar", Maintainer: "jguer", LastModified: 200},
+		"baz":     {Name: "baz", PackageBase: "baz", Maintainer: "mallory", LastModified: 200},
+	}
+	ups := func() []upgrade.Upgrade {
+		return []upgrade.Upgrade{{Name: "foo"}, {Name: "foo-lib"}, {Name: "bar"}, {Name: "baz"}, {Name: "qux"}}
+	}
+	names := func(ups []upgrade.Upgrade) []string {
+		s := make([]string, 0, len(ups))
+		for _, up := range ups {
+			s = append(s, up.Name)
+		}
+		return s
+	}
+
+	buf := new(bytes.Buffer)
+	kept, changes := pauseMaintainerChanges(rt, ups(), aurdata)
+	text.CaptureOutput(buf, buf, func() {
+		printMaintainerChanges(changes, rt.Config.TrustChanges)
+	})
+	assert.Equal(t, []string{"bar", "baz", "qux"}, names(kept))
+	assert.Equal(t, 1, strings.Count(buf.String(), "foo changed maintainer"))
+	assert.Contains(t, buf.String(), "mallory")
+	assert.Contains(t, buf.String(), "paused")
+
+	rt.Config.TrustChanges = true
+	buf.Reset()
+	kept, changes = pauseMaintainerChanges(rt, ups(), aurdata)
+	text.CaptureOutput(buf, buf, func() {
+		printMaintainerChanges(changes, rt.Config.TrustChanges)
+	})
+	assert.Len(t, kept, 5)
+	assert.NotContains(t, buf.String(), "paused")
+}
+
+// coMaintainerAUR reports co-maintainers like the metadata dump.
+type coMaintainerAUR struct {
+	query.AURBackend
+	co map[string][]string
+}
+
+func (a coMaintainerAUR) CoMaintainers(pkgbase string) ([]string, bool) {
+	co, ok := a.co[pkgbase]
+	return co, ok
+}
+
+func TestPauseCoMaintainerChanges(t *testing.T) {
+	rt := &Runtime{
+		Trust:  tofu.NewStore(""),
+		Config: &settings.YayConfig{},
+		AUR: coMaintainerAUR{co: map[string][]string{
+			"foo": {"mallory", "alice"},
+			"bar": {"bob", "alice"},
+			"baz": {"mallory"},
+		}},
+	}
+	rt.Trust.Set("foo", tofu.Record{Maintainer: "jguer", LastModified: 100, CoMaintainers: []string{"alice"}})
+	rt.Trust.Set("bar", tofu.Record{Maintainer: "jguer", LastModified: 100, CoMaintainers: []string{"alice", "bob"}})
+	// recorded with the rpc backend
+	rt.Trust.Set("baz", tofu.Record{Maintainer: "jguer", LastModified: 100})
+
+	aurdata := map[string]*query.Pkg{}
+	for _, name := range []string{"foo", "bar", "baz"} {
+		aurdata[name] = &query.Pkg{Name: name, PackageBase: name, Maintainer: "jguer", LastModified: 200}
+	}
+
+	buf := new(bytes.Buffer)
+	kept, changes := pauseMaintainerChanges(rt, []upgrade.Upgrade{{Name: "foo"}, {Name: "bar"}, {Name: "baz"}}, aurdata)
+	text.CaptureOutput(buf, buf, func() {
+		printMaintainerChanges(changes, rt.Config.TrustChanges)
+	})
+	require.Len(t, kept, 2)
+	assert.Equal(t, "bar", kept[0].Name)
+	assert.Equal(t, "baz", kept[1].Name)
+	assert.Contains(t, buf.String(), "foo changed co-maintainers")
+	assert.NotContains(t, buf.String(), "changed maintainer")
+	assert.Contains(t, buf.String(), "mallory alice")
+
+	dir, err := ioutil.TempDir("", "yay-trust")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	rt.Trust.FilePath = filepath.Join(dir, trustFileName)
+
+	// installing foo records its new co-maintainers
+	srcinfo, err := gosrc.Parse("pkgbase = foo\n\tpkgver = 1\n\tpkgrel = 1\n\tarch = any\n\npkgname = foo\n")
+	require.NoError(t, err)
+	require.NoError(t, recordTrust(rt, []dep.Base{{aurdata["foo"]}}, map[string]*gosrc.Srcinfo{"foo": srcinfo}))
+	r, _ := rt.Trust.Get("foo")
+	assert.Equal(t, []string{"mallory", "alice"}, r.CoMaintainers)
+
+	kept, _ = pauseMaintainerChanges(rt, []upgrade.Upgrade{{Name: "foo"}}, aurdata)
+	assert.Len(t, kept, 1)
+}
@@ -12,8 +12,12 @@ import (
 	"github.com/Jguer/yay/v10/pkg/upgrade"
 )
 
-// upList returns lists of packages to upgrade from each source.
-func upList(warnings *query.AURWarnings, rt *Runtime, enableDowngrade bool) (aurUp, repoUp []upgrade.Upgrade, err error) {
+// upList returns lists of packages to upgrade from each source. Auto holds
+// are only placed and released with autoHold, which is set by sysupgrades
+// but not by the read-only update checks. AUR upgrades paused by a
+// maintainer change are left out and returned as changes.
+func upList(warnings *query.AURWarnings, rt *Runtime, enableDowngrade, autoHold bool) (aurUp, repoUp []upgrade.Upgrade,
+	changes []maintainerChange, err error) {
 	remote, remoteNames := query.GetRemotePackages(rt.DB)
 
 	var wg sync.WaitGroup
@@ -41,17 +45,25 @@ func upList(warnings *query.AURWarnings, rt *Runtime, enableDowngrade bool) (aur
 	if rt.Config.Mode == settings.ModeAny || rt.Config.Mode == settings.ModeAUR {
 		text.OperationInfoln(text.T("Searching AUR for updates..."))
 
-		var _aurdata []*query.Pkg
-		_aurdata, err = query.AURInfo(rt.AUR, remoteNames, warnings, rt.Config.RequestSplitN)
-		errs.Add(err)
-		if err == nil {
+		_aurdata, errInfo := query.AURInfo(rt.AURInfo, remoteNames, warnings, rt.Config.RequestSplitN)
+		// packages of failed requests are left out and reported through
+		// warnings.Failed, the others are still checked for upgrades
+		if len(_aurdata) == 0 {
+			errs.Add(errInfo)
+		}
+
+		if len(_aurdata) > 0 {
 			for _, pkg := range _aurdata {
 				aurdata[pkg.Name] = pkg
 			}
 
+			if autoHold && rt.Config.AutoHold && rt.Holds != nil {
+				errs.Add(autoHoldPackages(rt.Holds, warnings, aurdata))
+			}
+
 			wg.Add(1)
 			go func() {
-				aurUp = upgrade.UpAUR(remote, aurdata, rt.Config.TimeUpdate)
+				aurUp = upgrade.UpAUR(remote, aurdata, rt.Config.TimeUpdate, rt.Holds)
 				wg.Done()
 			}()
 
@@ -59,7 +71,7 @@ func upList(warnings *query.AURWarnings, rt *Runtime, enableDowngrade bool) (aur
 				text.OperationInfoln(text.T("Checking development packages..."))
 				wg.Add(1)
 				go func() {
-					develUp = upgrade.UpDevel(remote, aurdata, rt.VCSStore)
+					develUp = upgrade.UpDevel(remote, aurdata, rt.VCSStore, rt.Holds)
 					wg.Done()
 				}()
 			}
@@ -68,6 +80,17 @@ func upList(warnings *query.AURWarnings, rt *Runtime, enableDowngrade bool) (aur
 
 	wg.Wait()
 
+	for i := range aurUp {
+		aurUp[i].Repository = rt.sourceName(aurUp[i].Name)
+	}
+
+	// held repo packages are reported as ignored so they can be passed on
+	// to pacman
+	repoUp, held := upgrade.FilterHeld(repoUp, rt.Holds)
+	for _, name := range held {
+		warnings.Ignore.Set(name)
+	}
+
 	upgrade.PrintLocalNewerThanAUR(remote, aurdata)
 
 	if develUp != nil {
@@ -84,7 +107,9 @@ func upList(warnings *query.AURWarnings, rt *Runtime, enableDowngrade bool) (aur
 		aurUp = develUp
 	}
 
-	return aurUp, repoUp, errs.Return()
+	aurUp, changes = pauseMaintainerChanges(rt, aurUp, aurdata)
+
+	return aurUp, repoUp, changes, errs.Return()
 }
 
 // createDevelDB forces yay to create a DB of the existing development packages
@@ -104,7 +129,7 @@ func createDevelDB(rt *Runtime) error {
 
 	bases := dep.GetBases(info)
 	toSkip := pkgbuildsToSkip(bases, stringset.Make(remoteNames...), rt.Config.ReDownload, rt.Config.BuildDir)
-	_, err = downloadPkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, bases, toSkip, rt.Config.BuildDir, rt.Config.AURURL)
+	_, err = downloadPkgbuilds(buildRun{rt.GitBuilder, rt.CmdRunner}, bases, toSkip, rt.Config.BuildDir, rt.Sources)
 	if err != nil {
 		return err
 	}
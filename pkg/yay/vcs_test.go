@@ -0,0 +1,150 @@
+package yay
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/db"
+	"github.com/Jguer/yay/v10/pkg/db/mock"
+	"github.com/Jguer/yay/v10/pkg/hold"
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+	"github.com/Jguer/yay/v10/pkg/tofu"
+	"github.com/Jguer/yay/v10/pkg/upgrade"
+)
+
+// upListAUR answers info requests from pkgs and fails requests for broken.
+type upListAUR struct {
+	pkgs   map[string]query.Pkg
+	broken string
+}
+
+func (a upListAUR) Info(names []string) ([]query.Pkg, error) {
+	info := make([]query.Pkg, 0, len(names))
+	for _, name := range names {
+		if name == a.broken {
+			return nil, errors.New("connection reset")
+		}
+		if pkg, ok := a.pkgs[name]; ok {
+			info = append(info, pkg)
+		}
+	}
+	return info, nil
+}
+
+func upListRuntime(t *testing.T, aur upListAUR) *Runtime {
+	dir, err := ioutil.TempDir("", "yay-uplist")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	local := make([]db.IPackage, 0, len(aur.pkgs))
+	for _, name := range []string{"foo", "bar", "baz"} {
+		local = append(local, &mock.Package{PName: name, PBase: name, PVersion: "1.0-1"})
+	}
+
+	return &Runtime{
+		DB:      &mock.DBMock{Local: local},
+		AURInfo: aur,
+		Holds:   hold.NewStore(filepath.Join(dir, "holds.json")),
+		Config: &settings.YayConfig{
+			Mode: settings.ModeAUR,
+			PersistentYayConfig: settings.PersistentYayConfig{
+				AutoHold:      true,
+				RequestSplitN: 1,
+			},
+		},
+	}
+}
+
+func TestUpListAutoHold(t *testing.T) {
+	aur := upListAUR{pkgs: map[string]query.Pkg{
+		"foo": {Name: "foo", PackageBase: "foo", Version: "2.0-1"},
+		"bar": {Name: "bar", PackageBase: "bar", Version: "2.0-1", Maintainer: "alice"},
+		"baz": {Name: "baz", PackageBase: "baz", Version: "2.0-1", Maintainer: "alice"},
+	}}
+
+	for _, autoHold := range []bool{false, true} {
+		rt := upListRuntime(t, aur)
+
+		var err error
+		text.CaptureOutput(nil, nil, func() {
+			_, _, _, err = upList(query.NewWarnings(), rt, false, autoHold)
+		})
+		require.NoError(t, err)
+
+		_, statErr := os.Stat(rt.Holds.FilePath)
+		assert.Equal(t, autoHold, statErr == nil, "holds written with autoHold %v", autoHold)
+	}
+}
+
+func TestUpListPartialInfo(t *testing.T) {
+	aur := upListAUR{broken: "baz", pkgs: map[string]query.Pkg{
+		"foo": {Name: "foo", PackageBase: "foo", Version: "2.0-1", Maintainer: "alice"},
+		"bar": {Name: "bar", PackageBase: "bar", Version: "1.0-1", Maintainer: "alice"},
+	}}
+	rt := upListRuntime(t, aur)
+	warnings := query.NewWarnings()
+
+	var (
+		aurUp []upgrade.Upgrade
+		err   error
+	)
+	text.CaptureOutput(nil, nil, func() {
+		aurUp, _, _, err = upList(warnings, rt, false, false)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, aurUp, 1)
+	assert.Equal(t, "foo", aurUp[0].Name)
+	assert.Equal(t, []string{"baz"}, warnings.Failed)
+
+	// nothing to show when every request failed
+	rt = upListRuntime(t, upListAUR{broken: "foo"})
+	rt.Config.RequestSplitN = 3
+	text.CaptureOutput(nil, nil, func() {
+		_, _, _, err = upList(query.NewWarnings(), rt, false, false)
+	})
+	assert.Error(t, err)
+}
+
+func TestUpListPausedChanges(t *testing.T) {
+	aur := upListAUR{pkgs: map[string]query.Pkg{
+		"foo": {Name: "foo", PackageBase: "foo", Version: "2.0-1", Maintainer: "mallory", LastModified: 200},
+		"bar": {Name: "bar", PackageBase: "bar", Version: "2.0-1", Maintainer: "alice"},
+	}}
+	rt := upListRuntime(t, aur)
+	rt.Config.AutoHold = false
+	rt.Trust = tofu.NewStore("")
+	rt.Trust.Set("foo", tofu.Record{Maintainer: "alice", LastModified: 100})
+
+	var (
+		aurUp   []upgrade.Upgrade
+		changes []maintainerChange
+		err     error
+	)
+	text.CaptureOutput(nil, nil, func() {
+		aurUp, _, changes, err = upList(query.NewWarnings(), rt, false, false)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, aurUp, 1)
+	assert.Equal(t, "bar", aurUp[0].Name)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "foo", changes[0].pkg.PackageBase)
+
+	// update checks report the paused bases on stderr
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	text.CaptureOutput(stdout, stderr, func() {
+		printPausedUpgrades(changes, false)
+	})
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "foo")
+}
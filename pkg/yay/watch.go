@@ -0,0 +1,189 @@
+package yay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Jguer/yay/v10/pkg/query"
+	"github.com/Jguer/yay/v10/pkg/text"
+	"github.com/Jguer/yay/v10/pkg/upgrade"
+)
+
+// watchDBDir is the directory below the cache dir holding the private copy
+// of the sync databases used by --watch.
+const watchDBDir = "watchdb"
+
+type watchUpgrade struct {
+	Name          string `json:"name"`
+	Repository    string `json:"repository"`
+	LocalVersion  string `json:"localversion"`
+	RemoteVersion string `json:"remoteversion"`
+}
+
+type watchResult struct {
+	Time     int64          `json:"time"`
+	Upgrades []watchUpgrade `json:"upgrades"`
+}
+
+// initWatchDB prepares dbPath to be used in place of the system database
+// path. The local database is linked, the sync databases are copied once to
+// avoid downloading them in full on the first refresh.
+func initWatchDB(sysDBPath, dbPath string) error {
+	syncPath := filepath.Join(dbPath, "sync")
+	if err := os.MkdirAll(syncPath, 0o755); err != nil {
+		return err
+	}
+
+	localPath := filepath.Join(dbPath, "local")
+	if _, err := os.Lstat(localPath); os.IsNotExist(err) {
+		if err := os.Symlink(filepath.Join(sysDBPath, "local"), localPath); err != nil {
+			return err
+		}
+	}
+
+	sysDBs, err := filepath.Glob(filepath.Join(sysDBPath, "sync", "*.db"))
+	if err != nil {
+		return err
+	}
+
+	for _, sysDB := range sysDBs {
+		target := filepath.Join(syncPath, filepath.Base(sysDB))
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			continue
+		}
+		if err := copyFile(sysDB, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// refreshWatchDB syncs the databases in dbPath the same way checkupdates
+// does. fakeroot lets pacman refresh them without root privileges.
+func refreshWatchDB(rt *Runtime, dbPath string) error {
+	cmd := exec.Command("fakeroot", "--", rt.Config.PacmanBin,
+		"-Sy", "--config", rt.Config.PacmanConf, "--dbpath", dbPath, "--logfile", "/dev/null")
+
+	_, stderr, err := rt.CmdRunner.Capture(cmd, 0)
+	if err != nil {
+		return errors.New(text.Tf("error refreshing databases: %s", stderr))
+	}
+
+	return rt.DB.RefreshHandle()
+}
+
+func checkWatchUpgrades(rt *Runtime, dbPath string) ([]upgrade.Upgrade, error) {
+	if err := refreshWatchDB(rt, dbPath); err != nil {
+		return nil, err
+	}
+
+	var (
+		aurUp   []upgrade.Upgrade
+		repoUp  []upgrade.Upgrade
+		changes []maintainerChange
+		err     error
+	)
+
+	warnings := query.NewWarnings()
+	text.CaptureOutput(nil, nil, func() {
+		aurUp, repoUp, changes, err = upList(warnings, rt, false, false)
+	})
+	warnings.PrintFailed()
+	printPausedUpgrades(changes, rt.Config.TrustChanges)
+
+	return append(repoUp, aurUp...), err
+}
+
+func notifyWatch(rt *Runtime, ups []upgrade.Upgrade) error {
+	if rt.Config.WatchCmd == "" {
+		result := watchResult{Time: time.Now().Unix(), Upgrades: make([]watchUpgrade, 0, len(ups))}
+		for _, up := range ups {
+			result.Upgrades = append(result.Upgrades, watchUpgrade(up))
+		}
+
+		line, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		text.Println(string(line))
+		return nil
+	}
+
+	if len(ups) == 0 {
+		return nil
+	}
+
+	body := make([]string, 0, len(ups))
+	for _, up := range ups {
+		body = append(body, fmt.Sprintf("%s %s -> %s", up.Name, up.LocalVersion, up.RemoteVersion))
+	}
+
+	// the command is run by the shell so it may quote its arguments, the
+	// summary and the upgrades are passed on as the last two
+	cmd := exec.Command("sh", "-c", rt.Config.WatchCmd+` "$@"`, "sh",
+		text.Tf("%d upgrades available", len(ups)), strings.Join(body, "\n"))
+
+	_, stderr, err := rt.CmdRunner.Capture(cmd, 0)
+	if err != nil {
+		return fmt.Errorf("%s %s", stderr, err)
+	}
+	return nil
+}
+
+// watchUpgrades checks for repo, AUR and devel upgrades using a private copy
+// of the sync databases and reports them as JSON lines or through WatchCmd.
+// With a WatchInterval of 0 or less it checks once, which suits systemd timers.
+func watchUpgrades(rt *Runtime) error {
+	sysDBPath := rt.Pacman.DBPath
+	dbPath := filepath.Join(rt.Config.CacheDir, watchDBDir)
+
+	if err := initWatchDB(sysDBPath, dbPath); err != nil {
+		return err
+	}
+
+	// the executor shares this config and picks the path up on refresh
+	rt.Pacman.DBPath = dbPath
+
+	for {
+		ups, err := checkWatchUpgrades(rt, dbPath)
+		if err == nil {
+			err = notifyWatch(rt, ups)
+		}
+
+		if rt.Config.WatchInterval <= 0 {
+			return err
+		}
+		if err != nil {
+			text.Errorln(err)
+		}
+
+		time.Sleep(time.Duration(rt.Config.WatchInterval) * time.Minute)
+	}
+}
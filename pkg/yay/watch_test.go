@@ -0,0 +1,77 @@
+package yay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Jguer/yay/v10/pkg/exe"
+	"github.com/Jguer/yay/v10/pkg/settings"
+	"github.com/Jguer/yay/v10/pkg/text"
+	"github.com/Jguer/yay/v10/pkg/upgrade"
+)
+
+func TestInitWatchDB(t *testing.T) {
+	sysDBPath, err := ioutil.TempDir("", "yay-sysdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(sysDBPath)
+	dbPath, err := ioutil.TempDir("", "yay-watchdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dbPath)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(sysDBPath, "local"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(sysDBPath, "sync"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sysDBPath, "sync", "core.db"), []byte("core"), 0o644))
+
+	require.NoError(t, initWatchDB(sysDBPath, dbPath))
+	// a second run must not fail on the existing link and copies
+	require.NoError(t, initWatchDB(sysDBPath, dbPath))
+
+	link, err := os.Readlink(filepath.Join(dbPath, "local"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(sysDBPath, "local"), link)
+
+	content, err := ioutil.ReadFile(filepath.Join(dbPath, "sync", "core.db"))
+	require.NoError(t, err)
+	assert.Equal(t, "core", string(content))
+}
+
+func TestNotifyWatchJSON(t *testing.T) {
+	rt := &Runtime{Config: &settings.YayConfig{}}
+	ups := []upgrade.Upgrade{{Name: "yay", Repository: "aur", LocalVersion: "1.0-1", RemoteVersion: "1.1-1"}}
+
+	buf := new(bytes.Buffer)
+	text.CaptureOutput(buf, nil, func() {
+		require.NoError(t, notifyWatch(rt, ups))
+	})
+
+	assert.Regexp(t,
+		`^\{"time":\d+,"upgrades":\[\{"name":"yay","repository":"aur","localversion":"1.0-1","remoteversion":"1.1-1"\}\]\}\n$`,
+		buf.String())
+}
+
+func TestNotifyWatchCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yay-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "out")
+	rt := &Runtime{
+		CmdRunner: &exe.OSRunner{},
+		Config: &settings.YayConfig{PersistentYayConfig: settings.PersistentYayConfig{
+			WatchCmd: `printf '%s|' "AUR updates" >` + out,
+		}},
+	}
+	ups := []upgrade.Upgrade{{Name: "yay", Repository: "aur", LocalVersion: "1.0-1", RemoteVersion: "1.1-1"}}
+
+	require.NoError(t, notifyWatch(rt, ups))
+
+	content, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "AUR updates|1 upgrades available|yay 1.0-1 -> 1.1-1|", string(content))
+}